@@ -19,6 +19,9 @@ package bpgroup
 
 import (
 	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
 
 	"github.com/jstuczyn/amcl/version3/go/amcl"
 	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
@@ -27,12 +30,28 @@ import (
 // todo: consider replacing attributes with getters?
 // todo: how many bytes of entropy
 
-// BpGroup represents data required for a bilinear pairing
+// Generators are the same for all groups, hence so are their precomputation tables.
+// They are built on the first use.
+var (
+	genTablesOnce sync.Once
+	gen1Table     *G1Table
+	gen2Table     *G2Table
+)
+
+func buildGenTables() {
+	gen1Table = NewG1Table(Curve.ECP_generator())
+	gen2Table = NewG2Table(Curve.ECP2_generator())
+}
+
+// BpGroup represents data required for a bilinear pairing.
+// It is safe for concurrent use by multiple goroutines.
 type BpGroup struct {
 	gen1 *Curve.ECP
 	gen2 *Curve.ECP2
 	ord  *Curve.BIG
-	rng  *amcl.RAND
+
+	rngMu sync.Mutex // protects rng
+	rng   *amcl.RAND
 }
 
 // Gen1 returns generator for G1
@@ -45,14 +64,38 @@ func (b *BpGroup) Gen2() *Curve.ECP2 {
 	return b.gen2
 }
 
+// Gen1Mul returns e * Gen1 computed using precomputation table.
+func (b *BpGroup) Gen1Mul(e *Curve.BIG) *Curve.ECP {
+	genTablesOnce.Do(buildGenTables)
+	return gen1Table.Mul(e)
+}
+
+// Gen2Mul returns e * Gen2 computed using precomputation table.
+func (b *BpGroup) Gen2Mul(e *Curve.BIG) *Curve.ECP2 {
+	genTablesOnce.Do(buildGenTables)
+	return gen2Table.Mul(e)
+}
+
 // Order returns order of the group
 func (b *BpGroup) Order() *Curve.BIG {
 	return b.ord
 }
 
-// Rng returns instance of random number generator
+// Rng returns a new instance of random number generator seeded from the group's own generator.
+// It is safe to call concurrently, however, the returned generator itself is not
+// and hence must not be shared between goroutines.
+// For deterministic groups the returned generators are deterministic as long as the calls are made in the same order.
 func (b *BpGroup) Rng() *amcl.RAND {
-	return b.rng
+	seed := make([]byte, childSeedLen)
+	b.rngMu.Lock()
+	for i := range seed {
+		seed[i] = b.rng.GetByte()
+	}
+	b.rngMu.Unlock()
+
+	rng := amcl.NewRAND()
+	rng.Seed(childSeedLen, seed)
+	return rng
 }
 
 // Pair performs the bilinear pairing operation e(G1, G2) -> GT
@@ -60,18 +103,96 @@ func (b *BpGroup) Pair(g1 *Curve.ECP, g2 *Curve.ECP2) *Curve.FP12 {
 	return Curve.Fexp(Curve.Ate(g2, g1))
 }
 
-// New returns a new instance of a BpGroup
-func New() *BpGroup {
-	rng := amcl.NewRAND()
+// MultiPair computes product of pairings e(g1s[0], g2s[0]) * e(g1s[1], g2s[1]) * ...
+// It accumulates the Miller loops of all the pairs and performs only a single final exponentiation,
+// which is considerably cheaper than multiplying results of Pair.
+// It returns nil if the numbers of G1 and G2 elements differ.
+func (b *BpGroup) MultiPair(g1s []*Curve.ECP, g2s []*Curve.ECP2) *Curve.FP12 {
+	if len(g1s) != len(g2s) {
+		return nil
+	}
+
+	// pairs involving point at infinity contribute identity to the product.
+	// Is_infinity normalises the point in place, hence it is called on copies
+	// to avoid writing to points owned and possibly shared by the caller.
+	ps := make([]*Curve.ECP, 0, len(g1s))
+	qs := make([]*Curve.ECP2, 0, len(g2s))
+	for i := range g1s {
+		p := Curve.NewECP()
+		p.Copy(g1s[i])
+		q := Curve.NewECP2()
+		q.Copy(g2s[i])
+		if !p.Is_infinity() && !q.Is_infinity() {
+			ps = append(ps, p)
+			qs = append(qs, q)
+		}
+	}
 
-	// amcl suggests using at least 128 bytes of entropy.
-	// todo: is 256 enough for our needs?
-	n := 256
-	raw, err := generateRandomBytes(n)
+	acc := Curve.NewFP12int(1)
+	i := 0
+	for ; i+1 < len(ps); i += 2 {
+		acc.Mul(Curve.Ate2(qs[i], ps[i], qs[i+1], ps[i+1]))
+	}
+	if i < len(ps) {
+		acc.Mul(Curve.Ate(qs[i], ps[i]))
+	}
+	return Curve.Fexp(acc)
+}
+
+// PairingCheck checks whether the product of pairings e(g1s[0], g2s[0]) * e(g1s[1], g2s[1]) * ... equals 1.
+// To check whether e(A, B) == e(C, D), it should be called with (A, -C) and (B, D).
+// It returns false if the numbers of G1 and G2 elements differ.
+func (b *BpGroup) PairingCheck(g1s []*Curve.ECP, g2s []*Curve.ECP2) bool {
+	gt := b.MultiPair(g1s, g2s)
+	return gt != nil && gt.Isunity()
+}
+
+// amcl suggests using at least 128 bytes of entropy.
+// todo: is 256 enough for our needs?
+const entropyLen = 256
+
+// childSeedLen is the number of bytes drawn from the group's generator to seed each generator returned by Rng.
+const childSeedLen = 128
+
+var (
+	// ErrEmptySeed indicates that a deterministic group was requested without providing any seed.
+	ErrEmptySeed = errors.New("Seed for deterministic group can't be empty")
+)
+
+// New returns a new instance of a BpGroup with random number generator seeded from crypto/rand.
+// It panics if the system entropy source fails; use NewWithReader to handle such failure instead.
+func New() *BpGroup {
+	b, err := NewWithReader(rand.Reader)
 	if err != nil {
 		panic(err)
 	}
-	rng.Seed(n, raw)
+	return b
+}
+
+// NewWithReader returns a new instance of a BpGroup with random number generator seeded
+// with entropy read from r. It returns an error if r fails to provide sufficient amount of data.
+func NewWithReader(r io.Reader) (*BpGroup, error) {
+	raw := make([]byte, entropyLen)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, err
+	}
+	return newWithSeed(raw), nil
+}
+
+// NewDeterministic returns a new instance of a BpGroup with random number generator
+// seeded solely with the provided seed. Groups created with the same seed produce identical
+// sequences of random numbers, which allows replaying exact protocol runs.
+// It must never be used outside tests and generation of test vectors.
+func NewDeterministic(seed []byte) (*BpGroup, error) {
+	if len(seed) == 0 {
+		return nil, ErrEmptySeed
+	}
+	return newWithSeed(seed), nil
+}
+
+func newWithSeed(seed []byte) *BpGroup {
+	rng := amcl.NewRAND()
+	rng.Seed(len(seed), seed)
 
 	b := BpGroup{
 		gen1: Curve.ECP_generator(),
@@ -81,14 +202,3 @@ func New() *BpGroup {
 	}
 	return &b
 }
-
-// Returns slice of bytes of specified size of cryptographically secure random numbers.
-// Refer to https://golang.org/pkg/crypto/rand/ for details regarding sources of entropy
-func generateRandomBytes(n int) ([]byte, error) {
-	b := make([]byte, n)
-	_, err := rand.Read(b)
-	if err != nil {
-		return nil, err
-	}
-	return b, nil
-}
@@ -16,6 +16,9 @@
 package bpgroup_test
 
 import (
+	"bytes"
+	"errors"
+	"sync"
 	"testing"
 
 	"github.com/jstuczyn/CoconutGo/bpgroup"
@@ -74,6 +77,104 @@ func TestPairing(t *testing.T) {
 	assert.True(t, gt1.Equals(gt2), "e(aP, bQ) != e(P, Q)^ab")
 }
 
+func TestMultiPair(t *testing.T) {
+	G := bpgroup.New()
+
+	n := 5
+	g1s := make([]*Curve.ECP, n)
+	g2s := make([]*Curve.ECP2, n)
+	expected := Curve.NewFP12int(1)
+	for i := 0; i < n; i++ {
+		g1s[i] = Curve.G1mul(G.Gen1(), Curve.Randomnum(G.Order(), G.Rng()))
+		g2s[i] = Curve.G2mul(G.Gen2(), Curve.Randomnum(G.Order(), G.Rng()))
+		expected.Mul(G.Pair(g1s[i], g2s[i]))
+		// check both even and odd number of pairs
+		assert.True(t, expected.Equals(G.MultiPair(g1s[:i+1], g2s[:i+1])))
+	}
+
+	assert.True(t, G.MultiPair(nil, nil).Isunity())
+	assert.Nil(t, G.MultiPair(g1s, g2s[1:]))
+	assert.False(t, G.PairingCheck(g1s, g2s[1:]))
+
+	// points at infinity do not affect the result
+	withInf := G.MultiPair(append(g1s, Curve.NewECP()), append(g2s, G.Gen2()))
+	assert.True(t, expected.Equals(withInf))
+
+	// e(aP, Q) * e(-P, aQ) == 1
+	a := Curve.Randomnum(G.Order(), G.Rng())
+	negP := Curve.NewECP()
+	negP.Sub(G.Gen1())
+	assert.True(t, G.PairingCheck(
+		[]*Curve.ECP{Curve.G1mul(G.Gen1(), a), negP},
+		[]*Curve.ECP2{G.Gen2(), Curve.G2mul(G.Gen2(), a)},
+	))
+	assert.False(t, G.PairingCheck(
+		[]*Curve.ECP{Curve.G1mul(G.Gen1(), a), negP},
+		[]*Curve.ECP2{G.Gen2(), G.Gen2()},
+	))
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("entropy source failure")
+}
+
+func TestNewWithReader(t *testing.T) {
+	_, err := bpgroup.NewWithReader(failingReader{})
+	assert.NotNil(t, err, "Should return error of the entropy source")
+
+	_, err = bpgroup.NewWithReader(bytes.NewReader(make([]byte, 10)))
+	assert.NotNil(t, err, "Should require sufficient amount of entropy")
+
+	entropy := bytes.Repeat([]byte{42}, 1024)
+	G1, err := bpgroup.NewWithReader(bytes.NewReader(entropy))
+	assert.Nil(t, err)
+	G2, err := bpgroup.NewWithReader(bytes.NewReader(entropy))
+	assert.Nil(t, err)
+	assert.Zero(t, Curve.Comp(Curve.Randomnum(G1.Order(), G1.Rng()), Curve.Randomnum(G2.Order(), G2.Rng())))
+}
+
+func TestNewDeterministic(t *testing.T) {
+	_, err := bpgroup.NewDeterministic(nil)
+	assert.Equal(t, bpgroup.ErrEmptySeed, err)
+
+	G1, err := bpgroup.NewDeterministic([]byte("seed"))
+	assert.Nil(t, err)
+	G2, err := bpgroup.NewDeterministic([]byte("seed"))
+	assert.Nil(t, err)
+	G3, err := bpgroup.NewDeterministic([]byte("other seed"))
+	assert.Nil(t, err)
+
+	for i := 0; i < 5; i++ {
+		x1 := Curve.Randomnum(G1.Order(), G1.Rng())
+		x2 := Curve.Randomnum(G2.Order(), G2.Rng())
+		x3 := Curve.Randomnum(G3.Order(), G3.Rng())
+		assert.Zero(t, Curve.Comp(x1, x2), "Groups with the same seed should produce the same numbers")
+		assert.NotZero(t, Curve.Comp(x1, x3), "Groups with different seeds should produce different numbers")
+	}
+}
+
+func TestConcurrentRng(t *testing.T) {
+	G := bpgroup.New()
+	var wg sync.WaitGroup
+	results := make([]*Curve.BIG, 16)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = Curve.Randomnum(G.Order(), G.Rng())
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range results {
+		for j := i + 1; j < len(results); j++ {
+			assert.NotZero(t, Curve.Comp(results[i], results[j]), "Generators should not repeat outputs")
+		}
+	}
+}
+
 var g1Mulres *Curve.ECP
 
 func BenchmarkG1Mul(b *testing.B) {
@@ -112,6 +213,26 @@ func BenchmarkG2Mul(b *testing.B) {
 	g2MulRes = res
 }
 
+var pairingCheckRes bool
+
+func BenchmarkPairingCheck(b *testing.B) {
+	G := bpgroup.New()
+	var res bool
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		r := Curve.Randomnum(G.Order(), G.Rng())
+		rg1 := Curve.G1mul(G.Gen1(), r)
+		neg := Curve.NewECP()
+		neg.Sub(rg1)
+		rg2 := Curve.G2mul(G.Gen2(), r)
+		b.StartTimer()
+		res = G.PairingCheck([]*Curve.ECP{rg1, neg}, []*Curve.ECP2{rg2, rg2})
+	}
+	// it is recommended to store results in package level variables,
+	// so that compiler would not try to optimize the benchmark
+	pairingCheckRes = res
+}
+
 var pairRes *Curve.FP12
 
 func BenchmarkPairing(b *testing.B) {
@@ -132,3 +253,14 @@ func BenchmarkPairing(b *testing.B) {
 	// so that compiler would not try to optimize the benchmark
 	pairRes = res
 }
+
+func TestCurve(t *testing.T) {
+	G := bpgroup.New()
+	assert.Equal(t, bpgroup.CurrentCurve(), G.Curve())
+	if Curve.MODBYTES == 32 {
+		assert.Equal(t, bpgroup.BN254, G.Curve())
+	} else {
+		assert.Equal(t, bpgroup.BLS381, G.Curve())
+	}
+	assert.Equal(t, "unknown", bpgroup.CurveID(0).String())
+}
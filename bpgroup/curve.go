@@ -0,0 +1,78 @@
+// curve.go - identification of the underlying curve
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package bpgroup
+
+import (
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// CurveID identifies the pairing-friendly curve the library was built against.
+// The curve is selected at build time with changecurve.sh, so all BpGroups of a binary share it.
+type CurveID byte
+
+const (
+	// BN254 identifies the BN254 curve used by the Python implementation.
+	BN254 CurveID = 1
+
+	// BLS381 identifies the BLS12-381 curve.
+	BLS381 CurveID = 2
+)
+
+// String returns name of the curve.
+func (c CurveID) String() string {
+	switch c {
+	case BN254:
+		return "BN254"
+	case BLS381:
+		return "BLS381"
+	default:
+		return "unknown"
+	}
+}
+
+// moduli of the supported curves, as formatted by BIG.ToString
+const (
+	bn254Modulus  = "2523648240000001ba344d80000000086121000000000013a700000000000013"
+	bls381Modulus = "1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab"
+)
+
+// currentCurve is determined once, so that building against an unsupported curve fails on initialisation
+// rather than producing data tagged with the wrong curve.
+var currentCurve = detectCurve()
+
+// detectCurve identifies the curve the library was built against by its field modulus.
+// It panics if the curve is neither BN254 nor BLS381.
+func detectCurve() CurveID {
+	switch Curve.NewBIGints(Curve.Modulus).ToString() {
+	case bn254Modulus:
+		return BN254
+	case bls381Modulus:
+		return BLS381
+	default:
+		panic("bpgroup: unsupported curve")
+	}
+}
+
+// CurrentCurve returns identifier of the curve the library was built against.
+func CurrentCurve() CurveID {
+	return currentCurve
+}
+
+// Curve returns identifier of the curve the group is defined over.
+func (b *BpGroup) Curve() CurveID {
+	return CurrentCurve()
+}
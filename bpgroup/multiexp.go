@@ -0,0 +1,257 @@
+// multiexp.go - multi-scalar multiplication
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package bpgroup
+
+import (
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// Small number of points is handled with Straus' interleaved windowed method,
+// which shares the doublings between all the points.
+// From pippengerThreshold points onwards Pippenger's bucket method is used instead,
+// as its cost per point does not depend on the window size.
+// Neither of them is constant-time, hence G1MultiMul and G2MultiMul must only be used with public scalars,
+// such as during verification. Sums involving secret scalars should use G1MulSum and G2MulSum instead.
+const pippengerThreshold = 128
+
+// amcl multiplies points on the G2 curve using 4-dimensional GLS decomposition,
+// so for very few points it is faster to just add results of G2mul.
+const g2StrausThreshold = 6
+
+// pippengerWindow returns window size for Pippenger's method for n points.
+func pippengerWindow(n int) int {
+	c := 0
+	for ; n > 1; n >>= 1 {
+		c++
+	}
+	// c ~ log2(n) - 2 balances number of bucket additions against number of windows
+	if c -= 2; c < windowBits {
+		c = windowBits
+	}
+	return c
+}
+
+// G1MultiMul computes es[0] * ps[0] + es[1] * ps[1] + ... for points on the G1 curve.
+// The points must be elements of the prime order subgroup.
+// It returns nil if the numbers of points and scalars differ.
+func G1MultiMul(ps []*Curve.ECP, es []*Curve.BIG) *Curve.ECP {
+	if len(ps) != len(es) {
+		return nil
+	}
+	if len(ps) < pippengerThreshold {
+		return g1Straus(ps, es)
+	}
+	return g1Pippenger(ps, es, pippengerWindow(len(ps)))
+}
+
+// G2MultiMul computes es[0] * ps[0] + es[1] * ps[1] + ... for points on the G2 curve.
+// The points must be elements of the prime order subgroup.
+// It returns nil if the numbers of points and scalars differ.
+func G2MultiMul(ps []*Curve.ECP2, es []*Curve.BIG) *Curve.ECP2 {
+	if len(ps) != len(es) {
+		return nil
+	}
+	if len(ps) < g2StrausThreshold {
+		acc := Curve.NewECP2()
+		for i := range ps {
+			acc.Add(Curve.G2mul(ps[i], es[i]))
+		}
+		return acc
+	}
+	if len(ps) < pippengerThreshold {
+		return g2Straus(ps, es)
+	}
+	return g2Pippenger(ps, es, pippengerWindow(len(ps)))
+}
+
+// G1MulSum computes es[0] * ps[0] + es[1] * ps[1] + ... for points on the G1 curve
+// using the constant-time multiplication of amcl for each of the points.
+// Unlike G1MultiMul it is suitable for secret scalars.
+// It returns nil if the numbers of points and scalars differ.
+func G1MulSum(ps []*Curve.ECP, es []*Curve.BIG) *Curve.ECP {
+	if len(ps) != len(es) {
+		return nil
+	}
+	acc := Curve.NewECP()
+	for i := range ps {
+		acc.Add(Curve.G1mul(ps[i], es[i]))
+	}
+	return acc
+}
+
+// G2MulSum computes es[0] * ps[0] + es[1] * ps[1] + ... for points on the G2 curve
+// using the constant-time multiplication of amcl for each of the points.
+// Unlike G2MultiMul it is suitable for secret scalars.
+// It returns nil if the numbers of points and scalars differ.
+func G2MulSum(ps []*Curve.ECP2, es []*Curve.BIG) *Curve.ECP2 {
+	if len(ps) != len(es) {
+		return nil
+	}
+	acc := Curve.NewECP2()
+	for i := range ps {
+		acc.Add(Curve.G2mul(ps[i], es[i]))
+	}
+	return acc
+}
+
+// g1Double doubles P in place. amcl does not export doubling, but its addition uses complete formulas.
+func g1Double(P *Curve.ECP) {
+	Q := Curve.NewECP()
+	Q.Copy(P)
+	P.Add(Q)
+}
+
+func g1Straus(ps []*Curve.ECP, es []*Curve.BIG) *Curve.ECP {
+	multiples := make([][]*Curve.ECP, len(ps))
+	digits := make([][]int, len(ps))
+	for i := range ps {
+		// multiples[i][d] = d * ps[i]
+		multiples[i] = make([]*Curve.ECP, windowSize)
+		multiples[i][0] = Curve.NewECP()
+		for d := 1; d < windowSize; d++ {
+			multiples[i][d] = Curve.NewECP()
+			multiples[i][d].Copy(multiples[i][d-1])
+			multiples[i][d].Add(ps[i])
+		}
+		digits[i] = scalarWindows(es[i], windowBits)
+	}
+
+	acc := Curve.NewECP()
+	for j := numWindows - 1; j >= 0; j-- {
+		for k := 0; k < windowBits; k++ {
+			g1Double(acc)
+		}
+		for i := range ps {
+			if d := digits[i][j]; d != 0 {
+				acc.Add(multiples[i][d])
+			}
+		}
+	}
+	return acc
+}
+
+func g1Pippenger(ps []*Curve.ECP, es []*Curve.BIG, c int) *Curve.ECP {
+	digits := make([][]int, len(ps))
+	for i := range es {
+		digits[i] = scalarWindows(es[i], c)
+	}
+
+	acc := Curve.NewECP()
+	for j := (orderBits+c-1)/c - 1; j >= 0; j-- {
+		for k := 0; k < c; k++ {
+			g1Double(acc)
+		}
+
+		buckets := make([]*Curve.ECP, 1<<uint(c))
+		for i := range ps {
+			d := digits[i][j]
+			if d == 0 {
+				continue
+			}
+			if buckets[d] == nil {
+				buckets[d] = Curve.NewECP()
+			}
+			buckets[d].Add(ps[i])
+		}
+
+		// sum(d * buckets[d]) = buckets[max] + (buckets[max] + buckets[max-1]) + ...
+		sum := Curve.NewECP()
+		total := Curve.NewECP()
+		for d := len(buckets) - 1; d > 0; d-- {
+			if buckets[d] != nil {
+				sum.Add(buckets[d])
+			}
+			total.Add(sum)
+		}
+		acc.Add(total)
+	}
+	return acc
+}
+
+// g2Double doubles P in place. amcl does not export doubling, but its addition uses complete formulas.
+func g2Double(P *Curve.ECP2) {
+	Q := Curve.NewECP2()
+	Q.Copy(P)
+	P.Add(Q)
+}
+
+func g2Straus(ps []*Curve.ECP2, es []*Curve.BIG) *Curve.ECP2 {
+	multiples := make([][]*Curve.ECP2, len(ps))
+	digits := make([][]int, len(ps))
+	for i := range ps {
+		// multiples[i][d] = d * ps[i]
+		multiples[i] = make([]*Curve.ECP2, windowSize)
+		multiples[i][0] = Curve.NewECP2()
+		for d := 1; d < windowSize; d++ {
+			multiples[i][d] = Curve.NewECP2()
+			multiples[i][d].Copy(multiples[i][d-1])
+			multiples[i][d].Add(ps[i])
+		}
+		digits[i] = scalarWindows(es[i], windowBits)
+	}
+
+	acc := Curve.NewECP2()
+	for j := numWindows - 1; j >= 0; j-- {
+		for k := 0; k < windowBits; k++ {
+			g2Double(acc)
+		}
+		for i := range ps {
+			if d := digits[i][j]; d != 0 {
+				acc.Add(multiples[i][d])
+			}
+		}
+	}
+	return acc
+}
+
+func g2Pippenger(ps []*Curve.ECP2, es []*Curve.BIG, c int) *Curve.ECP2 {
+	digits := make([][]int, len(ps))
+	for i := range es {
+		digits[i] = scalarWindows(es[i], c)
+	}
+
+	acc := Curve.NewECP2()
+	for j := (orderBits+c-1)/c - 1; j >= 0; j-- {
+		for k := 0; k < c; k++ {
+			g2Double(acc)
+		}
+
+		buckets := make([]*Curve.ECP2, 1<<uint(c))
+		for i := range ps {
+			d := digits[i][j]
+			if d == 0 {
+				continue
+			}
+			if buckets[d] == nil {
+				buckets[d] = Curve.NewECP2()
+			}
+			buckets[d].Add(ps[i])
+		}
+
+		// sum(d * buckets[d]) = buckets[max] + (buckets[max] + buckets[max-1]) + ...
+		sum := Curve.NewECP2()
+		total := Curve.NewECP2()
+		for d := len(buckets) - 1; d > 0; d-- {
+			if buckets[d] != nil {
+				sum.Add(buckets[d])
+			}
+			total.Add(sum)
+		}
+		acc.Add(total)
+	}
+	return acc
+}
@@ -0,0 +1,119 @@
+// multiexp_test.go - tests for multi-scalar multiplication
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package bpgroup_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomMultiMulInput(G *bpgroup.BpGroup, n int) ([]*Curve.ECP, []*Curve.ECP2, []*Curve.BIG) {
+	g1s := make([]*Curve.ECP, n)
+	g2s := make([]*Curve.ECP2, n)
+	es := make([]*Curve.BIG, n)
+	for i := 0; i < n; i++ {
+		g1s[i] = G.Gen1Mul(Curve.Randomnum(G.Order(), G.Rng()))
+		g2s[i] = G.Gen2Mul(Curve.Randomnum(G.Order(), G.Rng()))
+		es[i] = Curve.Randomnum(G.Order(), G.Rng())
+	}
+	return g1s, g2s, es
+}
+
+func TestMultiMul(t *testing.T) {
+	G := bpgroup.New()
+	// the last case uses Pippenger's method rather than Straus'
+	for _, n := range []int{0, 1, 2, 5, 130} {
+		g1s, g2s, es := randomMultiMulInput(G, n)
+		if n > 1 {
+			// edge cases
+			g1s[0] = Curve.NewECP()
+			g2s[0] = Curve.NewECP2()
+			es[1] = Curve.NewBIGint(0)
+		}
+		if n > 2 {
+			es[2] = Curve.NewBIGcopy(G.Order()).Plus(Curve.NewBIGint(5))
+		}
+
+		exp1 := Curve.NewECP()
+		exp2 := Curve.NewECP2()
+		for i := 0; i < n; i++ {
+			exp1.Add(Curve.G1mul(g1s[i], es[i]))
+			exp2.Add(Curve.G2mul(g2s[i], es[i]))
+		}
+		assert.True(t, exp1.Equals(bpgroup.G1MultiMul(g1s, es)), fmt.Sprintf("n=%d", n))
+		assert.True(t, exp2.Equals(bpgroup.G2MultiMul(g2s, es)), fmt.Sprintf("n=%d", n))
+		if n < 10 {
+			assert.True(t, exp1.Equals(bpgroup.G1MulSum(g1s, es)), fmt.Sprintf("n=%d", n))
+			assert.True(t, exp2.Equals(bpgroup.G2MulSum(g2s, es)), fmt.Sprintf("n=%d", n))
+		}
+	}
+
+	g1s, g2s, es := randomMultiMulInput(G, 2)
+	assert.Nil(t, bpgroup.G1MultiMul(g1s, es[1:]))
+	assert.Nil(t, bpgroup.G2MultiMul(g2s[1:], es))
+	assert.Nil(t, bpgroup.G1MulSum(g1s, es[1:]))
+	assert.Nil(t, bpgroup.G2MulSum(g2s[1:], es))
+}
+
+var multiMulG1Res *Curve.ECP
+
+func BenchmarkG1MultiMul(b *testing.B) {
+	G := bpgroup.New()
+	for _, n := range []int{2, 5, 10, 50} {
+		g1s, _, es := randomMultiMulInput(G, n)
+		b.Run(fmt.Sprintf("n=%d/MultiMul", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				multiMulG1Res = bpgroup.G1MultiMul(g1s, es)
+			}
+		})
+		b.Run(fmt.Sprintf("n=%d/G1mul", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				res := Curve.G1mul(g1s[0], es[0])
+				for j := 1; j < n; j++ {
+					res.Add(Curve.G1mul(g1s[j], es[j]))
+				}
+				multiMulG1Res = res
+			}
+		})
+	}
+}
+
+var multiMulG2Res *Curve.ECP2
+
+func BenchmarkG2MultiMul(b *testing.B) {
+	G := bpgroup.New()
+	for _, n := range []int{2, 5, 10, 50} {
+		_, g2s, es := randomMultiMulInput(G, n)
+		b.Run(fmt.Sprintf("n=%d/MultiMul", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				multiMulG2Res = bpgroup.G2MultiMul(g2s, es)
+			}
+		})
+		b.Run(fmt.Sprintf("n=%d/G2mul", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				res := Curve.G2mul(g2s[0], es[0])
+				for j := 1; j < n; j++ {
+					res.Add(Curve.G2mul(g2s[j], es[j]))
+				}
+				multiMulG2Res = res
+			}
+		})
+	}
+}
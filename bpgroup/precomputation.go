@@ -0,0 +1,206 @@
+// precomputation.go - fixed-base precomputation tables
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package bpgroup
+
+import (
+	"crypto/subtle"
+
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// The tables use windows of 4 bits, i.e. each window holds 16 multiples of the base shifted by 4*j bits.
+// Multiplication then only requires a single addition per window and no doublings.
+// As the tables are used with secret scalars, the lookups are constant-time: entries are stored
+// in their affine encoding and every lookup reads the entire window, selecting the required entry
+// with constant-time operations. The point at infinity has no such encoding, hence window j holds
+// (d+1) * 16^j * base for d = 0, ..., 15 and the sum of the offsets, sum(16^j * base), is subtracted at the end.
+const (
+	windowBits = 4
+	windowSize = 1 << windowBits
+)
+
+// orderBits is the bit length of the group order.
+var orderBits = func() int {
+	b := make([]byte, Curve.MODBYTES)
+	Curve.NewBIGints(Curve.CURVE_Order).ToBytes(b)
+	for i := range b {
+		if b[i] != 0 {
+			bits := 8 * (len(b) - i)
+			for mask := byte(0x80); b[i]&mask == 0; mask >>= 1 {
+				bits--
+			}
+			return bits
+		}
+	}
+	return 0
+}()
+
+// numWindows is the number of windows required to represent any scalar reduced modulo the group order.
+var numWindows = (orderBits + windowBits - 1) / windowBits
+
+// scalarWindows returns digits of e reduced modulo the group order in base 2^c,
+// starting from the least significant one.
+func scalarWindows(e *Curve.BIG, c int) []int {
+	x := Curve.NewBIGcopy(e)
+	x.Mod(Curve.NewBIGints(Curve.CURVE_Order))
+	b := make([]byte, Curve.MODBYTES)
+	x.ToBytes(b)
+
+	digits := make([]int, (orderBits+c-1)/c)
+	for j := range digits {
+		for k := 0; k < c; k++ {
+			bit := j*c + k
+			if bit >= orderBits {
+				break
+			}
+			if (b[len(b)-1-bit/8]>>uint(bit%8))&1 == 1 {
+				digits[j] |= 1 << uint(k)
+			}
+		}
+	}
+	return digits
+}
+
+// selectEntry returns a copy of the d-th entry of the window.
+// It reads all the entries, so that neither the timing nor the memory access pattern depend on d.
+func selectEntry(window [][]byte, d int) []byte {
+	entry := make([]byte, len(window[0]))
+	for i := range window {
+		subtle.ConstantTimeCopy(subtle.ConstantTimeEq(int32(i), int32(d)), entry, window[i])
+	}
+	return entry
+}
+
+// G1Table is a fixed-base precomputation table for multiplying a point on the G1 curve.
+// It is safe for concurrent use and its multiplication is constant-time with respect to the scalar.
+// The base must be an element of the prime order subgroup.
+type G1Table struct {
+	base *Curve.ECP
+
+	windows [][][]byte
+	offset  *Curve.ECP
+}
+
+// NewG1Table creates a precomputation table for the given base.
+func NewG1Table(base *Curve.ECP) *G1Table {
+	P := Curve.NewECP()
+	P.Copy(base)
+	t := &G1Table{base: P}
+	t.build()
+	return t
+}
+
+// Base returns the base point of the table.
+func (t *G1Table) Base() *Curve.ECP {
+	return t.base
+}
+
+func (t *G1Table) build() {
+	t.windows = make([][][]byte, numWindows)
+	t.offset = Curve.NewECP()
+	cur := Curve.NewECP()
+	cur.Copy(t.base)
+	for j := range t.windows {
+		t.offset.Add(cur)
+		w := make([][]byte, windowSize)
+		entry := Curve.NewECP()
+		for d := range w {
+			entry.Add(cur)
+			w[d] = ecpBytes(entry)
+		}
+		// entry = 16 * cur
+		cur = entry
+		t.windows[j] = w
+	}
+}
+
+// Mul returns e * base.
+func (t *G1Table) Mul(e *Curve.BIG) *Curve.ECP {
+	acc := Curve.NewECP()
+	for j, d := range scalarWindows(e, windowBits) {
+		acc.Add(Curve.ECP_fromBytes(selectEntry(t.windows[j], d)))
+	}
+	acc.Sub(t.offset)
+	return acc
+}
+
+// G2Table is a fixed-base precomputation table for multiplying a point on the G2 curve.
+// It is safe for concurrent use and its multiplication is constant-time with respect to the scalar.
+// The base must be an element of the prime order subgroup.
+type G2Table struct {
+	base *Curve.ECP2
+
+	windows [][][]byte
+	offset  *Curve.ECP2
+}
+
+// NewG2Table creates a precomputation table for the given base.
+func NewG2Table(base *Curve.ECP2) *G2Table {
+	P := Curve.NewECP2()
+	P.Copy(base)
+	t := &G2Table{base: P}
+	t.build()
+	return t
+}
+
+// Base returns the base point of the table.
+func (t *G2Table) Base() *Curve.ECP2 {
+	return t.base
+}
+
+func (t *G2Table) build() {
+	t.windows = make([][][]byte, numWindows)
+	t.offset = Curve.NewECP2()
+	cur := Curve.NewECP2()
+	cur.Copy(t.base)
+	for j := range t.windows {
+		t.offset.Add(cur)
+		w := make([][]byte, windowSize)
+		entry := Curve.NewECP2()
+		for d := range w {
+			entry.Add(cur)
+			w[d] = ecp2Bytes(entry)
+		}
+		// entry = 16 * cur
+		cur = entry
+		t.windows[j] = w
+	}
+}
+
+// Mul returns e * base.
+func (t *G2Table) Mul(e *Curve.BIG) *Curve.ECP2 {
+	acc := Curve.NewECP2()
+	for j, d := range scalarWindows(e, windowBits) {
+		acc.Add(Curve.ECP2_fromBytes(selectEntry(t.windows[j], d)))
+	}
+	acc.Sub(t.offset)
+	return acc
+}
+
+// ecpBytes returns the uncompressed encoding of the point on the G1 curve.
+func ecpBytes(P *Curve.ECP) []byte {
+	b := make([]byte, 2*int(Curve.MODBYTES)+1)
+	P.ToBytes(b, false)
+	return b
+}
+
+// ecp2Bytes returns the encoding of the point on the G2 curve.
+func ecp2Bytes(P *Curve.ECP2) []byte {
+	b := make([]byte, 4*int(Curve.MODBYTES))
+	P.ToBytes(b)
+	return b
+}
@@ -0,0 +1,102 @@
+// precomputation_test.go - tests for fixed-base precomputation tables
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package bpgroup_test
+
+import (
+	"testing"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedBaseTables(t *testing.T) {
+	G := bpgroup.New()
+	P := Curve.G1mul(G.Gen1(), Curve.Randomnum(G.Order(), G.Rng()))
+	Q := Curve.G2mul(G.Gen2(), Curve.Randomnum(G.Order(), G.Rng()))
+	tP := bpgroup.NewG1Table(P)
+	tQ := bpgroup.NewG2Table(Q)
+	assert.True(t, P.Equals(tP.Base()))
+	assert.True(t, Q.Equals(tQ.Base()))
+
+	orderMinusOne := Curve.NewBIGcopy(G.Order())
+	orderMinusOne = orderMinusOne.Minus(Curve.NewBIGint(1))
+	// scalars larger than the order should be handled as well
+	orderPlusOne := Curve.NewBIGcopy(G.Order())
+	orderPlusOne = orderPlusOne.Plus(Curve.NewBIGint(1))
+
+	scalars := []*Curve.BIG{Curve.NewBIGint(0), Curve.NewBIGint(1), Curve.NewBIGint(15), Curve.NewBIGint(16),
+		orderMinusOne, orderPlusOne, G.Order()}
+	for i := 0; i < 10; i++ {
+		scalars = append(scalars, Curve.Randomnum(G.Order(), G.Rng()))
+	}
+
+	for _, e := range scalars {
+		assert.True(t, Curve.G1mul(P, e).Equals(tP.Mul(e)))
+		assert.True(t, Curve.G2mul(Q, e).Equals(tQ.Mul(e)))
+		assert.True(t, Curve.G1mul(G.Gen1(), e).Equals(G.Gen1Mul(e)))
+		assert.True(t, Curve.G2mul(G.Gen2(), e).Equals(G.Gen2Mul(e)))
+	}
+}
+
+var g1TableMulRes *Curve.ECP
+
+func BenchmarkG1TableMul(b *testing.B) {
+	G := bpgroup.New()
+	// build the table before the measurement
+	G.Gen1Mul(Curve.NewBIGint(1))
+	var res *Curve.ECP
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		t := Curve.Randomnum(G.Order(), G.Rng())
+		b.StartTimer()
+		res = G.Gen1Mul(t)
+	}
+	// it is recommended to store results in package level variables,
+	// so that compiler would not try to optimize the benchmark
+	g1TableMulRes = res
+}
+
+var g2TableMulRes *Curve.ECP2
+
+func BenchmarkG2TableMul(b *testing.B) {
+	G := bpgroup.New()
+	// build the table before the measurement
+	G.Gen2Mul(Curve.NewBIGint(1))
+	var res *Curve.ECP2
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		t := Curve.Randomnum(G.Order(), G.Rng())
+		b.StartTimer()
+		res = G.Gen2Mul(t)
+	}
+	// it is recommended to store results in package level variables,
+	// so that compiler would not try to optimize the benchmark
+	g2TableMulRes = res
+}
+
+var g1TableRes *bpgroup.G1Table
+
+func BenchmarkNewG1Table(b *testing.B) {
+	G := bpgroup.New()
+	var res *bpgroup.G1Table
+	for i := 0; i < b.N; i++ {
+		res = bpgroup.NewG1Table(G.Gen1())
+	}
+	// it is recommended to store results in package level variables,
+	// so that compiler would not try to optimize the benchmark
+	g1TableRes = res
+}
@@ -0,0 +1,686 @@
+// dkg.go - distributed generation of threshold Coconut keys
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package coconut provides the functionalities required by the Coconut Scheme.
+package coconut
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// The distributed key generation follows the protocol of Gennaro, Jarecki, Krawczyk and Rabin:
+// "Secure Distributed Key Generation for Discrete-Log Based Cryptosystems".
+// Every authority acts as a dealer of q + 1 random polynomials of degree t - 1, one for x and one for each y,
+// and the final keys are sums of the polynomials of all qualified dealers, so that no party learns the master key.
+//
+// The protocol runs in the following rounds, where the messages returned by each round
+// have to be delivered to all participants (or only to their recipient in case of private DKGShares)
+// before the next one starts:
+//  1. Deal: each dealer broadcasts Pedersen commitments in G1 to its polynomials
+//     and privately sends the shares to every participant.
+//  2. ProcessDealings: each participant verifies its shares and complains about invalid or missing ones.
+//  3. RespondToComplaints: each dealer publicly reveals the shares of its accusers.
+//  4. ProcessComplaintResponses: dealers with t or more complaints or with an invalid response are disqualified.
+//     Each qualified dealer broadcasts Feldman commitments in G2 to its polynomials.
+//  5. ProcessFeldmanCommitments: each participant verifies its shares against the Feldman commitments
+//     and publicly reveals the shares that fail the check.
+//  6. ProcessFeldmanComplaints: each participant reveals its shares of every qualified dealer
+//     with a valid complaint against its Feldman commitments.
+//  7. Finish: contributions of the dealers with invalid Feldman commitments are reconstructed
+//     from the revealed shares, and the keys are derived.
+//
+// The participants are identified with distinct positive AuthorityIDs, which are also the points
+// at which the polynomials are evaluated, so that the derived keys are bound to those ids.
+
+var (
+	// ErrDKGParams indicates incorrect parameters provided for the distributed key generation.
+	ErrDKGParams = errors.New("Invalid set of parameters provided to distributed keygen")
+
+	// ErrDKGRound indicates that a round of the distributed key generation was executed out of order.
+	ErrDKGRound = errors.New("Distributed keygen round executed out of order")
+
+	// ErrDKGQualified indicates that all the dealers were disqualified.
+	ErrDKGQualified = errors.New("No qualified dealers in distributed keygen")
+
+	// ErrDKGReconstruction indicates that there were fewer than t valid shares
+	// to reconstruct the contribution of a dealer with invalid Feldman commitments.
+	ErrDKGReconstruction = errors.New("Not enough valid shares to reconstruct the contribution of a dealer")
+)
+
+// rounds of the distributed key generation
+const (
+	dkgRoundDeal = iota
+	dkgRoundProcessDealings
+	dkgRoundRespondToComplaints
+	dkgRoundProcessComplaintResponses
+	dkgRoundProcessFeldmanCommitments
+	dkgRoundProcessFeldmanComplaints
+	dkgRoundFinish
+	dkgRoundDone
+)
+
+// DKGDealing is the broadcast message of a dealer containing Pedersen commitments
+// g1^a[k][l] * h^b[k][l] to the coefficients of its polynomials a[0] (for x), a[1], ..., a[q] (for y)
+// and of the corresponding blinding polynomials b.
+type DKGDealing struct {
+	from        AuthorityID
+	commitments [][]*Curve.ECP
+}
+
+// From returns the identifier of the dealer.
+func (dealing *DKGDealing) From() AuthorityID {
+	return dealing.from
+}
+
+// DKGShares represents evaluations a[k](to) and b[k](to) of all polynomials of a dealer at the point of a participant.
+// It is sent privately by the dealer to the participant in the first round and is broadcast
+// whenever shares have to be revealed, i.e. in response to a complaint, as a complaint against Feldman commitments
+// and when the contribution of a dealer is reconstructed.
+type DKGShares struct {
+	from   AuthorityID
+	to     AuthorityID
+	s      []*Curve.BIG
+	sPrime []*Curve.BIG
+}
+
+// From returns the identifier of the dealer of the shares.
+func (shares *DKGShares) From() AuthorityID {
+	return shares.from
+}
+
+// To returns the identifier of the participant the shares are intended for.
+func (shares *DKGShares) To() AuthorityID {
+	return shares.to
+}
+
+// DKGComplaint is the broadcast message of a participant accusing a dealer of sending invalid or no shares.
+type DKGComplaint struct {
+	from    AuthorityID
+	against AuthorityID
+}
+
+// From returns the identifier of the accusing participant.
+func (complaint *DKGComplaint) From() AuthorityID {
+	return complaint.from
+}
+
+// Against returns the identifier of the accused dealer.
+func (complaint *DKGComplaint) Against() AuthorityID {
+	return complaint.against
+}
+
+// DKGFeldmanCommitments is the broadcast message of a qualified dealer containing
+// Feldman commitments g2^a[k][l] to the coefficients of its polynomials.
+type DKGFeldmanCommitments struct {
+	from        AuthorityID
+	commitments [][]*Curve.ECP2
+}
+
+// From returns the identifier of the dealer.
+func (cms *DKGFeldmanCommitments) From() AuthorityID {
+	return cms.from
+}
+
+// DKGParticipant represents state of a single signing authority taking part in the distributed key generation.
+// It is not safe for concurrent use.
+type DKGParticipant struct {
+	params *Params
+	id     AuthorityID
+	t      int
+	ids    []AuthorityID // sorted identifiers of all participants
+	round  int
+
+	h *Curve.ECP // second generator of the Pedersen commitments
+
+	a [][]*Curve.BIG // own polynomials, a[0] for x and a[1], ..., a[q] for y
+	b [][]*Curve.BIG // own blinding polynomials
+
+	dealings     map[AuthorityID]*DKGDealing
+	shares       map[AuthorityID]*DKGShares    // verified shares received from the dealers
+	complaints   map[AuthorityID][]AuthorityID // dealer -> accusing participants
+	disqualified map[AuthorityID]bool
+	qual         []AuthorityID
+	feldman      map[AuthorityID]*DKGFeldmanCommitments
+	faulty       map[AuthorityID]bool // qualified dealers with invalid Feldman commitments
+}
+
+// NewDKGParticipant creates the state of the participant with the given identifier
+// in the distributed generation of keys for the authorities with the given ids,
+// any t of which are required to issue credentials. The ids have to be distinct and positive
+// and have to include the id of the participant.
+func NewDKGParticipant(params *Params, id AuthorityID, t int, ids []AuthorityID) (*DKGParticipant, error) {
+	if params == nil || len(ids) < t || t <= 0 {
+		return nil, ErrDKGParams
+	}
+	if positive, err := validateAuthorityIDs(ids); err != nil || !positive {
+		return nil, ErrDKGParams
+	}
+	sorted := make([]AuthorityID, len(ids))
+	copy(sorted, ids)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	h, err := params.hashToG1(dkgDomain, []byte("dkg_h"))
+	if err != nil {
+		return nil, err
+	}
+	dp := &DKGParticipant{
+		params:       params,
+		id:           id,
+		t:            t,
+		ids:          sorted,
+		h:            h,
+		dealings:     make(map[AuthorityID]*DKGDealing),
+		shares:       make(map[AuthorityID]*DKGShares),
+		complaints:   make(map[AuthorityID][]AuthorityID),
+		disqualified: make(map[AuthorityID]bool),
+		feldman:      make(map[AuthorityID]*DKGFeldmanCommitments),
+		faulty:       make(map[AuthorityID]bool),
+	}
+	if !dp.validID(id) {
+		return nil, ErrDKGParams
+	}
+	return dp, nil
+}
+
+// ID returns the identifier of the participant.
+func (dp *DKGParticipant) ID() AuthorityID {
+	return dp.id
+}
+
+// Qualified returns sorted identifiers of the dealers that were not disqualified.
+// It is only available after ProcessComplaintResponses.
+func (dp *DKGParticipant) Qualified() []AuthorityID {
+	return dp.qual
+}
+
+// nextRound ensures the rounds are executed in order.
+func (dp *DKGParticipant) nextRound(round int) error {
+	if dp.round != round {
+		return ErrDKGRound
+	}
+	dp.round++
+	return nil
+}
+
+// Deal generates the polynomials of the participant and returns its broadcast dealing
+// alongside the shares for all participants in the order of their ids, which have to be delivered privately.
+func (dp *DKGParticipant) Deal() (*DKGDealing, []*DKGShares, error) {
+	if err := dp.nextRound(dkgRoundDeal); err != nil {
+		return nil, nil, err
+	}
+	p, g1, rng := dp.params.p, dp.params.g1, dp.params.G.Rng()
+	polys := len(dp.params.hs) + 1
+
+	dp.a = make([][]*Curve.BIG, polys)
+	dp.b = make([][]*Curve.BIG, polys)
+	commitments := make([][]*Curve.ECP, polys)
+	for k := 0; k < polys; k++ {
+		dp.a[k] = make([]*Curve.BIG, dp.t)
+		dp.b[k] = make([]*Curve.BIG, dp.t)
+		commitments[k] = make([]*Curve.ECP, dp.t)
+		for l := 0; l < dp.t; l++ {
+			dp.a[k][l] = Curve.Randomnum(p, rng)
+			dp.b[k][l] = Curve.Randomnum(p, rng)
+			commitments[k][l] = bpgroup.G1MulSum([]*Curve.ECP{g1, dp.h}, []*Curve.BIG{dp.a[k][l], dp.b[k][l]})
+		}
+	}
+
+	shares := make([]*DKGShares, len(dp.ids))
+	for i, j := range dp.ids {
+		shares[i] = dp.sharesFor(j)
+	}
+
+	return &DKGDealing{from: dp.id, commitments: commitments}, shares, nil
+}
+
+// ProcessDealings takes the dealings broadcast by all dealers and the shares sent to the participant.
+// Dealers that did not broadcast a valid dealing are disqualified.
+// It returns complaints against the dealers whose shares are missing or are inconsistent with their dealings.
+func (dp *DKGParticipant) ProcessDealings(dealings []*DKGDealing, shares []*DKGShares) ([]*DKGComplaint, error) {
+	if err := dp.nextRound(dkgRoundProcessDealings); err != nil {
+		return nil, err
+	}
+	for _, dealing := range dealings {
+		if dp.validateDealing(dealing) != nil {
+			continue
+		}
+		if _, ok := dp.dealings[dealing.from]; ok {
+			// dealer that broadcast multiple dealings can't be trusted
+			dp.disqualified[dealing.from] = true
+			continue
+		}
+		dp.dealings[dealing.from] = dealing
+	}
+
+	for _, s := range shares {
+		if s == nil || s.to != dp.id || dp.disqualified[s.from] || !dp.verifyPedersen(s) {
+			continue
+		}
+		dp.shares[s.from] = s
+	}
+
+	var complaints []*DKGComplaint
+	for _, i := range dp.ids {
+		if _, ok := dp.dealings[i]; !ok {
+			dp.disqualified[i] = true
+		}
+		if dp.disqualified[i] {
+			continue
+		}
+		if _, ok := dp.shares[i]; !ok {
+			// own complaints are recorded directly, so that the dealer is disqualified
+			// if it does not respond even when the broadcast of the complaint does not come back
+			complaints = append(complaints, &DKGComplaint{from: dp.id, against: i})
+			dp.complaints[i] = append(dp.complaints[i], dp.id)
+		}
+	}
+	return complaints, nil
+}
+
+// RespondToComplaints takes the complaints broadcast by all participants
+// and returns the shares of the participants that complained against this dealer, which have to be broadcast.
+func (dp *DKGParticipant) RespondToComplaints(complaints []*DKGComplaint) ([]*DKGShares, error) {
+	if err := dp.nextRound(dkgRoundRespondToComplaints); err != nil {
+		return nil, err
+	}
+	accused := make(map[[2]AuthorityID]bool)
+	for i, accusers := range dp.complaints {
+		for _, j := range accusers {
+			accused[[2]AuthorityID{i, j}] = true
+		}
+	}
+	for _, complaint := range complaints {
+		if complaint == nil || !dp.validID(complaint.from) || !dp.validID(complaint.against) {
+			continue
+		}
+		key := [2]AuthorityID{complaint.against, complaint.from}
+		if accused[key] {
+			continue
+		}
+		accused[key] = true
+		dp.complaints[complaint.against] = append(dp.complaints[complaint.against], complaint.from)
+	}
+
+	var responses []*DKGShares
+	for _, j := range dp.complaints[dp.id] {
+		responses = append(responses, dp.sharesFor(j))
+	}
+	return responses, nil
+}
+
+// ProcessComplaintResponses takes the shares revealed by all dealers in response to the complaints
+// and determines the set of qualified dealers. Dealers with at least t complaints or with a missing
+// or invalid response to any complaint are disqualified.
+// It returns the Feldman commitments of the participant, which have to be broadcast.
+func (dp *DKGParticipant) ProcessComplaintResponses(responses []*DKGShares) (*DKGFeldmanCommitments, error) {
+	if err := dp.nextRound(dkgRoundProcessComplaintResponses); err != nil {
+		return nil, err
+	}
+	revealed := make(map[[2]AuthorityID]*DKGShares)
+	for _, s := range responses {
+		if s != nil && !dp.disqualified[s.from] && dp.verifyPedersen(s) {
+			revealed[[2]AuthorityID{s.from, s.to}] = s
+		}
+	}
+
+	for i, accusers := range dp.complaints {
+		if dp.disqualified[i] {
+			continue
+		}
+		if len(accusers) >= dp.t {
+			dp.disqualified[i] = true
+			continue
+		}
+		for _, j := range accusers {
+			s, ok := revealed[[2]AuthorityID{i, j}]
+			if !ok {
+				dp.disqualified[i] = true
+				break
+			}
+			if j == dp.id {
+				dp.shares[i] = s
+			}
+		}
+	}
+
+	dp.qual = nil
+	for _, i := range dp.ids {
+		if !dp.disqualified[i] {
+			dp.qual = append(dp.qual, i)
+		}
+	}
+	if len(dp.qual) == 0 {
+		return nil, ErrDKGQualified
+	}
+
+	commitments := make([][]*Curve.ECP2, len(dp.a))
+	for k := range dp.a {
+		commitments[k] = make([]*Curve.ECP2, dp.t)
+		for l := range dp.a[k] {
+			commitments[k][l] = dp.params.G.Gen2Mul(dp.a[k][l])
+		}
+	}
+	return &DKGFeldmanCommitments{from: dp.id, commitments: commitments}, nil
+}
+
+// ProcessFeldmanCommitments takes the Feldman commitments broadcast by all qualified dealers
+// and verifies the shares of the participant against them.
+// It returns the shares that fail the check, which have to be broadcast as complaints.
+func (dp *DKGParticipant) ProcessFeldmanCommitments(cms []*DKGFeldmanCommitments) ([]*DKGShares, error) {
+	if err := dp.nextRound(dkgRoundProcessFeldmanCommitments); err != nil {
+		return nil, err
+	}
+	for _, cm := range cms {
+		if cm == nil || dp.disqualified[cm.from] || dp.validateFeldmanCommitments(cm) != nil {
+			continue
+		}
+		if _, ok := dp.feldman[cm.from]; ok {
+			// equivocating dealer has to have its contribution reconstructed
+			dp.faulty[cm.from] = true
+			continue
+		}
+		dp.feldman[cm.from] = cm
+	}
+
+	var complaints []*DKGShares
+	for _, i := range dp.qual {
+		if s := dp.shares[i]; s != nil && !dp.verifyFeldman(s) {
+			complaints = append(complaints, s)
+		}
+	}
+	return complaints, nil
+}
+
+// ProcessFeldmanComplaints takes the shares revealed by all participants as complaints against Feldman commitments.
+// Valid complaints, i.e. shares consistent with the Pedersen commitments of the dealer but not with its Feldman
+// commitments, require the contribution of the dealer to be reconstructed. It returns the shares of the participant
+// from all such dealers, which have to be broadcast.
+func (dp *DKGParticipant) ProcessFeldmanComplaints(complaints []*DKGShares) ([]*DKGShares, error) {
+	if err := dp.nextRound(dkgRoundProcessFeldmanComplaints); err != nil {
+		return nil, err
+	}
+	for _, s := range complaints {
+		if s == nil || dp.disqualified[s.from] || !dp.verifyPedersen(s) {
+			continue
+		}
+		if !dp.verifyFeldman(s) {
+			dp.faulty[s.from] = true
+		}
+	}
+	for _, i := range dp.qual {
+		if _, ok := dp.feldman[i]; !ok {
+			dp.faulty[i] = true
+		}
+	}
+
+	var reconstruction []*DKGShares
+	for _, i := range dp.qual {
+		if s := dp.shares[i]; s != nil && dp.faulty[i] {
+			reconstruction = append(reconstruction, s)
+		}
+	}
+	return reconstruction, nil
+}
+
+// Finish takes the shares revealed by all participants to reconstruct the contributions of faulty dealers
+// and returns the SecretKey and the VerificationKey of the participant, bound to its id as the AuthorityID,
+// alongside the aggregated VerificationKey of all the authorities.
+func (dp *DKGParticipant) Finish(reconstruction []*DKGShares) (*SecretKey, *VerificationKey, *VerificationKey, error) {
+	if err := dp.nextRound(dkgRoundFinish); err != nil {
+		return nil, nil, nil, err
+	}
+	p, g2 := dp.params.p, dp.params.g2
+	polys := len(dp.a)
+
+	// g2^a[k][0] of every qualified dealer
+	constants := make(map[AuthorityID][]*Curve.ECP2, len(dp.qual))
+	for _, i := range dp.qual {
+		if !dp.faulty[i] {
+			constants[i] = make([]*Curve.ECP2, polys)
+			for k := range constants[i] {
+				constants[i][k] = dp.feldman[i].commitments[k][0]
+			}
+		}
+	}
+
+	revealed := make(map[AuthorityID]map[AuthorityID]*DKGShares)
+	for _, s := range reconstruction {
+		if s == nil || !dp.faulty[s.from] || !dp.verifyPedersen(s) {
+			continue
+		}
+		if revealed[s.from] == nil {
+			revealed[s.from] = make(map[AuthorityID]*DKGShares)
+		}
+		revealed[s.from][s.to] = s
+	}
+	for i := range dp.faulty {
+		if dp.disqualified[i] {
+			continue
+		}
+		if len(revealed[i]) < dp.t {
+			return nil, nil, nil, ErrDKGReconstruction
+		}
+		a0 := interpolateDKGShares(revealed[i], dp.t, p)
+		constants[i] = make([]*Curve.ECP2, polys)
+		for k := range a0 {
+			constants[i][k] = dp.params.G.Gen2Mul(a0[k])
+		}
+	}
+
+	s := make([]*Curve.BIG, polys)
+	for k := range s {
+		s[k] = Curve.NewBIG()
+		for _, i := range dp.qual {
+			s[k] = s[k].Plus(dp.shares[i].s[k])
+			s[k].Mod(p)
+		}
+	}
+	sk := &SecretKey{id: dp.id, x: s[0], y: s[1:]}
+
+	beta := make([]*Curve.ECP2, polys-1)
+	for k := range beta {
+		beta[k] = dp.params.G.Gen2Mul(sk.y[k])
+	}
+	vk := &VerificationKey{id: dp.id, g2: g2, alpha: dp.params.G.Gen2Mul(sk.x), beta: beta}
+
+	aggr := make([]*Curve.ECP2, polys)
+	for k := range aggr {
+		aggr[k] = Curve.NewECP2()
+		for _, i := range dp.qual {
+			aggr[k].Add(constants[i][k])
+		}
+	}
+	aggrVk := &VerificationKey{g2: g2, alpha: aggr[0], beta: aggr[1:]}
+
+	dp.a, dp.b = nil, nil
+	return sk, vk, aggrVk, nil
+}
+
+// validID checks whether id is an identifier of one of the participants.
+func (dp *DKGParticipant) validID(id AuthorityID) bool {
+	i := sort.Search(len(dp.ids), func(i int) bool { return dp.ids[i] >= id })
+	return i < len(dp.ids) && dp.ids[i] == id
+}
+
+// validateDealing ensures the dealing is from a valid dealer and contains t commitments to each of q + 1 polynomials.
+func (dp *DKGParticipant) validateDealing(dealing *DKGDealing) error {
+	if dealing == nil {
+		return utils.ErrValidateNil
+	}
+	if !dp.validID(dealing.from) || len(dealing.commitments) != len(dp.params.hs)+1 {
+		return ErrValidateLength
+	}
+	for _, cms := range dealing.commitments {
+		if len(cms) != dp.t {
+			return ErrValidateLength
+		}
+		if err := utils.ValidateECPs(cms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateFeldmanCommitments ensures the commitments are from a valid dealer
+// and contain t commitments to each of q + 1 polynomials.
+func (dp *DKGParticipant) validateFeldmanCommitments(cms *DKGFeldmanCommitments) error {
+	if !dp.validID(cms.from) || len(cms.commitments) != len(dp.params.hs)+1 {
+		return ErrValidateLength
+	}
+	for _, cm := range cms.commitments {
+		if len(cm) != dp.t {
+			return ErrValidateLength
+		}
+		// similarly to verification keys, only the structure is validated as subgroup checks on G2 are expensive
+		for _, c := range cm {
+			if c == nil {
+				return utils.ErrValidateNil
+			}
+			if utils.IsInfinity2(c) {
+				return utils.ErrValidateIdentity
+			}
+		}
+	}
+	return nil
+}
+
+// verifyPedersen checks whether the shares are consistent with the Pedersen commitments of their dealer,
+// i.e. whether g1^s[k] * h^sPrime[k] = prod(commitments[k][l]^(to^l)) for every k.
+// All the equations are combined with random weights into a single multi-exponentiation
+// of the public commitments, while the shares are multiplied in constant time.
+func (dp *DKGParticipant) verifyPedersen(s *DKGShares) bool {
+	dealing, ok := dp.dealings[s.from]
+	if !ok || !dp.validID(s.to) || len(s.s) != len(dealing.commitments) || len(s.sPrime) != len(s.s) {
+		return false
+	}
+	if utils.ValidateBIGs(s.s) != nil || utils.ValidateBIGs(s.sPrime) != nil {
+		return false
+	}
+	p := dp.params.p
+	rhos := batchWeights(len(s.s), dp.params)
+	powers := idPowers(s.to, dp.t, p)
+
+	sum, sumPrime := Curve.NewBIG(), Curve.NewBIG()
+	var points []*Curve.ECP
+	var scalars []*Curve.BIG
+	for k, cms := range dealing.commitments {
+		sum = sum.Plus(Curve.Modmul(rhos[k], s.s[k], p))
+		sum.Mod(p)
+		sumPrime = sumPrime.Plus(Curve.Modmul(rhos[k], s.sPrime[k], p))
+		sumPrime.Mod(p)
+		for l := range cms {
+			points = append(points, cms[l])
+			scalars = append(scalars, Curve.Modmul(rhos[k], powers[l], p))
+		}
+	}
+	lhs := bpgroup.G1MulSum([]*Curve.ECP{dp.params.g1, dp.h}, []*Curve.BIG{sum, sumPrime})
+	return lhs.Equals(bpgroup.G1MultiMul(points, scalars))
+}
+
+// verifyFeldman checks whether the shares are consistent with the Feldman commitments of their dealer,
+// i.e. whether g2^s[k] = prod(commitments[k][l]^(to^l)) for every k.
+// All the equations are combined with random weights into a single multi-exponentiation.
+func (dp *DKGParticipant) verifyFeldman(s *DKGShares) bool {
+	if s == nil {
+		return false
+	}
+	cms, ok := dp.feldman[s.from]
+	if !ok {
+		return false
+	}
+	p := dp.params.p
+	rhos := batchWeights(len(s.s), dp.params)
+	powers := idPowers(s.to, dp.t, p)
+
+	sum := Curve.NewBIG()
+	var points []*Curve.ECP2
+	var scalars []*Curve.BIG
+	for k, cm := range cms.commitments {
+		sum = sum.Plus(Curve.Modmul(rhos[k], s.s[k], p))
+		sum.Mod(p)
+		for l := range cm {
+			points = append(points, cm[l])
+			scalars = append(scalars, Curve.Modmul(rhos[k], powers[l], p))
+		}
+	}
+	return dp.params.G.Gen2Mul(sum).Equals(bpgroup.G2MultiMul(points, scalars))
+}
+
+// batchWeights returns n random weights used to batch the verification of shares.
+func batchWeights(n int, params *Params) []*Curve.BIG {
+	p, rng := params.p, params.G.Rng()
+	rhos := make([]*Curve.BIG, n)
+	for i := range rhos {
+		rhos[i] = Curve.Randomnum(p, rng)
+	}
+	return rhos
+}
+
+// sharesFor returns evaluations of own polynomials at the point of participant j.
+func (dp *DKGParticipant) sharesFor(j AuthorityID) *DKGShares {
+	p := dp.params.p
+	jBIG := Curve.NewBIGint(int(j))
+	s := make([]*Curve.BIG, len(dp.a))
+	sPrime := make([]*Curve.BIG, len(dp.b))
+	for k := range dp.a {
+		s[k] = polyEvalMod(dp.a[k], jBIG, p)
+		sPrime[k] = polyEvalMod(dp.b[k], jBIG, p)
+	}
+	return &DKGShares{from: dp.id, to: j, s: s, sPrime: sPrime}
+}
+
+// idPowers returns j^0, j^1, ..., j^(t-1) mod p.
+func idPowers(j AuthorityID, t int, p *Curve.BIG) []*Curve.BIG {
+	jBIG := Curve.NewBIGint(int(j))
+	powers := make([]*Curve.BIG, t)
+	powers[0] = Curve.NewBIGint(1)
+	for l := 1; l < t; l++ {
+		powers[l] = Curve.Modmul(powers[l-1], jBIG, p)
+	}
+	return powers
+}
+
+// interpolateDKGShares recovers the constant terms of all polynomials of a dealer from t of the revealed shares.
+func interpolateDKGShares(revealed map[AuthorityID]*DKGShares, t int, p *Curve.BIG) []*Curve.BIG {
+	ids := make([]AuthorityID, 0, len(revealed))
+	for j := range revealed {
+		ids = append(ids, j)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	ids = ids[:t]
+
+	l := lagrangeCoefficients(ids, p)
+	a0 := make([]*Curve.BIG, len(revealed[ids[0]].s))
+	for k := range a0 {
+		a0[k] = Curve.NewBIG()
+		for i, j := range ids {
+			a0[k] = a0[k].Plus(Curve.Modmul(l[i], revealed[j].s[k], p))
+			a0[k].Mod(p)
+		}
+	}
+	return a0
+}
+
+// polyEvalMod evaluates the polynomial at x and reduces the result mod p.
+func polyEvalMod(coeff []*Curve.BIG, x *Curve.BIG, p *Curve.BIG) *Curve.BIG {
+	r := utils.PolyEval(coeff, x, p)
+	r.Mod(p)
+	return r
+}
@@ -0,0 +1,378 @@
+// dkg_test.go - tests for distributed generation of threshold Coconut keys
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package coconut
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/elgamal"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// dkgAdversary allows tampering with the messages of participants of the distributed key generation.
+// Nil functions leave the messages intact.
+type dkgAdversary struct {
+	deal      func(id AuthorityID, dealing *DKGDealing, shares []*DKGShares) (*DKGDealing, []*DKGShares)
+	responses func(id AuthorityID, responses []*DKGShares) []*DKGShares
+	feldman   func(id AuthorityID, cms *DKGFeldmanCommitments) *DKGFeldmanCommitments
+}
+
+// dkgResult is the outcome of the distributed key generation for a single participant.
+type dkgResult struct {
+	sk     *SecretKey
+	vk     *VerificationKey
+	aggrVk *VerificationKey
+	qual   []AuthorityID
+	err    error
+}
+
+// runDKG runs all rounds of the distributed key generation between the participants with the given ids in-process.
+func runDKG(t *testing.T, params *Params, thr int, ids []AuthorityID, adv *dkgAdversary) map[AuthorityID]*dkgResult {
+	if adv == nil {
+		adv = &dkgAdversary{}
+	}
+	dps := make([]*DKGParticipant, len(ids))
+	for i := range dps {
+		var err error
+		dps[i], err = NewDKGParticipant(params, ids[i], thr, ids)
+		assert.Nil(t, err)
+	}
+
+	var dealings []*DKGDealing
+	private := make(map[AuthorityID][]*DKGShares, len(ids)) // shares received by each participant
+	for _, dp := range dps {
+		dealing, shares, err := dp.Deal()
+		assert.Nil(t, err)
+		if adv.deal != nil {
+			dealing, shares = adv.deal(dp.ID(), dealing, shares)
+		}
+		if dealing != nil {
+			dealings = append(dealings, dealing)
+		}
+		for _, s := range shares {
+			if s != nil {
+				private[s.To()] = append(private[s.To()], s)
+			}
+		}
+	}
+
+	var complaints []*DKGComplaint
+	for _, dp := range dps {
+		c, err := dp.ProcessDealings(dealings, private[dp.ID()])
+		assert.Nil(t, err)
+		complaints = append(complaints, c...)
+	}
+
+	var responses []*DKGShares
+	for _, dp := range dps {
+		r, err := dp.RespondToComplaints(complaints)
+		assert.Nil(t, err)
+		if adv.responses != nil {
+			r = adv.responses(dp.ID(), r)
+		}
+		responses = append(responses, r...)
+	}
+
+	var feldman []*DKGFeldmanCommitments
+	for _, dp := range dps {
+		cms, err := dp.ProcessComplaintResponses(responses)
+		assert.Nil(t, err)
+		if adv.feldman != nil {
+			cms = adv.feldman(dp.ID(), cms)
+		}
+		if cms != nil {
+			feldman = append(feldman, cms)
+		}
+	}
+
+	var feldmanComplaints []*DKGShares
+	for _, dp := range dps {
+		c, err := dp.ProcessFeldmanCommitments(feldman)
+		assert.Nil(t, err)
+		feldmanComplaints = append(feldmanComplaints, c...)
+	}
+
+	var reconstruction []*DKGShares
+	for _, dp := range dps {
+		r, err := dp.ProcessFeldmanComplaints(feldmanComplaints)
+		assert.Nil(t, err)
+		reconstruction = append(reconstruction, r...)
+	}
+
+	results := make(map[AuthorityID]*dkgResult, len(ids))
+	for _, dp := range dps {
+		res := &dkgResult{qual: dp.Qualified()}
+		res.sk, res.vk, res.aggrVk, res.err = dp.Finish(reconstruction)
+		results[dp.ID()] = res
+	}
+	return results
+}
+
+// checkDKGKeys ensures all honest participants agree on the aggregated key and that any t of them
+// can issue credentials verifiable under it.
+func checkDKGKeys(t *testing.T, params *Params, thr int, results map[AuthorityID]*dkgResult, honest []AuthorityID) {
+	aggrVk := results[honest[0]].aggrVk
+	for _, id := range honest {
+		res := results[id]
+		assert.Nil(t, res.err)
+		assert.Nil(t, res.vk.Validate())
+		assert.True(t, res.aggrVk.alpha.Equals(aggrVk.alpha))
+		assert.Equal(t, results[honest[0]].qual, res.qual)
+		for j := range aggrVk.beta {
+			assert.True(t, res.aggrVk.beta[j].Equals(aggrVk.beta[j]))
+		}
+	}
+
+	// t honest authorities can issue the credential
+	for _, ids := range [][]AuthorityID{honest[len(honest)-thr:]} {
+		vks := make([]*VerificationKey, thr)
+		for i, id := range ids {
+			assert.Equal(t, id, results[id].vk.ID())
+			vks[i] = results[id].vk
+		}
+		avk, err := AggregateVerificationKeys(params, vks)
+		assert.Nil(t, err)
+		assert.True(t, avk.alpha.Equals(aggrVk.alpha))
+
+		d, gamma := elgamal.Keygen(params.G)
+		pubM := []*Curve.BIG{}
+		privM := []*Curve.BIG{Curve.NewBIGint(43)}
+		bsm, err := PrepareBlindSign(params, gamma, pubM, privM)
+		assert.Nil(t, err)
+
+		shares := make([]*SignatureShare, thr)
+		for i, id := range ids {
+			blindedSig, err := BlindSign(params, results[id].sk, bsm, gamma, pubM)
+			assert.Nil(t, err)
+			shares[i] = NewSignatureShare(id, Unblind(params, blindedSig, d))
+		}
+		aSig, err := AggregateSignatures(params, shares)
+		assert.Nil(t, err)
+		rSig := Randomize(params, aSig)
+		showMats, err := ShowBlindSignature(params, aggrVk, rSig, privM)
+		assert.Nil(t, err)
+		assert.True(t, BlindVerify(params, aggrVk, rSig, showMats, pubM))
+	}
+}
+
+func TestDKGParams(t *testing.T) {
+	params, err := Setup(1)
+	assert.Nil(t, err)
+
+	for _, p := range [][3]int{{0, 2, 3}, {4, 2, 3}, {1, 0, 3}, {1, 4, 3}} {
+		_, err := NewDKGParticipant(params, AuthorityID(p[0]), p[1], AuthorityIDs(p[2]))
+		assert.Equal(t, ErrDKGParams, err)
+	}
+	for _, ids := range [][]AuthorityID{{1, 2, 2}, {0, 1, 2}, {-1, 1, 2}, nil} {
+		_, err := NewDKGParticipant(params, 1, 1, ids)
+		assert.Equal(t, ErrDKGParams, err)
+	}
+	_, err = NewDKGParticipant(nil, 1, 2, AuthorityIDs(3))
+	assert.Equal(t, ErrDKGParams, err)
+
+	dp, err := NewDKGParticipant(params, 1, 2, AuthorityIDs(3))
+	assert.Nil(t, err)
+	_, err = dp.ProcessDealings(nil, nil)
+	assert.Equal(t, ErrDKGRound, err)
+	_, _, err = dp.Deal()
+	assert.Nil(t, err)
+	_, _, err = dp.Deal()
+	assert.Equal(t, ErrDKGRound, err)
+	_, _, _, err = dp.Finish(nil)
+	assert.Equal(t, ErrDKGRound, err)
+}
+
+func TestDKGHonest(t *testing.T) {
+	tests := []struct {
+		t   int
+		ids []AuthorityID
+	}{
+		{t: 1, ids: AuthorityIDs(1)},
+		{t: 2, ids: AuthorityIDs(3)},
+		{t: 3, ids: AuthorityIDs(5)},
+		{t: 2, ids: []AuthorityID{42, 5, 17}},
+	}
+	for _, test := range tests {
+		params, err := Setup(1)
+		assert.Nil(t, err)
+
+		results := runDKG(t, params, test.t, test.ids, nil)
+		honest := make([]AuthorityID, len(test.ids))
+		copy(honest, test.ids)
+		sort.Slice(honest, func(i, j int) bool { return honest[i] < honest[j] })
+		checkDKGKeys(t, params, test.t, results, honest)
+		assert.Equal(t, honest, results[honest[0]].qual)
+	}
+}
+
+func TestDKGComplaints(t *testing.T) {
+	params, err := Setup(1)
+	assert.Nil(t, err)
+	thr, n := 2, 4
+
+	// dealer 2 sends invalid share to participant 3, but answers the complaint correctly
+	corrupt := func(id AuthorityID, dealing *DKGDealing, shares []*DKGShares) (*DKGDealing, []*DKGShares) {
+		if id == 2 {
+			shares[2] = &DKGShares{from: 2, to: 3, s: shares[1].s, sPrime: shares[1].sPrime}
+		}
+		return dealing, shares
+	}
+	results := runDKG(t, params, thr, AuthorityIDs(n), &dkgAdversary{deal: corrupt})
+	checkDKGKeys(t, params, thr, results, []AuthorityID{1, 2, 3, 4})
+	assert.Equal(t, []AuthorityID{1, 2, 3, 4}, results[1].qual)
+
+	// the same dealer does not answer the complaint
+	silent := func(id AuthorityID, responses []*DKGShares) []*DKGShares {
+		if id == 2 {
+			return nil
+		}
+		return responses
+	}
+	results = runDKG(t, params, thr, AuthorityIDs(n), &dkgAdversary{deal: corrupt, responses: silent})
+	checkDKGKeys(t, params, thr, results, []AuthorityID{1, 3, 4})
+	assert.Equal(t, []AuthorityID{1, 3, 4}, results[1].qual)
+
+	// the dealer answers the complaint with invalid shares
+	lying := func(id AuthorityID, responses []*DKGShares) []*DKGShares {
+		if id == 2 {
+			for _, r := range responses {
+				r.s[0] = Curve.NewBIGint(1)
+			}
+		}
+		return responses
+	}
+	results = runDKG(t, params, thr, AuthorityIDs(n), &dkgAdversary{deal: corrupt, responses: lying})
+	checkDKGKeys(t, params, thr, results, []AuthorityID{1, 3, 4})
+	assert.Equal(t, []AuthorityID{1, 3, 4}, results[1].qual)
+
+	// dealer 4 sends invalid shares to t participants, so it is disqualified despite answering
+	corruptMany := func(id AuthorityID, dealing *DKGDealing, shares []*DKGShares) (*DKGDealing, []*DKGShares) {
+		if id == 4 {
+			shares[0], shares[1] = nil, nil
+		}
+		return dealing, shares
+	}
+	results = runDKG(t, params, thr, AuthorityIDs(n), &dkgAdversary{deal: corruptMany})
+	checkDKGKeys(t, params, thr, results, []AuthorityID{1, 2, 3})
+	assert.Equal(t, []AuthorityID{1, 2, 3}, results[1].qual)
+
+	// dealer 1 does not broadcast its dealing at all
+	absent := func(id AuthorityID, dealing *DKGDealing, shares []*DKGShares) (*DKGDealing, []*DKGShares) {
+		if id == 1 {
+			return nil, nil
+		}
+		return dealing, shares
+	}
+	results = runDKG(t, params, thr, AuthorityIDs(n), &dkgAdversary{deal: absent})
+	checkDKGKeys(t, params, thr, results, []AuthorityID{2, 3, 4})
+	assert.Equal(t, []AuthorityID{2, 3, 4}, results[2].qual)
+}
+
+func TestDKGFeldmanComplaints(t *testing.T) {
+	params, err := Setup(1)
+	assert.Nil(t, err)
+	thr, n := 2, 4
+
+	// dealer 3 broadcasts Feldman commitments inconsistent with its polynomials,
+	// so its contribution is reconstructed from the shares of the others
+	results := runDKG(t, params, thr, AuthorityIDs(n), &dkgAdversary{feldman: func(id AuthorityID, cms *DKGFeldmanCommitments) *DKGFeldmanCommitments {
+		if id == 3 {
+			cms.commitments[0][0] = params.G.Gen2Mul(Curve.NewBIGint(1))
+		}
+		return cms
+	}})
+	checkDKGKeys(t, params, thr, results, []AuthorityID{1, 2, 3, 4})
+	assert.Equal(t, []AuthorityID{1, 2, 3, 4}, results[1].qual)
+
+	// dealer 3 does not broadcast its Feldman commitments at all
+	results = runDKG(t, params, thr, AuthorityIDs(n), &dkgAdversary{feldman: func(id AuthorityID, cms *DKGFeldmanCommitments) *DKGFeldmanCommitments {
+		if id == 3 {
+			return nil
+		}
+		return cms
+	}})
+	checkDKGKeys(t, params, thr, results, []AuthorityID{1, 2, 3, 4})
+}
+
+func TestDKGOwnComplaints(t *testing.T) {
+	params, err := Setup(1)
+	assert.Nil(t, err)
+
+	dps := make([]*DKGParticipant, 3)
+	dealings := make([]*DKGDealing, 3)
+	received := make([]*DKGShares, 0, 3)
+	for i := range dps {
+		dps[i], err = NewDKGParticipant(params, AuthorityID(i+1), 2, AuthorityIDs(3))
+		assert.Nil(t, err)
+		var shares []*DKGShares
+		dealings[i], shares, err = dps[i].Deal()
+		assert.Nil(t, err)
+		// dealer 2 withholds the share of participant 1
+		if i != 1 {
+			received = append(received, shares[0])
+		}
+	}
+
+	dp := dps[0]
+	complaints, err := dp.ProcessDealings(dealings, received)
+	assert.Nil(t, err)
+	assert.Len(t, complaints, 1)
+	// the broadcast of the complaint never comes back to the participant and the dealer does not respond
+	_, err = dp.RespondToComplaints(nil)
+	assert.Nil(t, err)
+	_, err = dp.ProcessComplaintResponses(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []AuthorityID{1, 3}, dp.Qualified())
+	_, err = dp.ProcessFeldmanCommitments(nil)
+	assert.Nil(t, err)
+	_, err = dp.ProcessFeldmanComplaints(nil)
+	assert.Nil(t, err)
+}
+
+func TestDKGReconstructionFailure(t *testing.T) {
+	params, err := Setup(1)
+	assert.Nil(t, err)
+
+	dps := make([]*DKGParticipant, 2)
+	for i := range dps {
+		dps[i], err = NewDKGParticipant(params, AuthorityID(i+1), 2, AuthorityIDs(2))
+		assert.Nil(t, err)
+	}
+	dealing1, shares1, err := dps[0].Deal()
+	assert.Nil(t, err)
+	dealing2, shares2, err := dps[1].Deal()
+	assert.Nil(t, err)
+
+	dp := dps[0]
+	complaints, err := dp.ProcessDealings([]*DKGDealing{dealing1, dealing2}, []*DKGShares{shares1[0], shares2[0]})
+	assert.Nil(t, err)
+	assert.Empty(t, complaints)
+	_, err = dp.RespondToComplaints(nil)
+	assert.Nil(t, err)
+	cms, err := dp.ProcessComplaintResponses(nil)
+	assert.Nil(t, err)
+	// dealer 2 never publishes Feldman commitments and participant 2 never reveals its share
+	_, err = dp.ProcessFeldmanCommitments([]*DKGFeldmanCommitments{cms})
+	assert.Nil(t, err)
+	reconstruction, err := dp.ProcessFeldmanComplaints(nil)
+	assert.Nil(t, err)
+	assert.Len(t, reconstruction, 1)
+	_, _, _, err = dp.Finish(reconstruction)
+	assert.Equal(t, ErrDKGReconstruction, err)
+}
@@ -0,0 +1,549 @@
+// encoding.go - binary encoding of Coconut objects
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package coconut provides the functionalities required by the Coconut Scheme.
+package coconut
+
+import (
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	"github.com/jstuczyn/CoconutGo/elgamal"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// All objects are encoded as: [version | curve | tag | fields...], where:
+// curve is the identifier of the curve returned by bpgroup.CurrentCurve(),
+// BIG numbers take utils.BIGLen bytes,
+// G1 points are compressed and take utils.ECPLen bytes,
+// G2 points take utils.ECP2Len bytes,
+// authority ids take utils.IDLen bytes,
+// slices are prefixed with 4-byte big-endian number of their elements
+// and nested objects are prefixed with 4-byte big-endian length of their encoding.
+
+// encodingVersion is the version of the binary representation of all objects in the package.
+const encodingVersion byte = 1
+
+// type tags used in the header of encoded objects
+const (
+	paramsTag byte = iota + 1
+	secretKeyTag
+	verificationKeyTag
+	signatureTag
+	blindedSignatureTag
+	blindSignMatsTag
+	blindShowMatsTag
+	signerProofTag
+	verifierProofTag
+	dkgDealingTag
+	dkgSharesTag
+	dkgComplaintTag
+	dkgFeldmanCommitmentsTag
+	keygenCommitmentsTag
+	repairShareTag
+)
+
+// MarshalBinary encodes the Params as [version | curve | tag | hs | hash mode | dst].
+// Remaining parameters are fixed by the curve and are not included.
+func (params *Params) MarshalBinary() ([]byte, error) {
+	if params == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, paramsTag)
+	enc.PutECPs(params.hs)
+	enc.PutByte(byte(params.hashMode))
+	enc.PutBytes(params.hashDST)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the Params encoded by MarshalBinary and validates it.
+// The decoded Params use a new bilinear group created with bpgroup.New,
+// use UnmarshalParamsWithGroup to choose the source of randomness.
+func (params *Params) UnmarshalBinary(data []byte) error {
+	decoded, err := UnmarshalParamsWithGroup(data, bpgroup.New())
+	if err != nil {
+		return err
+	}
+	*params = *decoded
+	return nil
+}
+
+// UnmarshalParamsWithGroup decodes the Params encoded by MarshalBinary and validates it.
+// The decoded Params use the provided bilinear group, which allows choosing the source of randomness,
+// for example one created with bpgroup.NewWithReader or bpgroup.NewDeterministic.
+func UnmarshalParamsWithGroup(data []byte, G *bpgroup.BpGroup) (*Params, error) {
+	if G == nil {
+		return nil, ErrSetupParams
+	}
+	dec := utils.NewDecoder(data, encodingVersion, paramsTag)
+	hs := dec.GetECPs()
+	mode := HashMode(dec.GetByte())
+	dst := dec.GetBytes()
+	if err := dec.Finish(); err != nil {
+		return nil, err
+	}
+	if len(hs) < 1 {
+		return nil, ErrSetupParams
+	}
+	if err := validateHashMode(mode, dst); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateECPs(hs); err != nil {
+		return nil, err
+	}
+	return newParams(G, hs, mode, dst), nil
+}
+
+// MarshalBinary encodes the SecretKey as [version | curve | tag | id | x | y].
+func (sk *SecretKey) MarshalBinary() ([]byte, error) {
+	if sk == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, secretKeyTag)
+	enc.PutID(int(sk.id))
+	enc.PutBIG(sk.x)
+	enc.PutBIGs(sk.y)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the SecretKey encoded by MarshalBinary and validates it.
+func (sk *SecretKey) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, secretKeyTag)
+	id := AuthorityID(dec.GetID())
+	x := dec.GetBIG()
+	y := dec.GetBIGs()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	decoded := SecretKey{id: id, x: x, y: y}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*sk = decoded
+	return nil
+}
+
+// MarshalBinary encodes the VerificationKey as [version | curve | tag | id | g2 | alpha | beta].
+func (vk *VerificationKey) MarshalBinary() ([]byte, error) {
+	if vk == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, verificationKeyTag)
+	enc.PutID(int(vk.id))
+	enc.PutECP2(vk.g2)
+	enc.PutECP2(vk.alpha)
+	enc.PutECP2s(vk.beta)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the VerificationKey encoded by MarshalBinary and validates it.
+func (vk *VerificationKey) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, verificationKeyTag)
+	id := AuthorityID(dec.GetID())
+	g2 := dec.GetECP2()
+	alpha := dec.GetECP2()
+	beta := dec.GetECP2s()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	decoded := VerificationKey{id: id, g2: g2, alpha: alpha, beta: beta}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*vk = decoded
+	return nil
+}
+
+// MarshalBinary encodes the Signature as [version | curve | tag | sig1 | sig2].
+func (sig *Signature) MarshalBinary() ([]byte, error) {
+	if sig == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, signatureTag)
+	enc.PutECP(sig.sig1)
+	enc.PutECP(sig.sig2)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the Signature encoded by MarshalBinary and validates it.
+func (sig *Signature) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, signatureTag)
+	sig1 := dec.GetECP()
+	sig2 := dec.GetECP()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	decoded := Signature{sig1: sig1, sig2: sig2}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*sig = decoded
+	return nil
+}
+
+// MarshalBinary encodes the BlindedSignature as [version | curve | tag | sig1 | sig2Tilda].
+func (blindedSig *BlindedSignature) MarshalBinary() ([]byte, error) {
+	if blindedSig == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, blindedSignatureTag)
+	enc.PutECP(blindedSig.sig1)
+	enc.PutObject(blindedSig.sig2Tilda)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the BlindedSignature encoded by MarshalBinary and validates it.
+func (blindedSig *BlindedSignature) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, blindedSignatureTag)
+	sig1 := dec.GetECP()
+	sig2Tilda := &elgamal.Encryption{}
+	dec.GetObject(sig2Tilda)
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	decoded := BlindedSignature{sig1: sig1, sig2Tilda: sig2Tilda}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*blindedSig = decoded
+	return nil
+}
+
+// MarshalBinary encodes the BlindSignMats as [version | curve | tag | cm | enc | proof].
+func (blindSignMats *BlindSignMats) MarshalBinary() ([]byte, error) {
+	if blindSignMats == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, blindSignMatsTag)
+	enc.PutECP(blindSignMats.cm)
+	enc.PutLen(len(blindSignMats.enc))
+	for _, e := range blindSignMats.enc {
+		enc.PutObject(e)
+	}
+	enc.PutObject(blindSignMats.proof)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the BlindSignMats encoded by MarshalBinary and validates it.
+func (blindSignMats *BlindSignMats) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, blindSignMatsTag)
+	cm := dec.GetECP()
+	encs := make([]*elgamal.Encryption, dec.GetLen(2*utils.ECPLen))
+	for i := range encs {
+		encs[i] = &elgamal.Encryption{}
+		dec.GetObject(encs[i])
+	}
+	proof := &SignerProof{}
+	dec.GetObject(proof)
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	decoded := BlindSignMats{cm: cm, enc: encs, proof: proof}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*blindSignMats = decoded
+	return nil
+}
+
+// MarshalBinary encodes the BlindShowMats as [version | curve | tag | kappa | nu | proof].
+func (blindShowMats *BlindShowMats) MarshalBinary() ([]byte, error) {
+	if blindShowMats == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, blindShowMatsTag)
+	enc.PutECP2(blindShowMats.kappa)
+	enc.PutECP(blindShowMats.nu)
+	enc.PutObject(blindShowMats.proof)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the BlindShowMats encoded by MarshalBinary and validates it.
+func (blindShowMats *BlindShowMats) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, blindShowMatsTag)
+	kappa := dec.GetECP2()
+	nu := dec.GetECP()
+	proof := &VerifierProof{}
+	dec.GetObject(proof)
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	decoded := BlindShowMats{kappa: kappa, nu: nu, proof: proof}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*blindShowMats = decoded
+	return nil
+}
+
+// MarshalBinary encodes the SignerProof as [version | curve | tag | c | rr | rk | rm].
+func (proof *SignerProof) MarshalBinary() ([]byte, error) {
+	if proof == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, signerProofTag)
+	enc.PutBIG(proof.c)
+	enc.PutBIG(proof.rr)
+	enc.PutBIGs(proof.rk)
+	enc.PutBIGs(proof.rm)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the SignerProof encoded by MarshalBinary and ensures its scalars are in range.
+func (proof *SignerProof) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, signerProofTag)
+	c := dec.GetBIG()
+	rr := dec.GetBIG()
+	rk := dec.GetBIGs()
+	rm := dec.GetBIGs()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if err := validateProofScalars(c, []*Curve.BIG{rr}, rk, rm); err != nil {
+		return err
+	}
+	*proof = SignerProof{c: c, rr: rr, rk: rk, rm: rm}
+	return nil
+}
+
+// MarshalBinary encodes the VerifierProof as [version | curve | tag | c | rm | rt].
+func (proof *VerifierProof) MarshalBinary() ([]byte, error) {
+	if proof == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, verifierProofTag)
+	enc.PutBIG(proof.c)
+	enc.PutBIGs(proof.rm)
+	enc.PutBIG(proof.rt)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the VerifierProof encoded by MarshalBinary and ensures its scalars are in range.
+func (proof *VerifierProof) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, verifierProofTag)
+	c := dec.GetBIG()
+	rm := dec.GetBIGs()
+	rt := dec.GetBIG()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if err := validateProofScalars(c, rm, []*Curve.BIG{rt}); err != nil {
+		return err
+	}
+	*proof = VerifierProof{c: c, rm: rm, rt: rt}
+	return nil
+}
+
+// MarshalBinary encodes the DKGDealing as [version | curve | tag | from | commitments],
+// where commitments are prefixed with the number of polynomials.
+func (dealing *DKGDealing) MarshalBinary() ([]byte, error) {
+	if dealing == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, dkgDealingTag)
+	enc.PutID(int(dealing.from))
+	enc.PutLen(len(dealing.commitments))
+	for _, cms := range dealing.commitments {
+		enc.PutECPs(cms)
+	}
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the DKGDealing encoded by MarshalBinary and validates its points.
+func (dealing *DKGDealing) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, dkgDealingTag)
+	from := AuthorityID(dec.GetID())
+	commitments := make([][]*Curve.ECP, dec.GetLen(utils.ECPLen))
+	for i := range commitments {
+		commitments[i] = dec.GetECPs()
+	}
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if from < 1 || len(commitments) == 0 {
+		return ErrValidateLength
+	}
+	for _, cms := range commitments {
+		if err := utils.ValidateECPs(cms); err != nil {
+			return err
+		}
+	}
+	*dealing = DKGDealing{from: from, commitments: commitments}
+	return nil
+}
+
+// MarshalBinary encodes the DKGShares as [version | curve | tag | from | to | s | sPrime].
+func (shares *DKGShares) MarshalBinary() ([]byte, error) {
+	if shares == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, dkgSharesTag)
+	enc.PutID(int(shares.from))
+	enc.PutID(int(shares.to))
+	enc.PutBIGs(shares.s)
+	enc.PutBIGs(shares.sPrime)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the DKGShares encoded by MarshalBinary and validates its scalars.
+func (shares *DKGShares) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, dkgSharesTag)
+	from := AuthorityID(dec.GetID())
+	to := AuthorityID(dec.GetID())
+	s := dec.GetBIGs()
+	sPrime := dec.GetBIGs()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if from < 1 || to < 1 || len(s) == 0 || len(s) != len(sPrime) {
+		return ErrValidateLength
+	}
+	if err := utils.ValidateScalars(append(s, sPrime...)); err != nil {
+		return err
+	}
+	*shares = DKGShares{from: from, to: to, s: s, sPrime: sPrime}
+	return nil
+}
+
+// MarshalBinary encodes the DKGComplaint as [version | curve | tag | from | against].
+func (complaint *DKGComplaint) MarshalBinary() ([]byte, error) {
+	if complaint == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, dkgComplaintTag)
+	enc.PutID(int(complaint.from))
+	enc.PutID(int(complaint.against))
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the DKGComplaint encoded by MarshalBinary.
+func (complaint *DKGComplaint) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, dkgComplaintTag)
+	from := AuthorityID(dec.GetID())
+	against := AuthorityID(dec.GetID())
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if from < 1 || against < 1 {
+		return ErrValidateLength
+	}
+	*complaint = DKGComplaint{from: from, against: against}
+	return nil
+}
+
+// MarshalBinary encodes the DKGFeldmanCommitments as [version | curve | tag | from | commitments],
+// where commitments are prefixed with the number of polynomials.
+func (cms *DKGFeldmanCommitments) MarshalBinary() ([]byte, error) {
+	if cms == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, dkgFeldmanCommitmentsTag)
+	enc.PutID(int(cms.from))
+	enc.PutLen(len(cms.commitments))
+	for _, cm := range cms.commitments {
+		enc.PutECP2s(cm)
+	}
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the DKGFeldmanCommitments encoded by MarshalBinary and validates its points.
+func (cms *DKGFeldmanCommitments) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, dkgFeldmanCommitmentsTag)
+	from := AuthorityID(dec.GetID())
+	commitments := make([][]*Curve.ECP2, dec.GetLen(utils.ECP2Len))
+	for i := range commitments {
+		commitments[i] = dec.GetECP2s()
+	}
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if from < 1 || len(commitments) == 0 {
+		return ErrValidateLength
+	}
+	for _, cm := range commitments {
+		if err := utils.ValidateECP2s(cm); err != nil {
+			return err
+		}
+	}
+	*cms = DKGFeldmanCommitments{from: from, commitments: commitments}
+	return nil
+}
+
+// MarshalBinary encodes the KeygenCommitments as [version | curve | tag | alpha | beta],
+// where beta is prefixed with the number of polynomials w[i].
+func (cms *KeygenCommitments) MarshalBinary() ([]byte, error) {
+	if cms == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, keygenCommitmentsTag)
+	enc.PutECP2s(cms.alpha)
+	enc.PutLen(len(cms.beta))
+	for _, cm := range cms.beta {
+		enc.PutECP2s(cm)
+	}
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the KeygenCommitments encoded by MarshalBinary and validates them.
+func (cms *KeygenCommitments) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, keygenCommitmentsTag)
+	alpha := dec.GetECP2s()
+	beta := make([][]*Curve.ECP2, dec.GetLen(utils.ECP2Len))
+	for i := range beta {
+		beta[i] = dec.GetECP2s()
+	}
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	decoded := KeygenCommitments{alpha: alpha, beta: beta}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*cms = decoded
+	return nil
+}
+
+// MarshalBinary encodes the RepairShare as [version | curve | tag | from | to | x | y].
+func (rs *RepairShare) MarshalBinary() ([]byte, error) {
+	if rs == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, repairShareTag)
+	enc.PutID(int(rs.from))
+	enc.PutID(int(rs.to))
+	enc.PutBIG(rs.x)
+	enc.PutBIGs(rs.y)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the RepairShare encoded by MarshalBinary and validates its scalars.
+func (rs *RepairShare) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, repairShareTag)
+	from := AuthorityID(dec.GetID())
+	to := AuthorityID(dec.GetID())
+	x := dec.GetBIG()
+	y := dec.GetBIGs()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if from < 1 || to < 1 || len(y) == 0 {
+		return ErrValidateLength
+	}
+	if err := utils.ValidateScalars(append([]*Curve.BIG{x}, y...)); err != nil {
+		return err
+	}
+	*rs = RepairShare{from: from, to: to, x: x, y: y}
+	return nil
+}
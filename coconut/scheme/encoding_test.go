@@ -0,0 +1,305 @@
+// encoding_test.go - tests for binary encoding of Coconut objects
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package coconut
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	"github.com/jstuczyn/CoconutGo/elgamal"
+	"github.com/jstuczyn/amcl/version3/go/amcl"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+type binaryObject interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// encodingRoundTrip marshals the object, ensures malformed versions of its encoding are rejected
+// and unmarshals it into fresh.
+func encodingRoundTrip(t *testing.T, obj binaryObject, fresh binaryObject) {
+	b, err := obj.MarshalBinary()
+	assert.Nil(t, err)
+
+	assert.Equal(t, utils.ErrDecodeLength, fresh.UnmarshalBinary(b[:len(b)-1]), "Should reject truncated data")
+	assert.Equal(t, utils.ErrDecodeLength, fresh.UnmarshalBinary(append(b, 0x00)), "Should reject trailing data")
+	assert.Equal(t, utils.ErrDecodeLength, fresh.UnmarshalBinary([]byte{}), "Should reject empty data")
+
+	wrongVersion := append([]byte{}, b...)
+	wrongVersion[0]++
+	assert.Equal(t, utils.ErrDecodeHeader, fresh.UnmarshalBinary(wrongVersion), "Should reject unknown version")
+
+	assert.Nil(t, fresh.UnmarshalBinary(b))
+
+	b2, err := fresh.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, b, b2)
+}
+
+func TestEncoding(t *testing.T) {
+	pubM := []string{"Foo", "Bar"}
+	privM := []string{"Foo2", "Bar2"}
+
+	params, err := Setup(len(pubM) + len(privM))
+	assert.Nil(t, err)
+	paramsRec := &Params{}
+	encodingRoundTrip(t, params, paramsRec)
+	for i := range params.hs {
+		assert.True(t, params.hs[i].Equals(paramsRec.hs[i]))
+	}
+
+	pubBig := make([]*Curve.BIG, len(pubM))
+	privBig := make([]*Curve.BIG, len(privM))
+	for i := range pubM {
+		pubBig[i], err = utils.HashStringToBig(amcl.SHA256, pubM[i])
+		assert.Nil(t, err)
+	}
+	for i := range privM {
+		privBig[i], err = utils.HashStringToBig(amcl.SHA256, privM[i])
+		assert.Nil(t, err)
+	}
+
+	sk, vk, err := Keygen(paramsRec)
+	assert.Nil(t, err)
+	skRec, vkRec := &SecretKey{}, &VerificationKey{}
+	encodingRoundTrip(t, sk, skRec)
+	encodingRoundTrip(t, vk, vkRec)
+	keygenTest(t, paramsRec, skRec, vkRec)
+
+	d, gamma := elgamal.Keygen(paramsRec.G)
+	// the user's ElGamal keys are persisted between the request and unblinding
+	dRec, gammaRec := &elgamal.PrivateKey{}, &elgamal.PublicKey{}
+	encodingRoundTrip(t, d, dRec)
+	encodingRoundTrip(t, gamma, gammaRec)
+	blindSignMats, err := PrepareBlindSign(paramsRec, gamma, pubBig, privBig)
+	assert.Nil(t, err)
+	blindSignMatsRec := &BlindSignMats{}
+	encodingRoundTrip(t, blindSignMats, blindSignMatsRec)
+	assert.True(t, VerifySignerProof(params, gamma.Gamma(), blindSignMatsRec.enc, blindSignMatsRec.cm, blindSignMatsRec.proof))
+
+	blindedSig, err := BlindSign(params, skRec, blindSignMatsRec, gammaRec, pubBig)
+	assert.Nil(t, err)
+	blindedSigRec := &BlindedSignature{}
+	encodingRoundTrip(t, blindedSig, blindedSigRec)
+
+	sig := Unblind(params, blindedSigRec, dRec)
+	sigRec := &Signature{}
+	encodingRoundTrip(t, sig, sigRec)
+	assert.True(t, Verify(params, vkRec, append(privBig, pubBig...), sigRec))
+
+	blindShowMats, err := ShowBlindSignature(params, vkRec, sigRec, privBig)
+	assert.Nil(t, err)
+	blindShowMatsRec := &BlindShowMats{}
+	encodingRoundTrip(t, blindShowMats, blindShowMatsRec)
+	assert.True(t, BlindVerify(params, vk, sig, blindShowMatsRec, pubBig))
+
+	encodingRoundTrip(t, blindSignMats.proof, &SignerProof{})
+	encodingRoundTrip(t, blindShowMats.proof, &VerifierProof{})
+
+	// objects of different type should not be accepted
+	b, err := sig.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, utils.ErrDecodeHeader, blindedSigRec.UnmarshalBinary(b))
+
+	// nor incomplete objects
+	_, err = (&BlindShowMats{kappa: blindShowMats.kappa, nu: blindShowMats.nu}).MarshalBinary()
+	assert.Equal(t, utils.ErrEncodeNil, err)
+}
+
+func TestEncodingInvalidPoints(t *testing.T) {
+	params, err := Setup(1)
+	assert.Nil(t, err)
+	_, vk, err := Keygen(params)
+	assert.Nil(t, err)
+
+	b, err := vk.MarshalBinary()
+	assert.Nil(t, err)
+
+	// modify y coordinate of alpha
+	alphaEnd := utils.HeaderLen + utils.IDLen + 2*utils.ECP2Len
+	b[alphaEnd-1] ^= 0x01
+	assert.Equal(t, utils.ErrDecodeECP2, (&VerificationKey{}).UnmarshalBinary(b))
+
+	sig := &Signature{sig1: Curve.G1mul(params.g1, Curve.NewBIGint(42)), sig2: params.g1}
+	b, err = sig.MarshalBinary()
+	assert.Nil(t, err)
+
+	// x coordinate of sig1 larger than the field modulus
+	for i := utils.HeaderLen + 1; i < utils.HeaderLen+utils.ECPLen; i++ {
+		b[i] = 0xff
+	}
+	assert.Equal(t, utils.ErrDecodeECP, (&Signature{}).UnmarshalBinary(b))
+
+	// scalars of the SecretKey must be smaller than the order of the groups
+	sk := &SecretKey{x: Curve.NewBIGcopy(params.p), y: []*Curve.BIG{Curve.NewBIGint(42)}}
+	b, err = sk.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, utils.ErrValidateRange, (&SecretKey{}).UnmarshalBinary(b))
+	b, err = sk.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, utils.ErrValidateRange, (&SecretKey{}).UnmarshalJSON(b))
+
+	sk = &SecretKey{x: Curve.NewBIGint(42), y: []*Curve.BIG{Curve.NewBIGint(42)}}
+	b, err = sk.MarshalBinary()
+	assert.Nil(t, err)
+	b[len(b)-1]++
+	assert.Nil(t, (&SecretKey{}).UnmarshalBinary(b))
+	for i := len(b) - utils.BIGLen; i < len(b); i++ {
+		b[i] = 0xff
+	}
+	assert.Equal(t, utils.ErrValidateRange, (&SecretKey{}).UnmarshalBinary(b))
+
+	// so must be the scalars of the proofs
+	one := Curve.NewBIGint(1)
+	b, err = (&SignerProof{c: one, rr: one, rk: []*Curve.BIG{one}, rm: []*Curve.BIG{Curve.NewBIGcopy(params.p)}}).MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, utils.ErrValidateRange, (&SignerProof{}).UnmarshalBinary(b))
+	b, err = (&VerifierProof{c: one, rm: []*Curve.BIG{one}, rt: Curve.NewBIGcopy(params.p)}).MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, utils.ErrValidateRange, (&VerifierProof{}).UnmarshalBinary(b))
+	b, err = (&SignerProof{c: one, rr: one, rk: []*Curve.BIG{one}, rm: []*Curve.BIG{Curve.NewBIGcopy(params.p)}}).MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, utils.ErrValidateRange, (&SignerProof{}).UnmarshalJSON(b))
+	b, err = (&VerifierProof{c: one, rm: []*Curve.BIG{one}, rt: Curve.NewBIGcopy(params.p)}).MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, utils.ErrValidateRange, (&VerifierProof{}).UnmarshalJSON(b))
+
+	// the challenge is only reduced on curves other than BN254
+	b, err = (&VerifierProof{c: Curve.NewBIGcopy(params.p), rm: []*Curve.BIG{one}, rt: one}).MarshalBinary()
+	assert.Nil(t, err)
+	if bpgroup.CurrentCurve() == bpgroup.BN254 {
+		assert.Nil(t, (&VerifierProof{}).UnmarshalBinary(b))
+	} else {
+		assert.Equal(t, utils.ErrValidateRange, (&VerifierProof{}).UnmarshalBinary(b))
+	}
+}
+
+func TestEncodingNil(t *testing.T) {
+	for _, obj := range []encoding.BinaryMarshaler{
+		(*Params)(nil),
+		(*SecretKey)(nil),
+		(*VerificationKey)(nil),
+		(*Signature)(nil),
+		(*BlindedSignature)(nil),
+		(*BlindSignMats)(nil),
+		(*BlindShowMats)(nil),
+		(*SignerProof)(nil),
+		(*VerifierProof)(nil),
+	} {
+		_, err := obj.MarshalBinary()
+		assert.Equal(t, utils.ErrEncodeNil, err)
+	}
+
+	for _, obj := range []json.Marshaler{
+		(*SecretKey)(nil),
+		(*VerificationKey)(nil),
+		(*KeygenCommitments)(nil),
+		(*Signature)(nil),
+		(*BlindedSignature)(nil),
+		(*BlindSignMats)(nil),
+		(*BlindShowMats)(nil),
+		(*SignerProof)(nil),
+		(*VerifierProof)(nil),
+	} {
+		_, err := obj.MarshalJSON()
+		assert.Equal(t, utils.ErrEncodeNil, err)
+	}
+}
+
+func TestDKGMessagesEncoding(t *testing.T) {
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	dp, err := NewDKGParticipant(params, 2, 2, AuthorityIDs(3))
+	assert.Nil(t, err)
+
+	dealing, shares, err := dp.Deal()
+	assert.Nil(t, err)
+	dealingRec := &DKGDealing{}
+	encodingRoundTrip(t, dealing, dealingRec)
+	assert.Equal(t, AuthorityID(2), dealingRec.From())
+	for k := range dealing.commitments {
+		for l := range dealing.commitments[k] {
+			assert.True(t, dealing.commitments[k][l].Equals(dealingRec.commitments[k][l]))
+		}
+	}
+
+	sharesRec := &DKGShares{}
+	encodingRoundTrip(t, shares[0], sharesRec)
+	assert.Equal(t, AuthorityID(2), sharesRec.From())
+	assert.Equal(t, AuthorityID(1), sharesRec.To())
+
+	complaintRec := &DKGComplaint{}
+	encodingRoundTrip(t, &DKGComplaint{from: 3, against: 2}, complaintRec)
+	assert.Equal(t, AuthorityID(3), complaintRec.From())
+	assert.Equal(t, AuthorityID(2), complaintRec.Against())
+
+	// shares of other participants are processed as if they were sent over the network
+	_, err = dp.ProcessDealings([]*DKGDealing{dealingRec}, []*DKGShares{shares[1]})
+	assert.Nil(t, err)
+	_, err = dp.RespondToComplaints(nil)
+	assert.Nil(t, err)
+	cms, err := dp.ProcessComplaintResponses(nil)
+	assert.Nil(t, err)
+	cmsRec := &DKGFeldmanCommitments{}
+	encodingRoundTrip(t, cms, cmsRec)
+	assert.True(t, cms.commitments[0][0].Equals(cmsRec.commitments[0][0]))
+
+	b, err := (&DKGComplaint{from: 0, against: 2}).MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, ErrValidateLength, complaintRec.UnmarshalBinary(b))
+
+	var nilDealing *DKGDealing
+	_, err = nilDealing.MarshalBinary()
+	assert.Equal(t, utils.ErrEncodeNil, err)
+}
+
+func TestAuthorityIDEncoding(t *testing.T) {
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	sks, vks, _, err := TTPKeygen(params, 2, []AuthorityID{5, 9})
+	assert.Nil(t, err)
+
+	skRec, vkRec := &SecretKey{}, &VerificationKey{}
+	encodingRoundTrip(t, sks[1], skRec)
+	encodingRoundTrip(t, vks[1], vkRec)
+	assert.Equal(t, AuthorityID(9), skRec.ID())
+	assert.Equal(t, AuthorityID(9), vkRec.ID())
+	keygenTest(t, params, skRec, vkRec)
+
+	skJSONRec, vkJSONRec := &SecretKey{}, &VerificationKey{}
+	jsonRoundTrip(t, sks[0], skJSONRec)
+	jsonRoundTrip(t, vks[0], vkJSONRec)
+	assert.Equal(t, AuthorityID(5), skJSONRec.ID())
+	assert.Equal(t, AuthorityID(5), vkJSONRec.ID())
+
+	// keys not bound to any authority omit the id
+	_, vk, err := Keygen(params)
+	assert.Nil(t, err)
+	b, err := vk.MarshalJSON()
+	assert.Nil(t, err)
+	assert.NotContains(t, string(b), `"id"`)
+
+	b, err = sks[0].MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, ErrAuthorityID, skJSONRec.UnmarshalJSON(bytes.Replace(b, []byte(`"id":5`), []byte(`"id":-5`), 1)))
+}
@@ -0,0 +1,119 @@
+// feldman.go - Feldman commitments to the polynomials generated during TTPKeygen
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package coconut provides the functionalities required by the Coconut Scheme.
+package coconut
+
+import (
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// KeygenCommitments represents Feldman commitments to the coefficients of the polynomials
+// v and w[0], w[1], ... generated during TTPKeygen:
+// alpha[l] = g2^v[l],
+// beta[i][l] = g2^w[i][l].
+// They allow any party to check that a key of an authority lies on the same polynomials as all other keys.
+type KeygenCommitments struct {
+	alpha []*Curve.ECP2
+	beta  [][]*Curve.ECP2
+}
+
+// Alpha returns the commitments to the coefficients of the polynomial v.
+func (cms *KeygenCommitments) Alpha() []*Curve.ECP2 {
+	return cms.alpha
+}
+
+// Beta returns the commitments to the coefficients of the polynomials w[0], w[1], ...
+func (cms *KeygenCommitments) Beta() [][]*Curve.ECP2 {
+	return cms.beta
+}
+
+// Threshold returns the number of keys required to aggregate a credential.
+func (cms *KeygenCommitments) Threshold() int {
+	return len(cms.alpha)
+}
+
+// commitPolynomial creates Feldman commitments g2^coeff[0], g2^coeff[1], ... to the polynomial.
+func commitPolynomial(params *Params, coeff []*Curve.BIG) []*Curve.ECP2 {
+	cm := make([]*Curve.ECP2, len(coeff))
+	for l := range coeff {
+		cm[l] = params.G.Gen2Mul(coeff[l])
+	}
+	return cm
+}
+
+// NewKeygenCommitments creates Feldman commitments to the polynomials v and w[0], w[1], ...
+func NewKeygenCommitments(params *Params, v []*Curve.BIG, w [][]*Curve.BIG) *KeygenCommitments {
+	beta := make([][]*Curve.ECP2, len(w))
+	for i := range w {
+		beta[i] = commitPolynomial(params, w[i])
+	}
+	return &KeygenCommitments{alpha: commitPolynomial(params, v), beta: beta}
+}
+
+// evaluateCommitment returns g2^f(id) for the polynomial f committed to by cm.
+func evaluateCommitment(cm []*Curve.ECP2, powers []*Curve.BIG) *Curve.ECP2 {
+	return bpgroup.G2MultiMul(cm, powers)
+}
+
+// DeriveVerificationKey derives the expected VerificationKey of the authority with the given id from the commitments,
+// i.e. alpha = prod(alpha[l]^(id^l)) and beta[i] = prod(beta[i][l]^(id^l)).
+// For id 0 it returns the aggregated VerificationKey of all the authorities.
+func DeriveVerificationKey(params *Params, cms *KeygenCommitments, id AuthorityID) (*VerificationKey, error) {
+	if err := cms.validateStructure(); err != nil {
+		return nil, err
+	}
+	if id < 0 {
+		return nil, ErrAuthorityID
+	}
+	powers := idPowers(id, cms.Threshold(), params.p)
+	beta := make([]*Curve.ECP2, len(cms.beta))
+	for i := range cms.beta {
+		beta[i] = evaluateCommitment(cms.beta[i], powers)
+	}
+	return &VerificationKey{id: id, g2: params.g2, alpha: evaluateCommitment(cms.alpha, powers), beta: beta}, nil
+}
+
+// VerifySecretKeyShare checks whether the SecretKey lies on the polynomials committed to by cms
+// at the id of its authority, i.e. whether g2^x = prod(alpha[l]^(id^l))
+// and g2^y[i] = prod(beta[i][l]^(id^l)) for every i.
+// All the equations are combined with random weights into a single multi-exponentiation.
+func VerifySecretKeyShare(params *Params, cms *KeygenCommitments, sk *SecretKey) bool {
+	if cms.validateStructure() != nil || sk == nil || sk.x == nil || utils.ValidateBIGs(sk.y) != nil || sk.id < 1 || len(sk.y) != len(cms.beta) {
+		return false
+	}
+	p := params.p
+	rhos := batchWeights(len(sk.y)+1, params)
+	powers := idPowers(sk.id, cms.Threshold(), p)
+
+	sum := Curve.Modmul(rhos[0], sk.x, p)
+	points := append([]*Curve.ECP2{}, cms.alpha...)
+	scalars := make([]*Curve.BIG, 0, len(points)*(len(cms.beta)+1))
+	for l := range cms.alpha {
+		scalars = append(scalars, Curve.Modmul(rhos[0], powers[l], p))
+	}
+	for i, cm := range cms.beta {
+		sum = sum.Plus(Curve.Modmul(rhos[i+1], sk.y[i], p))
+		sum.Mod(p)
+		for l := range cm {
+			points = append(points, cm[l])
+			scalars = append(scalars, Curve.Modmul(rhos[i+1], powers[l], p))
+		}
+	}
+	return params.G.Gen2Mul(sum).Equals(bpgroup.G2MultiMul(points, scalars))
+}
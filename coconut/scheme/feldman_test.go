@@ -0,0 +1,126 @@
+// feldman_test.go - tests for Feldman commitments to the TTPKeygen polynomials
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package coconut
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// vkEqual ensures both verification keys consist of the same points.
+func vkEqual(t *testing.T, vk1 *VerificationKey, vk2 *VerificationKey) {
+	assert.Equal(t, vk1.id, vk2.id)
+	assert.True(t, vk1.g2.Equals(vk2.g2))
+	assert.True(t, vk1.alpha.Equals(vk2.alpha))
+	assert.Equal(t, len(vk1.beta), len(vk2.beta))
+	for i := range vk1.beta {
+		assert.True(t, vk1.beta[i].Equals(vk2.beta[i]))
+	}
+}
+
+func TestKeygenCommitments(t *testing.T) {
+	tests := []struct {
+		q   int
+		t   int
+		ids []AuthorityID
+	}{
+		{q: 1, t: 1, ids: AuthorityIDs(1)},
+		{q: 2, t: 2, ids: AuthorityIDs(3)},
+		{q: 3, t: 3, ids: []AuthorityID{42, 7, 1000, 3, 11}},
+	}
+
+	for _, test := range tests {
+		params, err := Setup(test.q)
+		assert.Nil(t, err)
+		sks, vks, cms, err := TTPKeygen(params, test.t, test.ids)
+		assert.Nil(t, err)
+		assert.Nil(t, cms.Validate())
+		assert.Equal(t, test.t, cms.Threshold())
+		assert.Len(t, cms.Beta(), test.q)
+
+		for i, id := range test.ids {
+			assert.True(t, VerifySecretKeyShare(params, cms, sks[i]))
+			vk, err := DeriveVerificationKey(params, cms, id)
+			assert.Nil(t, err)
+			vkEqual(t, vks[i], vk)
+		}
+
+		avk, err := AggregateVerificationKeys(params, vks[len(vks)-test.t:])
+		assert.Nil(t, err)
+		derivedAvk, err := DeriveVerificationKey(params, cms, 0)
+		assert.Nil(t, err)
+		vkEqual(t, avk, derivedAvk)
+	}
+}
+
+func TestKeygenCommitmentsInvalid(t *testing.T) {
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	sks, _, cms, err := TTPKeygen(params, 2, AuthorityIDs(3))
+	assert.Nil(t, err)
+	_, _, otherCms, err := TTPKeygen(params, 2, AuthorityIDs(3))
+	assert.Nil(t, err)
+
+	// share of a different authority or from a different keygen
+	assert.False(t, VerifySecretKeyShare(params, cms, &SecretKey{id: 2, x: sks[0].x, y: sks[0].y}))
+	assert.False(t, VerifySecretKeyShare(params, otherCms, sks[0]))
+	assert.False(t, VerifySecretKeyShare(params, cms, &SecretKey{id: 0, x: sks[0].x, y: sks[0].y}))
+
+	// single tampered element
+	x := Curve.NewBIGcopy(sks[0].x)
+	x = x.Plus(Curve.NewBIGint(1))
+	assert.False(t, VerifySecretKeyShare(params, cms, &SecretKey{id: 1, x: x, y: sks[0].y}))
+	y := []*Curve.BIG{sks[0].y[0], sks[1].y[1]}
+	assert.False(t, VerifySecretKeyShare(params, cms, &SecretKey{id: 1, x: sks[0].x, y: y}))
+	assert.False(t, VerifySecretKeyShare(params, cms, &SecretKey{id: 1, x: sks[0].x, y: sks[0].y[:1]}))
+	assert.False(t, VerifySecretKeyShare(params, cms, &SecretKey{id: 1, x: sks[0].x, y: []*Curve.BIG{nil, nil}}))
+	assert.False(t, VerifySecretKeyShare(params, cms, nil))
+	assert.False(t, VerifySecretKeyShare(params, nil, sks[0]))
+
+	_, err = DeriveVerificationKey(params, cms, -1)
+	assert.Equal(t, ErrAuthorityID, err)
+	_, err = DeriveVerificationKey(params, nil, 1)
+	assert.Equal(t, utils.ErrValidateNil, err)
+	_, err = DeriveVerificationKey(params, &KeygenCommitments{alpha: cms.alpha, beta: [][]*Curve.ECP2{cms.alpha[:1]}}, 1)
+	assert.Equal(t, ErrValidateLength, err)
+	_, err = DeriveVerificationKey(params, &KeygenCommitments{alpha: cms.alpha}, 1)
+	assert.Equal(t, ErrValidateLength, err)
+	_, err = DeriveVerificationKey(params, &KeygenCommitments{alpha: []*Curve.ECP2{Curve.NewECP2()}, beta: [][]*Curve.ECP2{cms.alpha[:1]}}, 1)
+	assert.Equal(t, utils.ErrValidateIdentity, err)
+}
+
+func TestKeygenCommitmentsEncoding(t *testing.T) {
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	sks, _, cms, err := TTPKeygen(params, 2, AuthorityIDs(3))
+	assert.Nil(t, err)
+
+	cmsRec := &KeygenCommitments{}
+	encodingRoundTrip(t, cms, cmsRec)
+	assert.True(t, VerifySecretKeyShare(params, cmsRec, sks[2]))
+
+	cmsJSONRec := &KeygenCommitments{}
+	jsonRoundTrip(t, cms, cmsJSONRec)
+	assert.True(t, VerifySecretKeyShare(params, cmsJSONRec, sks[2]))
+
+	b, err := (&KeygenCommitments{alpha: cms.alpha, beta: [][]*Curve.ECP2{cms.alpha[:1]}}).MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, ErrValidateLength, cmsRec.UnmarshalBinary(b))
+}
@@ -0,0 +1,107 @@
+// hashing.go - hashing to the G1 curve and to scalars used by the scheme
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package coconut provides the functionalities required by the Coconut Scheme.
+package coconut
+
+import (
+	"errors"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	"github.com/jstuczyn/amcl/version3/go/amcl"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// HashMode selects how the scheme hashes data to points on the G1 curve and to scalars.
+// It affects derivation of hs during setup, of the base of credentials issued on public attributes,
+// of the base h derived from the commitment during blind issuance and of challenges of the proofs.
+// All parties must use Params with the same mode.
+type HashMode byte
+
+const (
+	// HashModeLegacy uses hashing compatible with the Python implementation.
+	HashModeLegacy HashMode = iota
+
+	// HashModeIETF uses hash_to_curve and hash_to_field as defined by RFC 9380 with domain separation.
+	// It is only supported on BLS12-381.
+	HashModeIETF
+)
+
+// DefaultDST is the domain separation tag recommended for HashModeIETF.
+const DefaultDST = "COCONUT-V01-CS01-with-" + utils.G1SuiteID
+
+// Each use of hashing in HashModeIETF gets a separate domain, which is the tag of the params followed by the suffix.
+const (
+	hsDomain         = "HS_"
+	baseDomain       = "BASE_"
+	commitmentDomain = "COMMITMENT_"
+	challengeDomain  = "CHALLENGE_"
+	dkgDomain        = "DKG_"
+)
+
+var (
+	// ErrSetupHashMode indicates unknown or unsupported hashing mode or missing domain separation tag.
+	ErrSetupHashMode = errors.New("Invalid hashing mode or domain separation tag")
+)
+
+// validateHashMode ensures the mode can be used on the current curve with the given domain separation tag.
+func validateHashMode(mode HashMode, dst []byte) error {
+	switch mode {
+	case HashModeLegacy:
+		return nil
+	case HashModeIETF:
+		if bpgroup.CurrentCurve() != bpgroup.BLS381 || len(dst) == 0 {
+			return ErrSetupHashMode
+		}
+		return nil
+	default:
+		return ErrSetupHashMode
+	}
+}
+
+// domain returns domain separation tag for the given use of hashing.
+func domain(dst []byte, suffix string) []byte {
+	return append(append([]byte{}, dst...), suffix...)
+}
+
+// hashToG1 hashes msg to a point on the G1 curve in the domain of the given suffix.
+func hashToG1(mode HashMode, dst []byte, suffix string, msg []byte) (*Curve.ECP, error) {
+	if mode == HashModeIETF {
+		return utils.HashToCurveG1(msg, domain(dst, suffix))
+	}
+	// Python implementation uses SHA512
+	return utils.HashBytesToG1(amcl.SHA512, msg)
+}
+
+// hashToG1 hashes msg to a point on the G1 curve using the hashing mode of the params.
+func (params *Params) hashToG1(suffix string, msg []byte) (*Curve.ECP, error) {
+	return hashToG1(params.hashMode, params.hashDST, suffix, msg)
+}
+
+// hashToScalar hashes msg to a BIG number using the hashing mode of the params.
+func (params *Params) hashToScalar(suffix string, msg []byte) (*Curve.BIG, error) {
+	if params.hashMode == HashModeIETF {
+		return utils.HashToScalar(msg, domain(params.hashDST, suffix))
+	}
+	// Python implementation uses SHA256
+	return utils.HashBytesToBig(amcl.SHA256, msg)
+}
+
+// HashMode returns the hashing mode used by the params.
+func (params *Params) HashMode() HashMode {
+	return params.hashMode
+}
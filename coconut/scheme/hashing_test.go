@@ -0,0 +1,105 @@
+// hashing_test.go - tests of hashing modes of the scheme
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package coconut
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/elgamal"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+func TestSetupWithHashMode(t *testing.T) {
+	_, err := SetupWithHashMode(2, bpgroup.New(), HashMode(42), []byte(DefaultDST))
+	assert.Equal(t, ErrSetupHashMode, err)
+	_, err = SetupWithHashMode(2, bpgroup.New(), HashModeIETF, nil)
+	assert.Equal(t, ErrSetupHashMode, err)
+
+	if bpgroup.CurrentCurve() != bpgroup.BLS381 {
+		_, err = SetupWithHashMode(2, bpgroup.New(), HashModeIETF, []byte(DefaultDST))
+		assert.Equal(t, ErrSetupHashMode, err)
+		return
+	}
+
+	legacyParams, err := Setup(2)
+	assert.Nil(t, err)
+	assert.Equal(t, HashModeLegacy, legacyParams.HashMode())
+
+	params, err := SetupWithHashMode(2, bpgroup.New(), HashModeIETF, []byte(DefaultDST))
+	assert.Nil(t, err)
+	assert.Equal(t, HashModeIETF, params.HashMode())
+	params2, err := SetupWithHashMode(2, bpgroup.New(), HashModeIETF, []byte("OTHER-APPLICATION-DST"))
+	assert.Nil(t, err)
+
+	for i := range params.hs {
+		assert.False(t, params.hs[i].Equals(legacyParams.hs[i]))
+		assert.False(t, params.hs[i].Equals(params2.hs[i]), "Different domain separation tags should result in different hs")
+	}
+
+	// the hashing mode survives encoding
+	b, err := params.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := &Params{}
+	assert.Nil(t, decoded.UnmarshalBinary(b))
+	assert.Equal(t, HashModeIETF, decoded.HashMode())
+	assert.Equal(t, []byte(DefaultDST), decoded.hashDST)
+}
+
+func TestSchemeIETFHashMode(t *testing.T) {
+	if bpgroup.CurrentCurve() != bpgroup.BLS381 {
+		t.Skip("IETF hashing mode is only supported on BLS12-381")
+	}
+
+	params, err := SetupWithHashMode(4, bpgroup.New(), HashModeIETF, []byte(DefaultDST))
+	assert.Nil(t, err)
+	legacyParams, err := Setup(4)
+	assert.Nil(t, err)
+
+	sk, vk, err := Keygen(params)
+	assert.Nil(t, err)
+
+	pubM := []*Curve.BIG{Curve.NewBIGint(1), Curve.NewBIGint(2)}
+	privM := []*Curve.BIG{Curve.NewBIGint(3), Curve.NewBIGint(4)}
+	attrs := append(append([]*Curve.BIG{}, privM...), pubM...)
+
+	sig, err := Sign(params, sk, attrs)
+	assert.Nil(t, err)
+	assert.True(t, Verify(params, vk, attrs, sig))
+	legacySig, err := Sign(legacyParams, sk, attrs)
+	assert.Nil(t, err)
+	assert.False(t, sig.sig1.Equals(legacySig.sig1))
+
+	d, gamma := elgamal.Keygen(params.G)
+	blindSignMats, err := PrepareBlindSign(params, gamma, pubM, privM)
+	assert.Nil(t, err)
+
+	// the proof was created with different hashing
+	_, err = BlindSign(legacyParams, sk, blindSignMats, gamma, pubM)
+	assert.Equal(t, ErrBlindSignProof, err)
+
+	blindedSignature, err := BlindSign(params, sk, blindSignMats, gamma, pubM)
+	assert.Nil(t, err)
+	blindSig := Unblind(params, blindedSignature, d)
+	assert.True(t, Verify(params, vk, attrs, blindSig))
+
+	blindShowMats, err := ShowBlindSignature(params, vk, blindSig, privM)
+	assert.Nil(t, err)
+	assert.True(t, BlindVerify(params, vk, blindSig, blindShowMats, pubM))
+	assert.False(t, BlindVerify(legacyParams, vk, blindSig, blindShowMats, pubM))
+}
@@ -0,0 +1,567 @@
+// json.go - JSON encoding of Coconut objects compatible with the Python implementation
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package coconut provides the functionalities required by the Coconut Scheme.
+package coconut
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	"github.com/jstuczyn/CoconutGo/elgamal"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// All group elements and BIG numbers are represented by the same hex strings as produced by utils.ToCoconutString,
+// i.e. petlib's Bn.hex() and bplib's hexlify(G1Elem.export()) and hexlify(G2Elem.export()) of the Python implementation.
+
+// ErrJSONIncomplete indicates that the JSON document did not contain all required fields.
+var ErrJSONIncomplete = errors.New("Incomplete JSON object")
+
+type secretKeyJSON struct {
+	ID AuthorityID `json:"id,omitempty"`
+	X  string      `json:"x"`
+	Y  []string    `json:"y"`
+}
+
+type verificationKeyJSON struct {
+	ID    AuthorityID `json:"id,omitempty"`
+	G2    string      `json:"g2"`
+	Alpha string      `json:"alpha"`
+	Beta  []string    `json:"beta"`
+}
+
+type keygenCommitmentsJSON struct {
+	Alpha []string   `json:"alpha"`
+	Beta  [][]string `json:"beta"`
+}
+
+type signatureJSON struct {
+	Sig1 string `json:"sig1"`
+	Sig2 string `json:"sig2"`
+}
+
+type blindedSignatureJSON struct {
+	Sig1      string              `json:"sig1"`
+	Sig2Tilda *elgamal.Encryption `json:"sig2_tilda"`
+}
+
+type blindSignMatsJSON struct {
+	Cm    string                `json:"cm"`
+	Enc   []*elgamal.Encryption `json:"enc"`
+	Proof *SignerProof          `json:"proof"`
+}
+
+type blindShowMatsJSON struct {
+	Kappa string         `json:"kappa"`
+	Nu    string         `json:"nu"`
+	Proof *VerifierProof `json:"proof"`
+}
+
+type signerProofJSON struct {
+	C  string   `json:"c"`
+	Rr string   `json:"rr"`
+	Rk []string `json:"rk"`
+	Rm []string `json:"rm"`
+}
+
+type verifierProofJSON struct {
+	C  string   `json:"c"`
+	Rm []string `json:"rm"`
+	Rt string   `json:"rt"`
+}
+
+// hexEncoder converts elements to their string representations and retains the first encountered error.
+type hexEncoder struct {
+	err error
+}
+
+func (he *hexEncoder) big(x *Curve.BIG) string {
+	if he.err != nil {
+		return ""
+	}
+	if x == nil {
+		he.err = utils.ErrEncodeNil
+		return ""
+	}
+	return utils.ToCoconutString(x)
+}
+
+func (he *hexEncoder) bigs(xs []*Curve.BIG) []string {
+	s := make([]string, len(xs))
+	for i := range xs {
+		s[i] = he.big(xs[i])
+	}
+	return s
+}
+
+func (he *hexEncoder) ecp(p *Curve.ECP) string {
+	if he.err != nil {
+		return ""
+	}
+	if p == nil {
+		he.err = utils.ErrEncodeNil
+		return ""
+	}
+	if utils.IsInfinity(p) {
+		he.err = utils.ErrEncodeInfinity
+		return ""
+	}
+	return utils.ToCoconutString(p)
+}
+
+func (he *hexEncoder) ecp2(p *Curve.ECP2) string {
+	if he.err != nil {
+		return ""
+	}
+	if p == nil {
+		he.err = utils.ErrEncodeNil
+		return ""
+	}
+	if utils.IsInfinity2(p) {
+		he.err = utils.ErrEncodeInfinity
+		return ""
+	}
+	return utils.ToCoconutString(p)
+}
+
+func (he *hexEncoder) ecp2s(ps []*Curve.ECP2) []string {
+	s := make([]string, len(ps))
+	for i := range ps {
+		s[i] = he.ecp2(ps[i])
+	}
+	return s
+}
+
+// hexDecoder recovers elements from their string representations and retains the first encountered error.
+type hexDecoder struct {
+	err error
+}
+
+func (hd *hexDecoder) big(s string) *Curve.BIG {
+	if hd.err != nil {
+		return nil
+	}
+	x, err := utils.BIGFromCoconutString(s)
+	hd.err = err
+	return x
+}
+
+func (hd *hexDecoder) bigs(s []string) []*Curve.BIG {
+	xs := make([]*Curve.BIG, len(s))
+	for i := range s {
+		xs[i] = hd.big(s[i])
+	}
+	return xs
+}
+
+func (hd *hexDecoder) ecp(s string) *Curve.ECP {
+	if hd.err != nil {
+		return nil
+	}
+	p, err := utils.ECPFromCoconutString(s)
+	hd.err = err
+	return p
+}
+
+func (hd *hexDecoder) ecp2(s string) *Curve.ECP2 {
+	if hd.err != nil {
+		return nil
+	}
+	p, err := utils.ECP2FromCoconutString(s)
+	hd.err = err
+	return p
+}
+
+func (hd *hexDecoder) ecp2s(s []string) []*Curve.ECP2 {
+	ps := make([]*Curve.ECP2, len(s))
+	for i := range s {
+		ps[i] = hd.ecp2(s[i])
+	}
+	return ps
+}
+
+// MarshalJSON encodes the SecretKey as {"id": id, "x": x, "y": [y0, y1, ...]}.
+// The id is omitted for keys not bound to any authority id.
+func (sk *SecretKey) MarshalJSON() ([]byte, error) {
+	if sk == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	he := &hexEncoder{}
+	skJSON := secretKeyJSON{
+		ID: sk.id,
+		X:  he.big(sk.x),
+		Y:  he.bigs(sk.y),
+	}
+	if he.err != nil {
+		return nil, he.err
+	}
+	return json.Marshal(skJSON)
+}
+
+// UnmarshalJSON decodes the SecretKey encoded by MarshalJSON and validates it.
+func (sk *SecretKey) UnmarshalJSON(data []byte) error {
+	skJSON := secretKeyJSON{}
+	if err := json.Unmarshal(data, &skJSON); err != nil {
+		return err
+	}
+	hd := &hexDecoder{}
+	x := hd.big(skJSON.X)
+	y := hd.bigs(skJSON.Y)
+	if hd.err != nil {
+		return hd.err
+	}
+	decoded := SecretKey{id: skJSON.ID, x: x, y: y}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*sk = decoded
+	return nil
+}
+
+// MarshalJSON encodes the VerificationKey as {"id": id, "g2": g2, "alpha": alpha, "beta": [beta0, beta1, ...]}.
+// The id is omitted for keys not bound to any authority id.
+func (vk *VerificationKey) MarshalJSON() ([]byte, error) {
+	if vk == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	he := &hexEncoder{}
+	vkJSON := verificationKeyJSON{
+		ID:    vk.id,
+		G2:    he.ecp2(vk.g2),
+		Alpha: he.ecp2(vk.alpha),
+		Beta:  he.ecp2s(vk.beta),
+	}
+	if he.err != nil {
+		return nil, he.err
+	}
+	return json.Marshal(vkJSON)
+}
+
+// UnmarshalJSON decodes the VerificationKey encoded by MarshalJSON and validates it.
+func (vk *VerificationKey) UnmarshalJSON(data []byte) error {
+	vkJSON := verificationKeyJSON{}
+	if err := json.Unmarshal(data, &vkJSON); err != nil {
+		return err
+	}
+	hd := &hexDecoder{}
+	g2 := hd.ecp2(vkJSON.G2)
+	alpha := hd.ecp2(vkJSON.Alpha)
+	beta := hd.ecp2s(vkJSON.Beta)
+	if hd.err != nil {
+		return hd.err
+	}
+	if vkJSON.ID < 0 {
+		return ErrAuthorityID
+	}
+	decoded := VerificationKey{id: vkJSON.ID, g2: g2, alpha: alpha, beta: beta}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*vk = decoded
+	return nil
+}
+
+// MarshalJSON encodes the KeygenCommitments as {"alpha": [alpha0, alpha1, ...], "beta": [[beta00, beta01, ...], ...]}.
+func (cms *KeygenCommitments) MarshalJSON() ([]byte, error) {
+	if cms == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	he := &hexEncoder{}
+	cmsJSON := keygenCommitmentsJSON{
+		Alpha: he.ecp2s(cms.alpha),
+		Beta:  make([][]string, len(cms.beta)),
+	}
+	for i := range cms.beta {
+		cmsJSON.Beta[i] = he.ecp2s(cms.beta[i])
+	}
+	if he.err != nil {
+		return nil, he.err
+	}
+	return json.Marshal(cmsJSON)
+}
+
+// UnmarshalJSON decodes the KeygenCommitments encoded by MarshalJSON and validates them.
+func (cms *KeygenCommitments) UnmarshalJSON(data []byte) error {
+	cmsJSON := keygenCommitmentsJSON{}
+	if err := json.Unmarshal(data, &cmsJSON); err != nil {
+		return err
+	}
+	hd := &hexDecoder{}
+	alpha := hd.ecp2s(cmsJSON.Alpha)
+	beta := make([][]*Curve.ECP2, len(cmsJSON.Beta))
+	for i := range cmsJSON.Beta {
+		beta[i] = hd.ecp2s(cmsJSON.Beta[i])
+	}
+	if hd.err != nil {
+		return hd.err
+	}
+	decoded := KeygenCommitments{alpha: alpha, beta: beta}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*cms = decoded
+	return nil
+}
+
+// MarshalJSON encodes the Signature as {"sig1": sig1, "sig2": sig2}.
+func (sig *Signature) MarshalJSON() ([]byte, error) {
+	if sig == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	he := &hexEncoder{}
+	sigJSON := signatureJSON{
+		Sig1: he.ecp(sig.sig1),
+		Sig2: he.ecp(sig.sig2),
+	}
+	if he.err != nil {
+		return nil, he.err
+	}
+	return json.Marshal(sigJSON)
+}
+
+// UnmarshalJSON decodes the Signature encoded by MarshalJSON and validates it.
+func (sig *Signature) UnmarshalJSON(data []byte) error {
+	sigJSON := signatureJSON{}
+	if err := json.Unmarshal(data, &sigJSON); err != nil {
+		return err
+	}
+	hd := &hexDecoder{}
+	sig1 := hd.ecp(sigJSON.Sig1)
+	sig2 := hd.ecp(sigJSON.Sig2)
+	if hd.err != nil {
+		return hd.err
+	}
+	decoded := Signature{sig1: sig1, sig2: sig2}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*sig = decoded
+	return nil
+}
+
+// MarshalJSON encodes the BlindedSignature as {"sig1": sig1, "sig2_tilda": {"c1": c1, "c2": c2}}.
+func (blindedSig *BlindedSignature) MarshalJSON() ([]byte, error) {
+	if blindedSig == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	he := &hexEncoder{}
+	blindedSigJSON := blindedSignatureJSON{
+		Sig1:      he.ecp(blindedSig.sig1),
+		Sig2Tilda: blindedSig.sig2Tilda,
+	}
+	if he.err != nil {
+		return nil, he.err
+	}
+	if blindedSig.sig2Tilda == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	return json.Marshal(blindedSigJSON)
+}
+
+// UnmarshalJSON decodes the BlindedSignature encoded by MarshalJSON and validates it.
+func (blindedSig *BlindedSignature) UnmarshalJSON(data []byte) error {
+	blindedSigJSON := blindedSignatureJSON{}
+	if err := json.Unmarshal(data, &blindedSigJSON); err != nil {
+		return err
+	}
+	if blindedSigJSON.Sig2Tilda == nil {
+		return ErrJSONIncomplete
+	}
+	hd := &hexDecoder{}
+	sig1 := hd.ecp(blindedSigJSON.Sig1)
+	if hd.err != nil {
+		return hd.err
+	}
+	decoded := BlindedSignature{sig1: sig1, sig2Tilda: blindedSigJSON.Sig2Tilda}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*blindedSig = decoded
+	return nil
+}
+
+// MarshalJSON encodes the BlindSignMats as {"cm": cm, "enc": [{"c1": c1, "c2": c2}, ...], "proof": {...}}.
+func (blindSignMats *BlindSignMats) MarshalJSON() ([]byte, error) {
+	if blindSignMats == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	he := &hexEncoder{}
+	blindSignMatsJSON := blindSignMatsJSON{
+		Cm:    he.ecp(blindSignMats.cm),
+		Enc:   blindSignMats.enc,
+		Proof: blindSignMats.proof,
+	}
+	if he.err != nil {
+		return nil, he.err
+	}
+	if blindSignMats.proof == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	for _, enc := range blindSignMats.enc {
+		if enc == nil {
+			return nil, utils.ErrEncodeNil
+		}
+	}
+	return json.Marshal(blindSignMatsJSON)
+}
+
+// UnmarshalJSON decodes the BlindSignMats encoded by MarshalJSON and validates it.
+func (blindSignMats *BlindSignMats) UnmarshalJSON(data []byte) error {
+	blindSignMatsJSON := blindSignMatsJSON{}
+	if err := json.Unmarshal(data, &blindSignMatsJSON); err != nil {
+		return err
+	}
+	if blindSignMatsJSON.Proof == nil {
+		return ErrJSONIncomplete
+	}
+	for _, enc := range blindSignMatsJSON.Enc {
+		if enc == nil {
+			return ErrJSONIncomplete
+		}
+	}
+	hd := &hexDecoder{}
+	cm := hd.ecp(blindSignMatsJSON.Cm)
+	if hd.err != nil {
+		return hd.err
+	}
+	decoded := BlindSignMats{cm: cm, enc: blindSignMatsJSON.Enc, proof: blindSignMatsJSON.Proof}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*blindSignMats = decoded
+	return nil
+}
+
+// MarshalJSON encodes the BlindShowMats as {"kappa": kappa, "nu": nu, "proof": {...}}.
+func (blindShowMats *BlindShowMats) MarshalJSON() ([]byte, error) {
+	if blindShowMats == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	he := &hexEncoder{}
+	blindShowMatsJSON := blindShowMatsJSON{
+		Kappa: he.ecp2(blindShowMats.kappa),
+		Nu:    he.ecp(blindShowMats.nu),
+		Proof: blindShowMats.proof,
+	}
+	if he.err != nil {
+		return nil, he.err
+	}
+	if blindShowMats.proof == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	return json.Marshal(blindShowMatsJSON)
+}
+
+// UnmarshalJSON decodes the BlindShowMats encoded by MarshalJSON and validates it.
+func (blindShowMats *BlindShowMats) UnmarshalJSON(data []byte) error {
+	blindShowMatsJSON := blindShowMatsJSON{}
+	if err := json.Unmarshal(data, &blindShowMatsJSON); err != nil {
+		return err
+	}
+	if blindShowMatsJSON.Proof == nil {
+		return ErrJSONIncomplete
+	}
+	hd := &hexDecoder{}
+	kappa := hd.ecp2(blindShowMatsJSON.Kappa)
+	nu := hd.ecp(blindShowMatsJSON.Nu)
+	if hd.err != nil {
+		return hd.err
+	}
+	decoded := BlindShowMats{kappa: kappa, nu: nu, proof: blindShowMatsJSON.Proof}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*blindShowMats = decoded
+	return nil
+}
+
+// MarshalJSON encodes the SignerProof as {"c": c, "rr": rr, "rk": [rk0, ...], "rm": [rm0, ...]}.
+func (proof *SignerProof) MarshalJSON() ([]byte, error) {
+	if proof == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	he := &hexEncoder{}
+	proofJSON := signerProofJSON{
+		C:  he.big(proof.c),
+		Rr: he.big(proof.rr),
+		Rk: he.bigs(proof.rk),
+		Rm: he.bigs(proof.rm),
+	}
+	if he.err != nil {
+		return nil, he.err
+	}
+	return json.Marshal(proofJSON)
+}
+
+// UnmarshalJSON decodes the SignerProof encoded by MarshalJSON and ensures its scalars are in range.
+func (proof *SignerProof) UnmarshalJSON(data []byte) error {
+	proofJSON := signerProofJSON{}
+	if err := json.Unmarshal(data, &proofJSON); err != nil {
+		return err
+	}
+	hd := &hexDecoder{}
+	c := hd.big(proofJSON.C)
+	rr := hd.big(proofJSON.Rr)
+	rk := hd.bigs(proofJSON.Rk)
+	rm := hd.bigs(proofJSON.Rm)
+	if hd.err != nil {
+		return hd.err
+	}
+	if err := validateProofScalars(c, []*Curve.BIG{rr}, rk, rm); err != nil {
+		return err
+	}
+	*proof = SignerProof{c: c, rr: rr, rk: rk, rm: rm}
+	return nil
+}
+
+// MarshalJSON encodes the VerifierProof as {"c": c, "rm": [rm0, ...], "rt": rt}.
+func (proof *VerifierProof) MarshalJSON() ([]byte, error) {
+	if proof == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	he := &hexEncoder{}
+	proofJSON := verifierProofJSON{
+		C:  he.big(proof.c),
+		Rm: he.bigs(proof.rm),
+		Rt: he.big(proof.rt),
+	}
+	if he.err != nil {
+		return nil, he.err
+	}
+	return json.Marshal(proofJSON)
+}
+
+// UnmarshalJSON decodes the VerifierProof encoded by MarshalJSON and ensures its scalars are in range.
+func (proof *VerifierProof) UnmarshalJSON(data []byte) error {
+	proofJSON := verifierProofJSON{}
+	if err := json.Unmarshal(data, &proofJSON); err != nil {
+		return err
+	}
+	hd := &hexDecoder{}
+	c := hd.big(proofJSON.C)
+	rm := hd.bigs(proofJSON.Rm)
+	rt := hd.big(proofJSON.Rt)
+	if hd.err != nil {
+		return hd.err
+	}
+	if err := validateProofScalars(c, rm, []*Curve.BIG{rt}); err != nil {
+		return err
+	}
+	*proof = VerifierProof{c: c, rm: rm, rt: rt}
+	return nil
+}
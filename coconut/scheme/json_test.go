@@ -0,0 +1,123 @@
+// json_test.go - tests for JSON encoding of Coconut objects
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package coconut
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	"github.com/jstuczyn/CoconutGo/elgamal"
+	"github.com/jstuczyn/amcl/version3/go/amcl"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// jsonRoundTrip marshals the object to JSON, unmarshals it into fresh and ensures the result encodes the same way.
+func jsonRoundTrip(t *testing.T, obj interface{}, fresh interface{}) {
+	b, err := json.Marshal(obj)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(b, fresh))
+	b2, err := json.Marshal(fresh)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(b), string(b2))
+}
+
+func TestJSON(t *testing.T) {
+	pubM := []string{"Foo", "Bar"}
+	privM := []string{"Foo2", "Bar2"}
+
+	params, err := Setup(len(pubM) + len(privM))
+	assert.Nil(t, err)
+
+	pubBig := make([]*Curve.BIG, len(pubM))
+	privBig := make([]*Curve.BIG, len(privM))
+	for i := range pubM {
+		pubBig[i], err = utils.HashStringToBig(amcl.SHA256, pubM[i])
+		assert.Nil(t, err)
+	}
+	for i := range privM {
+		privBig[i], err = utils.HashStringToBig(amcl.SHA256, privM[i])
+		assert.Nil(t, err)
+	}
+
+	sk, vk, err := Keygen(params)
+	assert.Nil(t, err)
+	skRec, vkRec := &SecretKey{}, &VerificationKey{}
+	jsonRoundTrip(t, sk, skRec)
+	jsonRoundTrip(t, vk, vkRec)
+	keygenTest(t, params, skRec, vkRec)
+
+	d, gamma := elgamal.Keygen(params.G)
+	blindSignMats, err := PrepareBlindSign(params, gamma, pubBig, privBig)
+	assert.Nil(t, err)
+	blindSignMatsRec := &BlindSignMats{}
+	jsonRoundTrip(t, blindSignMats, blindSignMatsRec)
+
+	blindedSig, err := BlindSign(params, skRec, blindSignMatsRec, gamma, pubBig)
+	assert.Nil(t, err)
+	blindedSigRec := &BlindedSignature{}
+	jsonRoundTrip(t, blindedSig, blindedSigRec)
+
+	sig := Unblind(params, blindedSigRec, d)
+	sigRec := &Signature{}
+	jsonRoundTrip(t, sig, sigRec)
+	assert.True(t, Verify(params, vkRec, append(privBig, pubBig...), sigRec))
+
+	blindShowMats, err := ShowBlindSignature(params, vkRec, sigRec, privBig)
+	assert.Nil(t, err)
+	blindShowMatsRec := &BlindShowMats{}
+	jsonRoundTrip(t, blindShowMats, blindShowMatsRec)
+	assert.True(t, BlindVerify(params, vk, sig, blindShowMatsRec, pubBig))
+
+	jsonRoundTrip(t, blindSignMats.proof, &SignerProof{})
+	jsonRoundTrip(t, blindShowMats.proof, &VerifierProof{})
+}
+
+func TestJSONInvalid(t *testing.T) {
+	params, err := Setup(1)
+	assert.Nil(t, err)
+	_, vk, err := Keygen(params)
+	assert.Nil(t, err)
+
+	b, err := json.Marshal(vk)
+	assert.Nil(t, err)
+	vkJSON := verificationKeyJSON{}
+	assert.Nil(t, json.Unmarshal(b, &vkJSON))
+
+	// modify y coordinate of alpha
+	alpha := []byte(vkJSON.Alpha)
+	if alpha[len(alpha)-1] == '0' {
+		alpha[len(alpha)-1] = '1'
+	} else {
+		alpha[len(alpha)-1] = '0'
+	}
+	vkJSON.Alpha = string(alpha)
+	b, err = json.Marshal(vkJSON)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.ErrDecodeECP2, json.Unmarshal(b, &VerificationKey{}))
+
+	assert.Equal(t, utils.ErrDecodeECP, json.Unmarshal([]byte(`{"sig1":"zz","sig2":""}`), &Signature{}))
+	assert.Equal(t, utils.ErrDecodeBIG, json.Unmarshal([]byte(`{"y":[]}`), &SecretKey{}), "Should require x")
+	assert.Equal(t, ErrJSONIncomplete, json.Unmarshal([]byte(`{"kappa":"","nu":""}`), &BlindShowMats{}))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"x": 42}`), &SecretKey{}))
+
+	_, err = json.Marshal(&Signature{sig1: params.g1})
+	assert.NotNil(t, err)
+	_, err = json.Marshal(&BlindSignMats{cm: params.g1})
+	assert.NotNil(t, err)
+}
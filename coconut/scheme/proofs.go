@@ -21,9 +21,9 @@ import (
 	"errors"
 	"strings"
 
+	"github.com/jstuczyn/CoconutGo/bpgroup"
 	"github.com/jstuczyn/CoconutGo/coconut/utils"
 	"github.com/jstuczyn/CoconutGo/elgamal"
-	"github.com/jstuczyn/amcl/version3/go/amcl"
 	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
 )
 
@@ -60,13 +60,13 @@ var (
 // constructChallenge construct a BIG num challenge by hashing a number of Eliptic Curve points
 // It's based on the original Python implementation:
 // https://github.com/asonnino/coconut/blob/master/coconut/proofs.py#L9.
-func constructChallenge(elems []utils.Printable) *Curve.BIG {
+func constructChallenge(params *Params, elems []utils.Printable) *Curve.BIG {
 	csa := make([]string, len(elems))
 	for i := range elems {
 		csa[i] = utils.ToCoconutString(elems[i])
 	}
 	cs := strings.Join(csa, ",")
-	c, err := utils.HashStringToBig(amcl.SHA256, cs)
+	c, err := params.hashToScalar(challengeDomain, []byte(cs))
 	if err != nil {
 		panic(err)
 	}
@@ -103,7 +103,7 @@ func ConstructSignerProof(params *Params, gamma *Curve.ECP, encs []*elgamal.Encr
 	b := make([]byte, utils.MB+1)
 	cm.ToBytes(b, true)
 
-	h, err := utils.HashBytesToG1(amcl.SHA512, b)
+	h, err := params.hashToG1(commitmentDomain, b)
 	if err != nil {
 		return nil, err
 	}
@@ -114,16 +114,15 @@ func ConstructSignerProof(params *Params, gamma *Curve.ECP, encs []*elgamal.Encr
 	var Cw *Curve.ECP
 
 	for i := range wk {
-		Aw[i] = Curve.G1mul(g1, wk[i]) // Aw[i] = (wk[i] * g1)
+		Aw[i] = params.G.Gen1Mul(wk[i]) // Aw[i] = (wk[i] * g1)
 	}
 	for i := range privM {
-		Bw[i] = Curve.G1mul(h, wm[i])        // Bw[i] = (wm[i] * h)
-		Bw[i].Add(Curve.G1mul(gamma, wk[i])) // Bw[i] = (wm[i] * h) + (wk[i] * gamma)
+		Bw[i] = bpgroup.G1MulSum([]*Curve.ECP{h, gamma}, []*Curve.BIG{wm[i], wk[i]}) // Bw[i] = (wm[i] * h) + (wk[i] * gamma)
 	}
 
-	Cw = Curve.G1mul(g1, wr) // Cw = (wr * g1)
+	Cw = params.G.Gen1Mul(wr) // Cw = (wr * g1)
 	for i := range attributes {
-		Cw.Add(Curve.G1mul(hs[i], wm[i])) // Cw = (wr * g1) + (wm[0] * hs[0]) + ... + (wm[i] * hs[i])
+		Cw.Add(params.hsMul(i, wm[i])) // Cw = (wr * g1) + (wm[0] * hs[0]) + ... + (wm[i] * hs[i])
 	}
 
 	tmpSlice := []utils.Printable{g1, g2, cm, h, Cw}
@@ -144,7 +143,7 @@ func ConstructSignerProof(params *Params, gamma *Curve.ECP, encs []*elgamal.Encr
 		i++
 	}
 
-	c := constructChallenge(ca)
+	c := constructChallenge(params, ca)
 
 	// responses
 	rr := wr.Minus(Curve.Modmul(c, r, p))
@@ -180,14 +179,14 @@ func ConstructSignerProof(params *Params, gamma *Curve.ECP, encs []*elgamal.Encr
 func VerifySignerProof(params *Params, gamma *Curve.ECP, encs []*elgamal.Encryption, cm *Curve.ECP, proof *SignerProof) bool {
 	g1, g2, hs := params.g1, params.g2, params.hs
 
-	if len(encs) != len(proof.rk) {
+	if proof == nil || len(encs) != len(proof.rk) || len(proof.rm) < len(encs) || len(proof.rm) > len(hs) {
 		return false
 	}
 
 	b := make([]byte, utils.MB+1)
 	cm.ToBytes(b, true)
 
-	h, err := utils.HashBytesToG1(amcl.SHA512, b)
+	h, err := params.hashToG1(commitmentDomain, b)
 	if err != nil {
 		panic(err)
 	}
@@ -198,19 +197,18 @@ func VerifySignerProof(params *Params, gamma *Curve.ECP, encs []*elgamal.Encrypt
 
 	for i := range proof.rk {
 		Aw[i] = Curve.G1mul(encs[i].C1(), proof.c) // Aw[i] = (c * c1[i])
-		Aw[i].Add(Curve.G1mul(g1, proof.rk[i]))    // Aw[i] = (c * c1[i]) + (rk[i] * g1)
+		Aw[i].Add(params.G.Gen1Mul(proof.rk[i]))   // Aw[i] = (c * c1[i]) + (rk[i] * g1)
 	}
 
 	for i := range encs {
-		Bw[i] = Curve.G1mul(encs[i].C2(), proof.c) // Bw[i] = (c * c2[i])
-		Bw[i].Add(Curve.G1mul(gamma, proof.rk[i])) // Bw[i] = (c * c2[i]) + (rk[i] * gamma)
-		Bw[i].Add(Curve.G1mul(h, proof.rm[i]))     // Bw[i] = (c * c2[i]) + (rk[i] * gamma) + (rm[i] * h)
+		// Bw[i] = (c * c2[i]) + (rk[i] * gamma) + (rm[i] * h)
+		Bw[i] = bpgroup.G1MultiMul([]*Curve.ECP{encs[i].C2(), gamma, h}, []*Curve.BIG{proof.c, proof.rk[i], proof.rm[i]})
 	}
 
-	Cw = Curve.G1mul(cm, proof.c)     // Cw = (cm * c)
-	Cw.Add(Curve.G1mul(g1, proof.rr)) // Cw = (cm * c) + (rr * g1)
+	Cw = Curve.G1mul(cm, proof.c)      // Cw = (cm * c)
+	Cw.Add(params.G.Gen1Mul(proof.rr)) // Cw = (cm * c) + (rr * g1)
 	for i := range proof.rm {
-		Cw.Add(Curve.G1mul(hs[i], proof.rm[i])) // Cw = (cm * c) + (rr * g1) + (rm[0] * hs[0]) + ... + (rm[i] * hs[i])
+		Cw.Add(params.hsMul(i, proof.rm[i])) // Cw = (cm * c) + (rr * g1) + (rm[0] * hs[0]) + ... + (rm[i] * hs[i])
 	}
 
 	tmpSlice := []utils.Printable{g1, g2, cm, h, Cw}
@@ -231,7 +229,7 @@ func VerifySignerProof(params *Params, gamma *Curve.ECP, encs []*elgamal.Encrypt
 		i++
 	}
 
-	return Curve.Comp(proof.c, constructChallenge(ca)) == 0
+	return Curve.Comp(proof.c, constructChallenge(params, ca)) == 0
 }
 
 // ConstructVerifierProof creates a non-interactive zero-knowledge proof in order to prove corectness of kappa and nu.
@@ -249,11 +247,10 @@ func ConstructVerifierProof(params *Params, vk *VerificationKey, sig *Signature,
 	wt := Curve.Randomnum(p, rng)
 
 	// witnesses commitments
-	Aw := Curve.G2mul(g2, wt) // Aw = (wt * g2)
-	Aw.Add(vk.alpha)          // Aw = (wt * g2) + alpha
-	for i := range privM {
-		Aw.Add(Curve.G2mul(vk.beta[i], wm[i])) // Aw = (wt * g2) + alpha + (wm[0] * beta[0]) + ... + (wm[i] * beta[i])
-	}
+	Aw := bpgroup.G2MulSum(vk.beta[:len(privM)], wm) // Aw = (wm[0] * beta[0]) + ... + (wm[i] * beta[i])
+	Aw.Add(params.G.Gen2Mul(wt))                     // Aw = (wt * g2) + (wm[0] * beta[0]) + ... + (wm[i] * beta[i])
+	Aw.Add(vk.alpha)                                 // Aw = (wt * g2) + alpha + (wm[0] * beta[0]) + ... + (wm[i] * beta[i])
+
 	Bw := Curve.G1mul(sig.sig1, wt) // Bw = wt * h
 
 	tmpSlice := []utils.Printable{g1, g2, vk.alpha, Aw, Bw}
@@ -270,7 +267,7 @@ func ConstructVerifierProof(params *Params, vk *VerificationKey, sig *Signature,
 		i++
 	}
 
-	c := constructChallenge(ca)
+	c := constructChallenge(params, ca)
 
 	// responses
 	rm := make([]*Curve.BIG, len(privM))
@@ -297,21 +294,18 @@ func ConstructVerifierProof(params *Params, vk *VerificationKey, sig *Signature,
 func VerifyVerifierProof(params *Params, vk *VerificationKey, sig *Signature, showMats *BlindShowMats) bool {
 	p, g1, g2, hs := params.p, params.g1, params.g2, params.hs
 
-	Aw := Curve.G2mul(showMats.kappa, showMats.proof.c) // Aw = (c * kappa)
-	Aw.Add(Curve.G2mul(vk.g2, showMats.proof.rt))       // Aw = (c * kappa) + (rt * g2)
-
-	// Aw = (c * kappa) + (rt * g2) + (alpha)
+	// Aw = (c * kappa) + (-c * alpha) + (rm[0] * beta[0]) + ... + (rm[i] * beta[i])
+	Aw := bpgroup.G2MultiMul(
+		append([]*Curve.ECP2{showMats.kappa, vk.alpha}, vk.beta[:len(showMats.proof.rm)]...),
+		append([]*Curve.BIG{showMats.proof.c, Curve.Modneg(showMats.proof.c, p)}, showMats.proof.rm...),
+	)
+	// Aw = (c * kappa) + (rt * g2) + (alpha - alpha * c) + (rm[0] * beta[0]) + ... + (rm[i] * beta[i])
+	// = (c * kappa) + (rt * g2) + ((1 - c) * alpha) + (rm[0] * beta[0]) + ... + (rm[i] * beta[i])
+	Aw.Add(params.g2Mul(vk.g2, showMats.proof.rt))
 	Aw.Add(vk.alpha)
-	// Aw = (c * kappa) + (rt * g2) + (alpha - alpha * c) = (c * kappa) + (rt * g2) + ((1 - c) * alpha)
-	Aw.Add(Curve.G2mul(vk.alpha, Curve.Modneg(showMats.proof.c, p)))
-
-	for i := range showMats.proof.rm {
-		// Aw = (c * kappa) + (rt * g2) + ((1 - c) * alpha) + (rm[0] * beta[0]) + ... + (rm[i] * beta[i])
-		Aw.Add(Curve.G2mul(vk.beta[i], showMats.proof.rm[i]))
-	}
 
-	Bw := Curve.G1mul(showMats.nu, showMats.proof.c) // Bw = (c * nu)
-	Bw.Add(Curve.G1mul(sig.sig1, showMats.proof.rt)) // Bw = (c * nu) + (rt * h)
+	// Bw = (c * nu) + (rt * h)
+	Bw := bpgroup.G1MultiMul([]*Curve.ECP{showMats.nu, sig.sig1}, []*Curve.BIG{showMats.proof.c, showMats.proof.rt})
 
 	tmpSlice := []utils.Printable{g1, g2, vk.alpha, Aw, Bw}
 	ca := make([]utils.Printable, len(tmpSlice)+len(hs)+len(vk.beta))
@@ -326,5 +320,5 @@ func VerifyVerifierProof(params *Params, vk *VerificationKey, sig *Signature, sh
 		ca[i] = item
 		i++
 	}
-	return Curve.Comp(showMats.proof.c, constructChallenge(ca)) == 0
+	return Curve.Comp(showMats.proof.c, constructChallenge(params, ca)) == 0
 }
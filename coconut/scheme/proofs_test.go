@@ -87,31 +87,31 @@ func TestSignerProof(t *testing.T) {
 		}
 
 		if len(test.priv) > 0 {
-			_, err = ConstructSignerProof(params, gamma, encs, cm, ks[1:], r, pubBig, privBig)
+			_, err = ConstructSignerProof(params, gamma.Gamma(), encs, cm, ks[1:], r, pubBig, privBig)
 			assert.Equal(t, ErrConstructSignerCiphertexts, err)
 
-			_, err = ConstructSignerProof(params, gamma, encs[1:], cm, ks, r, pubBig, privBig)
+			_, err = ConstructSignerProof(params, gamma.Gamma(), encs[1:], cm, ks, r, pubBig, privBig)
 			assert.Equal(t, ErrConstructSignerCiphertexts, err)
 
-			_, err = ConstructSignerProof(params, gamma, encs, cm, ks, r, pubBig, privBig[1:])
+			_, err = ConstructSignerProof(params, gamma.Gamma(), encs, cm, ks, r, pubBig, privBig[1:])
 			assert.Equal(t, ErrConstructSignerCiphertexts, err)
 		}
 
-		_, err = ConstructSignerProof(&Params{G: G, hs: params.hs[1:]}, gamma, encs, cm, ks, r, pubBig, privBig)
+		_, err = ConstructSignerProof(&Params{G: G, hs: params.hs[1:]}, gamma.Gamma(), encs, cm, ks, r, pubBig, privBig)
 		assert.Equal(t, ErrConstructSignerAttrs, err)
 
-		_, err = ConstructSignerProof(params, gamma, encs, cm, ks, r, append(pubBig, Curve.NewBIG()), privBig)
+		_, err = ConstructSignerProof(params, gamma.Gamma(), encs, cm, ks, r, append(pubBig, Curve.NewBIG()), privBig)
 		assert.Equal(t, ErrConstructSignerAttrs, err)
 
-		signerProof, err := ConstructSignerProof(params, gamma, encs, cm, ks, r, pubBig, privBig)
+		signerProof, err := ConstructSignerProof(params, gamma.Gamma(), encs, cm, ks, r, pubBig, privBig)
 		assert.Nil(t, err)
 
 		if len(test.priv) > 0 {
-			assert.False(t, VerifySignerProof(params, gamma, encs[1:], cm, signerProof), test.msg)
-			assert.False(t, VerifySignerProof(params, gamma, encs, cm,
+			assert.False(t, VerifySignerProof(params, gamma.Gamma(), encs[1:], cm, signerProof), test.msg)
+			assert.False(t, VerifySignerProof(params, gamma.Gamma(), encs, cm,
 				&SignerProof{c: signerProof.c, rr: signerProof.rr, rk: signerProof.rk[1:], rm: signerProof.rm}), test.msg)
 		}
-		assert.True(t, VerifySignerProof(params, gamma, encs, cm, signerProof), test.msg)
+		assert.True(t, VerifySignerProof(params, gamma.Gamma(), encs, cm, signerProof), test.msg)
 	}
 }
 
@@ -206,7 +206,7 @@ func BenchmarkConstructSignerProof(b *testing.B) {
 				}
 
 				b.StartTimer()
-				_, err := ConstructSignerProof(params, gamma, encs, cm, ks, r, []*Curve.BIG{}, privs)
+				_, err := ConstructSignerProof(params, gamma.Gamma(), encs, cm, ks, r, []*Curve.BIG{}, privs)
 				if err != nil {
 					panic(err)
 				}
@@ -250,9 +250,9 @@ func BenchmarkVerifySignerProof(b *testing.B) {
 					ks[i] = k
 				}
 
-				signerProof, _ := ConstructSignerProof(params, gamma, encs, cm, ks, r, []*Curve.BIG{}, privs)
+				signerProof, _ := ConstructSignerProof(params, gamma.Gamma(), encs, cm, ks, r, []*Curve.BIG{}, privs)
 				b.StartTimer()
-				isValid := VerifySignerProof(params, gamma, encs, cm, signerProof)
+				isValid := VerifySignerProof(params, gamma.Gamma(), encs, cm, signerProof)
 				if !isValid {
 					panic(isValid)
 				}
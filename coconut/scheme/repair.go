@@ -0,0 +1,179 @@
+// repair.go - recovery of a lost share of threshold Coconut keys
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package coconut provides the functionalities required by the Coconut Scheme.
+package coconut
+
+import (
+	"errors"
+
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// Repair follows the enrollment protocol of Laing and Stinson: the share of the lost authority r
+// is a linear combination s_r = sum(l_i(r) * s_i) of shares of any t helpers i, where l_i(r) are
+// the Lagrange coefficients at r over the ids of the helpers. Each helper splits its term l_i(r) * s_i
+// into t random parts, one for every helper (RepairMasks). Each helper sums the parts it received
+// (CombineRepairMasks) and sends the sum to the lost authority, which adds them up (RecoverSecretKey).
+// Every message is uniformly random on its own, hence neither the helpers nor the lost authority
+// learn anything beyond the share being recovered, which in turn can be checked with VerifySecretKeyShare.
+// The same is done independently for x and every y of the SecretKey.
+
+// RepairShare represents a masked part of a SecretKey exchanged during the recovery of a lost share.
+// It is sent either between two helpers or from a helper to the lost authority.
+type RepairShare struct {
+	from AuthorityID
+	to   AuthorityID
+	x    *Curve.BIG
+	y    []*Curve.BIG
+}
+
+var (
+	// ErrRepairParams indicates incorrect parameters provided for the share recovery,
+	// such as duplicate helpers or the lost authority being one of them.
+	ErrRepairParams = errors.New("Invalid set of parameters provided to repair")
+
+	// ErrRepairShares indicates that the parts to combine were inconsistent, were addressed to a different authority,
+	// or did not consist of exactly one part from each of the helpers.
+	ErrRepairShares = errors.New("Invalid set of repair shares to combine")
+)
+
+// From returns the id of the sender of the part.
+func (rs *RepairShare) From() AuthorityID {
+	return rs.from
+}
+
+// To returns the id of the recipient of the part.
+func (rs *RepairShare) To() AuthorityID {
+	return rs.to
+}
+
+// RepairMasks splits the contribution of the helper owning the SecretKey to the share of the lost authority
+// into random parts for every helper, including itself. Each part must be sent privately to its recipient.
+func RepairMasks(params *Params, sk *SecretKey, helpers []AuthorityID, lost AuthorityID) ([]*RepairShare, error) {
+	p, rng := params.p, params.G.Rng()
+
+	if sk == nil || sk.x == nil || len(sk.y) == 0 || utils.ValidateBIGs(sk.y) != nil || lost < 1 {
+		return nil, ErrRepairParams
+	}
+	if positive, err := validateAuthorityIDs(helpers); err != nil || !positive {
+		return nil, ErrRepairParams
+	}
+	xs := make([]*Curve.BIG, len(helpers))
+	idx := -1
+	for i, h := range helpers {
+		if h == lost {
+			return nil, ErrRepairParams
+		}
+		xs[i] = Curve.NewBIGint(int(h))
+		if h == sk.id {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return nil, ErrRepairParams
+	}
+	l := utils.LagrangeBasis(idx, p, xs, int(lost))
+
+	// split returns len(helpers) random numbers summing to l * s mod p.
+	split := func(s *Curve.BIG) []*Curve.BIG {
+		parts := make([]*Curve.BIG, len(helpers))
+		last := Curve.Modmul(l, s, p)
+		for k := 0; k < len(parts)-1; k++ {
+			parts[k] = Curve.Randomnum(p, rng)
+			last = last.Plus(Curve.Modneg(parts[k], p))
+			last.Mod(p)
+		}
+		parts[len(parts)-1] = last
+		return parts
+	}
+
+	xParts := split(sk.x)
+	yParts := make([][]*Curve.BIG, len(sk.y))
+	for i := range sk.y {
+		yParts[i] = split(sk.y[i])
+	}
+
+	masks := make([]*RepairShare, len(helpers))
+	for k, h := range helpers {
+		y := make([]*Curve.BIG, len(sk.y))
+		for i := range y {
+			y[i] = yParts[i][k]
+		}
+		masks[k] = &RepairShare{from: sk.id, to: h, x: xParts[k], y: y}
+	}
+	return masks, nil
+}
+
+// sumRepairShares adds up the parts sent to the authority with the given id, one by each of the helpers.
+// A missing part would silently produce an incorrect sum, hence the parts have to come from exactly the helpers.
+func sumRepairShares(params *Params, helpers []AuthorityID, id AuthorityID, parts []*RepairShare) (*Curve.BIG, []*Curve.BIG, error) {
+	p := params.p
+
+	if positive, err := validateAuthorityIDs(helpers); err != nil || !positive {
+		return nil, nil, ErrRepairParams
+	}
+	if len(parts) != len(helpers) {
+		return nil, nil, ErrRepairShares
+	}
+	pending := make(map[AuthorityID]bool, len(helpers))
+	for _, h := range helpers {
+		pending[h] = true
+	}
+	for _, part := range parts {
+		if part == nil || part.x == nil || utils.ValidateBIGs(part.y) != nil ||
+			part.to != id || !pending[part.from] || len(part.y) != len(parts[0].y) {
+			return nil, nil, ErrRepairShares
+		}
+		pending[part.from] = false
+	}
+
+	x := Curve.NewBIG()
+	y := make([]*Curve.BIG, len(parts[0].y))
+	for i := range y {
+		y[i] = Curve.NewBIG()
+	}
+	for _, part := range parts {
+		x = x.Plus(part.x)
+		x.Mod(p)
+		for i := range y {
+			y[i] = y[i].Plus(part.y[i])
+			y[i].Mod(p)
+		}
+	}
+	return x, y, nil
+}
+
+// CombineRepairMasks sums the parts received by the helper with the given id from all the helpers
+// into its contribution to the share of the lost authority. It requires exactly one part from each of the helpers.
+func CombineRepairMasks(params *Params, helpers []AuthorityID, id AuthorityID, lost AuthorityID, parts []*RepairShare) (*RepairShare, error) {
+	x, y, err := sumRepairShares(params, helpers, id, parts)
+	if err != nil {
+		return nil, err
+	}
+	return &RepairShare{from: id, to: lost, x: x, y: y}, nil
+}
+
+// RecoverSecretKey rebuilds the SecretKey of the lost authority with the given id from the contributions of all the helpers.
+// It requires exactly one contribution from each of the helpers.
+func RecoverSecretKey(params *Params, helpers []AuthorityID, lost AuthorityID, contributions []*RepairShare) (*SecretKey, error) {
+	x, y, err := sumRepairShares(params, helpers, lost, contributions)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretKey{id: lost, x: x, y: y}, nil
+}
@@ -0,0 +1,168 @@
+// repair_test.go - tests for recovery of a lost share of threshold Coconut keys
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package coconut
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// runRepair recovers the share of the lost authority with the help of the authorities with the given ids.
+// It returns the recovered key and all the messages exchanged by the helpers.
+func runRepair(t *testing.T, params *Params, sks []*SecretKey, helpers []AuthorityID, lost AuthorityID) (*SecretKey, []*RepairShare) {
+	received := make(map[AuthorityID][]*RepairShare)
+	var sent []*RepairShare
+	for _, id := range helpers {
+		masks, err := RepairMasks(params, sks[id-1], helpers, lost)
+		assert.Nil(t, err)
+		assert.Len(t, masks, len(helpers))
+		for _, mask := range masks {
+			received[mask.To()] = append(received[mask.To()], mask)
+		}
+		sent = append(sent, masks...)
+	}
+
+	contributions := make([]*RepairShare, len(helpers))
+	for i, id := range helpers {
+		var err error
+		contributions[i], err = CombineRepairMasks(params, helpers, id, lost, received[id])
+		assert.Nil(t, err)
+		assert.Equal(t, id, contributions[i].From())
+		assert.Equal(t, lost, contributions[i].To())
+	}
+	sent = append(sent, contributions...)
+
+	sk, err := RecoverSecretKey(params, helpers, lost, contributions)
+	assert.Nil(t, err)
+	return sk, sent
+}
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		t       int
+		n       int
+		helpers []AuthorityID
+		lost    AuthorityID
+	}{
+		{t: 1, n: 2, helpers: []AuthorityID{2}, lost: 1},
+		{t: 2, n: 3, helpers: []AuthorityID{1, 3}, lost: 2},
+		{t: 3, n: 5, helpers: []AuthorityID{5, 1, 4}, lost: 2},
+		{t: 3, n: 5, helpers: []AuthorityID{1, 2, 3}, lost: 5},
+	}
+
+	params, err := Setup(2)
+	assert.Nil(t, err)
+
+	for _, test := range tests {
+		sks, vks, cms, err := TTPKeygen(params, test.t, AuthorityIDs(test.n))
+		assert.Nil(t, err)
+
+		sk, sent := runRepair(t, params, sks, test.helpers, test.lost)
+		keygenTest(t, params, sk, vks[test.lost-1])
+		assert.Equal(t, test.lost, sk.ID())
+		assert.True(t, VerifySecretKeyShare(params, cms, sk))
+
+		// for t > 1 none of the messages reveals any of the shares
+		if test.t > 1 {
+			for _, msg := range sent {
+				for _, share := range sks {
+					assert.NotEqual(t, 0, Curve.Comp(msg.x, share.x))
+					for i := range share.y {
+						assert.NotEqual(t, 0, Curve.Comp(msg.y[i], share.y[i]))
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRepairInvalid(t *testing.T) {
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	sks, _, cms, err := TTPKeygen(params, 2, AuthorityIDs(3))
+	assert.Nil(t, err)
+
+	_, err = RepairMasks(params, sks[0], []AuthorityID{1, 2}, 2)
+	assert.Equal(t, ErrRepairParams, err)
+	_, err = RepairMasks(params, sks[0], []AuthorityID{1, 1}, 3)
+	assert.Equal(t, ErrRepairParams, err)
+	_, err = RepairMasks(params, sks[0], []AuthorityID{1, 2}, 0)
+	assert.Equal(t, ErrRepairParams, err)
+	_, err = RepairMasks(params, sks[0], []AuthorityID{0, 1}, 3)
+	assert.Equal(t, ErrRepairParams, err)
+	_, err = RepairMasks(params, sks[0], []AuthorityID{2, 3}, 1)
+	assert.Equal(t, ErrRepairParams, err)
+	_, err = RepairMasks(params, nil, []AuthorityID{1, 2}, 3)
+	assert.Equal(t, ErrRepairParams, err)
+
+	masks1, err := RepairMasks(params, sks[0], []AuthorityID{1, 2}, 3)
+	assert.Nil(t, err)
+	masks2, err := RepairMasks(params, sks[1], []AuthorityID{1, 2}, 3)
+	assert.Nil(t, err)
+
+	helpers := []AuthorityID{1, 2}
+	_, err = CombineRepairMasks(params, helpers, 1, 3, []*RepairShare{masks1[0], masks1[0]})
+	assert.Equal(t, ErrRepairShares, err)
+	_, err = CombineRepairMasks(params, helpers, 1, 3, []*RepairShare{masks1[0], masks2[1]})
+	assert.Equal(t, ErrRepairShares, err)
+	_, err = CombineRepairMasks(params, helpers, 1, 3, nil)
+	assert.Equal(t, ErrRepairShares, err)
+	_, err = CombineRepairMasks(params, helpers, 1, 3, []*RepairShare{masks1[0], {from: 2, to: 1, x: masks2[0].x, y: masks2[0].y[:1]}})
+	assert.Equal(t, ErrRepairShares, err)
+	_, err = CombineRepairMasks(params, []AuthorityID{1, 1}, 1, 3, []*RepairShare{masks1[0], masks2[0]})
+	assert.Equal(t, ErrRepairParams, err)
+
+	// part of one of the helpers is missing
+	_, err = CombineRepairMasks(params, helpers, 1, 3, []*RepairShare{masks1[0]})
+	assert.Equal(t, ErrRepairShares, err)
+	// part from an authority that is not a helper
+	_, err = CombineRepairMasks(params, helpers, 1, 3, []*RepairShare{masks1[0], {from: 3, to: 1, x: masks2[0].x, y: masks2[0].y}})
+	assert.Equal(t, ErrRepairShares, err)
+
+	c1, err := CombineRepairMasks(params, helpers, 1, 3, []*RepairShare{masks1[0], masks2[0]})
+	assert.Nil(t, err)
+	c2, err := CombineRepairMasks(params, helpers, 2, 3, []*RepairShare{masks1[1], masks2[1]})
+	assert.Nil(t, err)
+	_, err = RecoverSecretKey(params, helpers, 2, []*RepairShare{c1, c2})
+	assert.Equal(t, ErrRepairShares, err)
+
+	// contribution of one of the helpers is missing
+	_, err = RecoverSecretKey(params, helpers, 3, []*RepairShare{c1})
+	assert.Equal(t, ErrRepairShares, err)
+	_, err = RecoverSecretKey(params, []AuthorityID{1}, 3, []*RepairShare{c1, c2})
+	assert.Equal(t, ErrRepairShares, err)
+	sk, err := RecoverSecretKey(params, helpers, 3, []*RepairShare{c1, c2})
+	assert.Nil(t, err)
+	assert.True(t, VerifySecretKeyShare(params, cms, sk))
+}
+
+func TestRepairShareEncoding(t *testing.T) {
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	sks, _, _, err := TTPKeygen(params, 2, AuthorityIDs(3))
+	assert.Nil(t, err)
+	masks, err := RepairMasks(params, sks[0], []AuthorityID{1, 2}, 3)
+	assert.Nil(t, err)
+
+	maskRec := &RepairShare{}
+	encodingRoundTrip(t, masks[1], maskRec)
+	assert.Equal(t, AuthorityID(1), maskRec.From())
+	assert.Equal(t, AuthorityID(2), maskRec.To())
+	assert.Equal(t, 0, Curve.Comp(masks[1].x, maskRec.x))
+}
@@ -0,0 +1,190 @@
+// reshare.go - refreshing and resharing of threshold Coconut keys
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package coconut provides the functionalities required by the Coconut Scheme.
+package coconut
+
+import (
+	"errors"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// Resharing follows the protocol of Desmedt and Jajodia, with the verifiability of Wong, Wang and Wing:
+// each of at least t old authorities with ids S deals its own SecretKey (x_i, y_i) with fresh
+// polynomials of degree t'-1 to the n' new authorities and publishes Feldman commitments to them.
+// The new authority j combines received shares as x'_j = sum(l_i * f_i(j)), where l_i are the Lagrange
+// coefficients at 0 over S, and similarly for every y'_j. The new shares lie on a polynomial of degree t'-1
+// with the same constant term, hence the aggregated verification key stays unchanged,
+// while the old shares are independent of the new ones.
+// Periodic proactive refresh is resharing among the same authorities with the same (t, n).
+
+var (
+	// ErrReshareParams indicates incorrect parameters provided for Reshare.
+	ErrReshareParams = errors.New("Invalid set of parameters provided to reshare")
+
+	// ErrReshareShares indicates that the shares or commitments to combine were inconsistent
+	// or were not dealt by distinct old authorities.
+	ErrReshareShares = errors.New("Invalid set of shares to combine")
+)
+
+// Reshare deals the SecretKey of an old authority to the new authorities with the given ids,
+// so that any t of them can recover it. It returns the shares for the new authorities and Feldman commitments
+// to the polynomials, which are to be published. Constant terms of the commitments are the
+// VerificationKey of the old authority.
+func Reshare(params *Params, sk *SecretKey, t int, ids []AuthorityID) ([]*SecretKey, *KeygenCommitments, error) {
+	p, rng := params.p, params.G.Rng()
+
+	if len(ids) < t || t <= 0 || sk == nil || sk.x == nil || len(sk.y) == 0 || utils.ValidateBIGs(sk.y) != nil {
+		return nil, nil, ErrReshareParams
+	}
+	if positive, err := validateAuthorityIDs(ids); err != nil || !positive {
+		return nil, nil, ErrReshareParams
+	}
+
+	randomPolynomial := func(constant *Curve.BIG) []*Curve.BIG {
+		coeff := make([]*Curve.BIG, t)
+		coeff[0] = Curve.NewBIGcopy(constant)
+		coeff[0].Mod(p)
+		for l := 1; l < t; l++ {
+			coeff[l] = Curve.Randomnum(p, rng)
+		}
+		return coeff
+	}
+
+	v := randomPolynomial(sk.x)
+	w := make([][]*Curve.BIG, len(sk.y))
+	for i := range w {
+		w[i] = randomPolynomial(sk.y[i])
+	}
+
+	shares := make([]*SecretKey, len(ids))
+	for j, id := range ids {
+		idBIG := Curve.NewBIGint(int(id))
+		ys := make([]*Curve.BIG, len(w))
+		for i := range w {
+			ys[i] = polyEvalMod(w[i], idBIG, p)
+		}
+		shares[j] = &SecretKey{id: id, x: polyEvalMod(v, idBIG, p), y: ys}
+	}
+	return shares, NewKeygenCommitments(params, v, w), nil
+}
+
+// VerifyReshareCommitments checks whether the commitments published by an old authority
+// commit to the SecretKey corresponding to its VerificationKey. Together with VerifySecretKeyShare
+// on the received shares it ensures the authority dealt its actual key.
+func VerifyReshareCommitments(params *Params, vk *VerificationKey, cms *KeygenCommitments) bool {
+	if vk.validateStructure() != nil || cms.validateStructure() != nil || len(vk.beta) != len(cms.beta) {
+		return false
+	}
+	if !vk.g2.Equals(params.g2) || !vk.alpha.Equals(cms.alpha[0]) {
+		return false
+	}
+	for i := range vk.beta {
+		if !vk.beta[i].Equals(cms.beta[i][0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// reshareCoefficients returns the Lagrange coefficients at 0 over the ids of the old authorities.
+func reshareCoefficients(ids []AuthorityID, p *Curve.BIG) ([]*Curve.BIG, error) {
+	if positive, err := validateAuthorityIDs(ids); err != nil || !positive {
+		return nil, ErrReshareShares
+	}
+	return lagrangeCoefficients(ids, p), nil
+}
+
+// CombineReshares combines the shares received by a new authority from the old authorities with the given ids
+// into its new SecretKey. At least t shares, where t is the threshold of the old keys, are required.
+// All the shares must be dealt to the same authority.
+func CombineReshares(params *Params, ids []AuthorityID, shares []*SecretKey) (*SecretKey, error) {
+	p := params.p
+
+	if len(ids) != len(shares) {
+		return nil, ErrReshareShares
+	}
+	l, err := reshareCoefficients(ids, p)
+	if err != nil {
+		return nil, err
+	}
+	for _, share := range shares {
+		if share == nil || share.x == nil || utils.ValidateBIGs(share.y) != nil ||
+			share.id != shares[0].id || len(share.y) != len(shares[0].y) {
+			return nil, ErrReshareShares
+		}
+	}
+
+	x := Curve.NewBIG()
+	y := make([]*Curve.BIG, len(shares[0].y))
+	for k := range y {
+		y[k] = Curve.NewBIG()
+	}
+	for i, share := range shares {
+		x = x.Plus(Curve.Modmul(l[i], share.x, p))
+		x.Mod(p)
+		for k := range y {
+			y[k] = y[k].Plus(Curve.Modmul(l[i], share.y[k], p))
+			y[k].Mod(p)
+		}
+	}
+	return &SecretKey{id: shares[0].id, x: x, y: y}, nil
+}
+
+// CombineReshareCommitments combines the commitments published by the old authorities with the given ids
+// into the commitments to the polynomials of the new keys, in the same way as CombineReshares combines the shares.
+// The new authorities can use them with VerifySecretKeyShare and DeriveVerificationKey.
+func CombineReshareCommitments(params *Params, ids []AuthorityID, cms []*KeygenCommitments) (*KeygenCommitments, error) {
+	if len(ids) != len(cms) {
+		return nil, ErrReshareShares
+	}
+	l, err := reshareCoefficients(ids, params.p)
+	if err != nil {
+		return nil, err
+	}
+	for _, cm := range cms {
+		if err := cm.validateStructure(); err != nil {
+			return nil, err
+		}
+		if len(cm.alpha) != len(cms[0].alpha) || len(cm.beta) != len(cms[0].beta) {
+			return nil, ErrReshareShares
+		}
+	}
+
+	combine := func(get func(cm *KeygenCommitments) []*Curve.ECP2) []*Curve.ECP2 {
+		combined := make([]*Curve.ECP2, len(cms[0].alpha))
+		points := make([]*Curve.ECP2, len(cms))
+		for c := range combined {
+			for i := range cms {
+				points[i] = get(cms[i])[c]
+			}
+			combined[c] = bpgroup.G2MultiMul(points, l)
+		}
+		return combined
+	}
+
+	beta := make([][]*Curve.ECP2, len(cms[0].beta))
+	for k := range beta {
+		beta[k] = combine(func(cm *KeygenCommitments) []*Curve.ECP2 { return cm.beta[k] })
+	}
+	return &KeygenCommitments{
+		alpha: combine(func(cm *KeygenCommitments) []*Curve.ECP2 { return cm.alpha }),
+		beta:  beta,
+	}, nil
+}
@@ -0,0 +1,173 @@
+// reshare_test.go - tests for refreshing and resharing of threshold Coconut keys
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package coconut
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// signThreshold issues a credential on pubM with all the keys and aggregates it together with their verification keys.
+func signThreshold(t *testing.T, params *Params, sks []*SecretKey, vks []*VerificationKey, pubM []*Curve.BIG) (*Signature, *VerificationKey) {
+	shares := make([]*SignatureShare, len(sks))
+	for i, sk := range sks {
+		sig, err := Sign(params, sk, pubM)
+		assert.Nil(t, err)
+		shares[i] = NewSignatureShare(sk.ID(), sig)
+	}
+	aSig, err := AggregateSignatures(params, shares)
+	assert.Nil(t, err)
+	avk, err := AggregateVerificationKeys(params, vks)
+	assert.Nil(t, err)
+	return aSig, avk
+}
+
+func TestReshare(t *testing.T) {
+	tests := []struct {
+		t      int
+		ids    []AuthorityID
+		newT   int
+		newIds []AuthorityID
+		msg    string
+	}{
+		{t: 2, ids: AuthorityIDs(3), newT: 2, newIds: AuthorityIDs(3), msg: "Proactive refresh of the same authorities"},
+		{t: 2, ids: AuthorityIDs(3), newT: 3, newIds: []AuthorityID{1, 2, 3, 10, 11}, msg: "New authorities join and threshold is increased"},
+		{t: 3, ids: AuthorityIDs(5), newT: 2, newIds: []AuthorityID{2, 5}, msg: "Authorities leave and threshold is decreased"},
+		{t: 1, ids: []AuthorityID{7}, newT: 1, newIds: AuthorityIDs(2), msg: "Single authority reshares to two"},
+	}
+
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	pubM := []*Curve.BIG{Curve.Randomnum(params.p, params.G.Rng()), Curve.Randomnum(params.p, params.G.Rng())}
+
+	for _, test := range tests {
+		n, newN := len(test.ids), len(test.newIds)
+		sks, vks, cms, err := TTPKeygen(params, test.t, test.ids)
+		assert.Nil(t, err)
+		avk, err := DeriveVerificationKey(params, cms, 0)
+		assert.Nil(t, err)
+
+		// credential issued before the reshare by the first t authorities
+		oldSig, _ := signThreshold(t, params, sks[:test.t], vks[:test.t], pubM)
+		assert.True(t, Verify(params, avk, pubM, oldSig), test.msg)
+
+		// last t authorities reshare their keys
+		dealers := test.ids[n-test.t:]
+		dealt := make([][]*SecretKey, test.t)
+		dealtCms := make([]*KeygenCommitments, test.t)
+		for i := range dealers {
+			dealt[i], dealtCms[i], err = Reshare(params, sks[n-test.t+i], test.newT, test.newIds)
+			assert.Nil(t, err)
+			assert.Len(t, dealt[i], newN)
+			assert.True(t, VerifyReshareCommitments(params, vks[n-test.t+i], dealtCms[i]), test.msg)
+			for j := range dealt[i] {
+				assert.Equal(t, test.newIds[j], dealt[i][j].ID())
+				assert.True(t, VerifySecretKeyShare(params, dealtCms[i], dealt[i][j]), test.msg)
+			}
+		}
+
+		newCms, err := CombineReshareCommitments(params, dealers, dealtCms)
+		assert.Nil(t, err)
+		assert.Equal(t, test.newT, newCms.Threshold())
+		newAvk, err := DeriveVerificationKey(params, newCms, 0)
+		assert.Nil(t, err)
+		vkEqual(t, avk, newAvk)
+
+		newSks := make([]*SecretKey, newN)
+		newVks := make([]*VerificationKey, newN)
+		for j, id := range test.newIds {
+			received := make([]*SecretKey, len(dealers))
+			for i := range dealers {
+				received[i] = dealt[i][j]
+			}
+			newSks[j], err = CombineReshares(params, dealers, received)
+			assert.Nil(t, err)
+			assert.Equal(t, id, newSks[j].ID())
+			assert.True(t, VerifySecretKeyShare(params, newCms, newSks[j]), test.msg)
+			newVks[j], err = DeriveVerificationKey(params, newCms, id)
+			assert.Nil(t, err)
+			keygenTest(t, params, newSks[j], newVks[j])
+		}
+		if test.newT > 1 {
+			assert.NotEqual(t, 0, Curve.Comp(sks[0].x, newSks[0].x), test.msg)
+		}
+
+		// any newT of the new authorities aggregate to the unchanged key
+		newSig, aggregatedVk := signThreshold(t, params, newSks[newN-test.newT:], newVks[newN-test.newT:], pubM)
+		vkEqual(t, avk, aggregatedVk)
+		assert.True(t, Verify(params, aggregatedVk, pubM, oldSig), test.msg)
+		assert.True(t, Verify(params, avk, pubM, newSig), test.msg)
+	}
+}
+
+func TestReshareInvalid(t *testing.T) {
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	sks, vks, _, err := TTPKeygen(params, 2, AuthorityIDs(3))
+	assert.Nil(t, err)
+
+	_, _, err = Reshare(params, sks[0], 3, AuthorityIDs(2))
+	assert.Equal(t, ErrReshareParams, err)
+	_, _, err = Reshare(params, sks[0], 0, AuthorityIDs(2))
+	assert.Equal(t, ErrReshareParams, err)
+	_, _, err = Reshare(params, sks[0], 2, []AuthorityID{1, 1, 2})
+	assert.Equal(t, ErrReshareParams, err)
+	_, _, err = Reshare(params, sks[0], 2, []AuthorityID{0, 1, 2})
+	assert.Equal(t, ErrReshareParams, err)
+	_, _, err = Reshare(params, nil, 2, AuthorityIDs(3))
+	assert.Equal(t, ErrReshareParams, err)
+	_, _, err = Reshare(params, &SecretKey{x: sks[0].x, y: []*Curve.BIG{nil}}, 2, AuthorityIDs(3))
+	assert.Equal(t, ErrReshareParams, err)
+
+	shares1, cms1, err := Reshare(params, sks[0], 2, AuthorityIDs(3))
+	assert.Nil(t, err)
+	shares2, cms2, err := Reshare(params, sks[1], 2, AuthorityIDs(3))
+	assert.Nil(t, err)
+
+	// dealer claiming a key of a different authority
+	assert.False(t, VerifyReshareCommitments(params, vks[1], cms1))
+	assert.False(t, VerifyReshareCommitments(params, vks[0], &KeygenCommitments{alpha: cms1.alpha, beta: cms1.beta[:1]}))
+	assert.False(t, VerifyReshareCommitments(params, vks[0], nil))
+
+	_, err = CombineReshares(params, []AuthorityID{1, 1}, []*SecretKey{shares1[0], shares2[0]})
+	assert.Equal(t, ErrReshareShares, err)
+	_, err = CombineReshares(params, []AuthorityID{0, 2}, []*SecretKey{shares1[0], shares2[0]})
+	assert.Equal(t, ErrReshareShares, err)
+	_, err = CombineReshares(params, []AuthorityID{1, 2}, []*SecretKey{shares1[0]})
+	assert.Equal(t, ErrReshareShares, err)
+	_, err = CombineReshares(params, nil, nil)
+	assert.Equal(t, ErrReshareShares, err)
+	_, err = CombineReshares(params, []AuthorityID{1, 2}, []*SecretKey{shares1[0], {id: 1, x: shares2[0].x, y: shares2[0].y[:1]}})
+	assert.Equal(t, ErrReshareShares, err)
+	// shares dealt to different authorities
+	_, err = CombineReshares(params, []AuthorityID{1, 2}, []*SecretKey{shares1[0], shares2[1]})
+	assert.Equal(t, ErrReshareShares, err)
+
+	_, err = CombineReshareCommitments(params, []AuthorityID{1, 1}, []*KeygenCommitments{cms1, cms2})
+	assert.Equal(t, ErrReshareShares, err)
+	_, err = CombineReshareCommitments(params, []AuthorityID{1, 2}, []*KeygenCommitments{cms1, {alpha: cms2.alpha, beta: cms2.beta[:1]}})
+	assert.Equal(t, ErrReshareShares, err)
+
+	// shares combined with wrong ids do not lie on the combined polynomial
+	sk, err := CombineReshares(params, []AuthorityID{2, 1}, []*SecretKey{shares1[0], shares2[0]})
+	assert.Nil(t, err)
+	cms, err := CombineReshareCommitments(params, []AuthorityID{1, 2}, []*KeygenCommitments{cms1, cms2})
+	assert.Nil(t, err)
+	assert.False(t, VerifySecretKeyShare(params, cms, sk))
+}
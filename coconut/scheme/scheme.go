@@ -21,29 +21,37 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/jstuczyn/CoconutGo/bpgroup"
 	"github.com/jstuczyn/CoconutGo/coconut/utils"
 	"github.com/jstuczyn/CoconutGo/elgamal"
-	"github.com/jstuczyn/amcl/version3/go/amcl"
 	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
 )
 
 // todo: parallelization with worker pool
 // todo: make errors private
-// todo: rename and restructure PolynomialPoints struct + all its uses
 // todo: comments with maths computation
 // todo: comments with python sources
 // todo: remove ShowBlindSignature and move it straight to BlindVerify?
 
+// AuthorityID identifies a Coconut signing authority in the threshold setting.
+// It is the point at which the polynomials generated during TTPKeygen are evaluated to obtain its keys,
+// hence it must be positive and unique among the authorities.
+// The zero value indicates keys not bound to any such point, i.e. those created by Keygen
+// or the aggregated ones.
+type AuthorityID int
+
 // SecretKey represents secret key of a Coconut signing authority.
 type SecretKey struct {
-	x *Curve.BIG
-	y []*Curve.BIG
+	id AuthorityID
+	x  *Curve.BIG
+	y  []*Curve.BIG
 }
 
 // VerificationKey represents verification key of a Coconut signing authority.
 type VerificationKey struct {
+	id    AuthorityID
 	g2    *Curve.ECP2
 	alpha *Curve.ECP2
 	beta  []*Curve.ECP2
@@ -70,6 +78,11 @@ type Params struct {
 	g1 *Curve.ECP
 	g2 *Curve.ECP2
 	hs []*Curve.ECP
+
+	hsTables []*bpgroup.G1Table // fixed-base precomputation tables for hs
+
+	hashMode HashMode
+	hashDST  []byte // domain separation tag used with HashModeIETF
 }
 
 // BlindSignMats encapsulates data created by PrepareBlindSign function.
@@ -86,15 +99,16 @@ type BlindShowMats struct {
 	proof *VerifierProof
 }
 
-// PolynomialPoints (tmp) represents x values of points on polynomial of degree t - 1
-// generated during TTPKeygen.
-type PolynomialPoints struct {
-	xs []*Curve.BIG
+// SignatureShare represents a credential issued by a single signing authority, tagged with its AuthorityID,
+// so that it can be aggregated in a threshold manner.
+type SignatureShare struct {
+	id  AuthorityID
+	sig *Signature
 }
 
 var (
 	// ErrSetupParams indicates incorrect parameters provided for Setup.
-	ErrSetupParams = errors.New("Can't generate params for less than 1 attribute")
+	ErrSetupParams = errors.New("Can't generate params for less than 1 attribute or without a group")
 
 	// ErrSignParams indicates inconsistent parameters provided for Sign.
 	ErrSignParams = errors.New("Invalid attributes/secret key provided")
@@ -102,7 +116,12 @@ var (
 	// ErrKeygenParams indicates incorrect parameters provided for Keygen.
 	ErrKeygenParams = errors.New("Can't generate keys for less than 1 attribute")
 
-	// ErrTTPKeygenParams indicates incorrect parameters provided for TTPKeygen.
+	// ErrAuthorityID indicates an invalid authority id, i.e. a negative one
+	// or zero where a key bound to an authority id was expected.
+	ErrAuthorityID = errors.New("Invalid authority id")
+
+	// ErrTTPKeygenParams indicates incorrect parameters provided for TTPKeygen,
+	// such as threshold larger than the number of authorities or invalid or duplicate authority ids.
 	ErrTTPKeygenParams = errors.New("Invalid set of parameters provided to keygen")
 
 	// ErrPrepareBlindSignParams indicates that number of attributes to sign is larger than q specified in Setup.
@@ -111,7 +130,8 @@ var (
 	// ErrPrepareBlindSignPrivate indicates lack of private attributes to blindly sign.
 	ErrPrepareBlindSignPrivate = errors.New("No private attributes to sign")
 
-	// ErrBlindSignParams indicates that number of attributes to sign is larger than q specified in Setup.
+	// ErrBlindSignParams indicates that number of attributes to sign is larger than q specified in Setup
+	// or than the number of attributes supported by the secret key.
 	ErrBlindSignParams = errors.New("Too many attributes to sign")
 
 	// ErrBlindSignProof indicates that proof of corectness of ciphertext and cm was invalid
@@ -120,31 +140,107 @@ var (
 	// ErrShowBlindAttr indicates that either there were no private attributes provided
 	// or their number was larger than the verification key supports
 	ErrShowBlindAttr = errors.New("Invalid attributes provided")
+
+	// ErrAggregateParams indicates that there was nothing to aggregate
+	// or that the keys or signatures were tagged with duplicate authority ids
+	// or only some of them were tagged at all.
+	ErrAggregateParams = errors.New("Invalid set of parameters provided for aggregation")
 )
 
 // Setup generates the public parameters required by the Coconut scheme.
 // q indicates the maximum number of attributes that can be embed in the credentials.
+// The parameters use hashing compatible with the Python implementation.
 func Setup(q int) (*Params, error) {
-	if q < 1 {
+	return SetupWithGroup(q, bpgroup.New())
+}
+
+// SetupWithGroup generates the public parameters required by the Coconut scheme
+// using the provided bilinear group, which allows choosing the source of randomness,
+// for example a deterministic one created with bpgroup.NewDeterministic.
+// q indicates the maximum number of attributes that can be embed in the credentials.
+func SetupWithGroup(q int, G *bpgroup.BpGroup) (*Params, error) {
+	return SetupWithHashMode(q, G, HashModeLegacy, nil)
+}
+
+// SetupWithHashMode generates the public parameters required by the Coconut scheme
+// using the provided bilinear group and hashing mode.
+// dst is the domain separation tag used with HashModeIETF, for example DefaultDST, and is ignored otherwise.
+// q indicates the maximum number of attributes that can be embed in the credentials.
+func SetupWithHashMode(q int, G *bpgroup.BpGroup, mode HashMode, dst []byte) (*Params, error) {
+	if q < 1 || G == nil {
 		return nil, ErrSetupParams
 	}
+	if err := validateHashMode(mode, dst); err != nil {
+		return nil, err
+	}
 	hs := make([]*Curve.ECP, q)
 	for i := 0; i < q; i++ {
-		hi, err := utils.HashStringToG1(amcl.SHA512, fmt.Sprintf("h%d", i))
+		hi, err := hashToG1(mode, dst, hsDomain, []byte(fmt.Sprintf("h%d", i)))
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 		hs[i] = hi
 	}
 
-	G := bpgroup.New()
+	return newParams(G, hs, mode, dst), nil
+}
+
+// hsTableCache holds precomputation tables of all hs seen so far.
+// Setup always derives the same hs, so building the tables is only done once per process.
+var (
+	hsTableCacheMu sync.Mutex
+	hsTableCache   = make(map[string]*bpgroup.G1Table)
+)
+
+// hsTable returns the precomputation table for h, building it if it was not cached already.
+func hsTable(h *Curve.ECP) *bpgroup.G1Table {
+	key := string(utils.ECPToBytes(h))
+
+	hsTableCacheMu.Lock()
+	defer hsTableCacheMu.Unlock()
+	t, ok := hsTableCache[key]
+	if !ok {
+		t = bpgroup.NewG1Table(h)
+		hsTableCache[key] = t
+	}
+	return t
+}
+
+// newParams creates Params with the given group, hs and hashing mode, alongside the precomputation tables of hs.
+func newParams(G *bpgroup.BpGroup, hs []*Curve.ECP, mode HashMode, dst []byte) *Params {
+	hsTables := make([]*bpgroup.G1Table, len(hs))
+	for i := range hs {
+		hsTables[i] = hsTable(hs[i])
+	}
+	if mode == HashModeLegacy {
+		dst = nil
+	}
 	return &Params{
-		G:  G,
-		p:  G.Order(),
-		g1: G.Gen1(),
-		g2: G.Gen2(),
-		hs: hs,
-	}, nil
+		G:        G,
+		p:        G.Order(),
+		g1:       G.Gen1(),
+		g2:       G.Gen2(),
+		hs:       hs,
+		hsTables: hsTables,
+		hashMode: mode,
+		hashDST:  dst,
+	}
+}
+
+// hsMul returns e * hs[i] using the precomputation table if available.
+func (params *Params) hsMul(i int, e *Curve.BIG) *Curve.ECP {
+	if i < len(params.hsTables) {
+		return params.hsTables[i].Mul(e)
+	}
+	return Curve.G1mul(params.hs[i], e)
+}
+
+// g2Mul returns e * g2. If g2 is the generator used by the params, the precomputation table is used.
+func (params *Params) g2Mul(g2 *Curve.ECP2, e *Curve.BIG) *Curve.ECP2 {
+	if g2 == params.g2 || g2.Equals(params.g2) {
+		return params.G.Gen2Mul(e)
+	}
+	return Curve.G2mul(g2, e)
 }
 
 // Keygen generates a single Coconut keypair ((x, y1, y2...), (g2, g2^x, g2^y1, ...)).
@@ -164,25 +260,91 @@ func Keygen(params *Params) (*SecretKey, *VerificationKey, error) {
 		y[i] = Curve.Randomnum(p, rng)
 	}
 
-	alpha := Curve.G2mul(g2, x)
+	alpha := params.G.Gen2Mul(x)
 	beta := make([]*Curve.ECP2, q)
 	vk := &VerificationKey{g2: g2, alpha: alpha, beta: beta}
 
 	for i := 0; i < q; i++ {
-		beta[i] = Curve.G2mul(g2, y[i])
+		beta[i] = params.G.Gen2Mul(y[i])
 	}
 	return sk, vk, nil
 }
 
-// TTPKeygen generates a set of n Coconut keypairs [((x, y1, y2...), (g2, g2^x, g2^y1, ...)), ...],
-// such that they support threshold aggregation of t parties.
+// AuthorityIDs returns the ids 1, 2, ..., n.
+func AuthorityIDs(n int) []AuthorityID {
+	ids := make([]AuthorityID, n)
+	for i := range ids {
+		ids[i] = AuthorityID(i + 1)
+	}
+	return ids
+}
+
+// ID returns the id of the authority owning the key.
+func (sk *SecretKey) ID() AuthorityID {
+	return sk.id
+}
+
+// ID returns the id of the authority owning the key.
+func (vk *VerificationKey) ID() AuthorityID {
+	return vk.id
+}
+
+// NewSignatureShare tags the credential issued by the authority with the given id.
+func NewSignatureShare(id AuthorityID, sig *Signature) *SignatureShare {
+	return &SignatureShare{id: id, sig: sig}
+}
+
+// ID returns the id of the authority that issued the credential.
+func (share *SignatureShare) ID() AuthorityID {
+	return share.id
+}
+
+// Signature returns the credential issued by the authority.
+func (share *SignatureShare) Signature() *Signature {
+	return share.sig
+}
+
+// validateAuthorityIDs ensures the ids are either all positive and distinct or all zero.
+// It returns whether they are positive.
+func validateAuthorityIDs(ids []AuthorityID) (bool, error) {
+	seen := make(map[AuthorityID]bool, len(ids))
+	for _, id := range ids {
+		if id < 0 || (id == 0) != (ids[0] == 0) || (id != 0 && seen[id]) {
+			return false, ErrAggregateParams
+		}
+		seen[id] = true
+	}
+	return len(ids) > 0 && ids[0] != 0, nil
+}
+
+// lagrangeCoefficients returns the Lagrange coefficients at 0 over the given authority ids.
+func lagrangeCoefficients(ids []AuthorityID, p *Curve.BIG) []*Curve.BIG {
+	xs := make([]*Curve.BIG, len(ids))
+	for i, id := range ids {
+		xs[i] = Curve.NewBIGint(int(id))
+	}
+	l := make([]*Curve.BIG, len(ids))
+	for i := range ids {
+		l[i] = utils.LagrangeBasis(i, p, xs, 0)
+	}
+	return l
+}
+
+// TTPKeygen generates a set of Coconut keypairs [((x, y1, y2...), (g2, g2^x, g2^y1, ...)), ...]
+// for the authorities with the given ids, such that they support threshold aggregation of t parties.
+// AuthorityIDs(n) can be used if the authorities have no identifiers of their own.
 // It is expected that this procedure is executed by a Trusted Third Party.
-func TTPKeygen(params *Params, t int, n int) ([]*SecretKey, []*VerificationKey, error) {
+// It also publishes Feldman commitments to the generated polynomials, so that each authority
+// can check its key using VerifySecretKeyShare and anyone can derive the keys using DeriveVerificationKey.
+func TTPKeygen(params *Params, t int, ids []AuthorityID) ([]*SecretKey, []*VerificationKey, *KeygenCommitments, error) {
 	p, g2, hs, rng := params.p, params.g2, params.hs, params.G.Rng()
 
-	q := len(hs)
+	q, n := len(hs), len(ids)
 	if n < t || t <= 0 || q <= 0 {
-		return nil, nil, ErrTTPKeygenParams
+		return nil, nil, nil, ErrTTPKeygenParams
+	}
+	if positive, err := validateAuthorityIDs(ids); err != nil || !positive {
+		return nil, nil, nil, ErrTTPKeygenParams
 	}
 
 	// polynomials generation
@@ -201,43 +363,40 @@ func TTPKeygen(params *Params, t int, n int) ([]*SecretKey, []*VerificationKey,
 
 	// secret keys
 	sks := make([]*SecretKey, n)
-	// we can use any is now, rather than 1,2...,n; might be useful if we have some authorities ids?
-	for i := 1; i < n+1; i++ {
-		iBIG := Curve.NewBIGint(i)
-		x := utils.PolyEval(v, iBIG, p)
+	for i, id := range ids {
+		idBIG := Curve.NewBIGint(int(id))
+		x := utils.PolyEval(v, idBIG, p)
+		x.Mod(p)
 		ys := make([]*Curve.BIG, q)
 		for j, wj := range w {
-			ys[j] = utils.PolyEval(wj, iBIG, p)
+			ys[j] = utils.PolyEval(wj, idBIG, p)
+			ys[j].Mod(p)
 		}
-		sks[i-1] = &SecretKey{x: x, y: ys}
+		sks[i] = &SecretKey{id: id, x: x, y: ys}
 	}
 
 	// verification keys
 	vks := make([]*VerificationKey, n)
 	for i := range sks {
-		alpha := Curve.G2mul(g2, sks[i].x)
+		alpha := params.G.Gen2Mul(sks[i].x)
 		beta := make([]*Curve.ECP2, q)
 		for j, yj := range sks[i].y {
-			beta[j] = Curve.G2mul(g2, yj)
+			beta[j] = params.G.Gen2Mul(yj)
 		}
-		vks[i] = &VerificationKey{g2: g2, alpha: alpha, beta: beta}
+		vks[i] = &VerificationKey{id: ids[i], g2: g2, alpha: alpha, beta: beta}
 
 	}
-	return sks, vks, nil
+	return sks, vks, NewKeygenCommitments(params, v, w), nil
 }
 
 // getBaseFromAttributes generates the base h from public attributes.
 // It is only used for Sign function that works exlusively on public attributes
-func getBaseFromAttributes(pubM []*Curve.BIG) *Curve.ECP {
+func getBaseFromAttributes(params *Params, pubM []*Curve.BIG) (*Curve.ECP, error) {
 	s := make([]string, len(pubM))
 	for i := range pubM {
 		s[i] = utils.ToCoconutString(pubM[i])
 	}
-	h, err := utils.HashStringToG1(amcl.SHA512, strings.Join(s, ","))
-	if err != nil {
-		panic(err)
-	}
-	return h
+	return params.hashToG1(baseDomain, []byte(strings.Join(s, ",")))
 }
 
 // Sign creates a Coconut credential under a given secret key on a set of public attributes only.
@@ -248,7 +407,10 @@ func Sign(params *Params, sk *SecretKey, pubM []*Curve.BIG) (*Signature, error)
 		return nil, ErrSignParams
 	}
 
-	h := getBaseFromAttributes(pubM)
+	h, err := getBaseFromAttributes(params, pubM)
+	if err != nil {
+		return nil, err
+	}
 
 	K := Curve.NewBIGcopy(sk.x) // K = x
 	for i := 0; i < len(pubM); i++ {
@@ -264,23 +426,27 @@ func Sign(params *Params, sk *SecretKey, pubM []*Curve.BIG) (*Signature, error)
 // It returns commitment to the private and public attributes,
 // encryptions of the private attributes
 // and zero-knowledge proof asserting corectness of the above.
-func PrepareBlindSign(params *Params, gamma *Curve.ECP, pubM []*Curve.BIG, privM []*Curve.BIG) (*BlindSignMats, error) {
-	G, p, g1, hs, rng := params.G, params.p, params.g1, params.hs, params.G.Rng()
+func PrepareBlindSign(params *Params, gamma *elgamal.PublicKey, pubM []*Curve.BIG, privM []*Curve.BIG) (*BlindSignMats, error) {
+	G, p, hs, rng := params.G, params.p, params.hs, params.G.Rng()
 
 	if len(privM) <= 0 {
 		return nil, ErrPrepareBlindSignPrivate
 	}
+	if err := gamma.Validate(); err != nil {
+		return nil, err
+	}
 	attributes := append(privM, pubM...)
 	if len(attributes) > len(hs) {
 		return nil, ErrPrepareBlindSignParams
 	}
 
 	r := Curve.Randomnum(p, rng)
-	cm := Curve.G1mul(g1, r)
+	// all the bases of the commitment are fixed, so the precomputation tables are faster than multi-scalar multiplication
+	cm := G.Gen1Mul(r)
 
 	cmElems := make([]*Curve.ECP, len(attributes))
 	for i := range attributes {
-		cmElems[i] = Curve.G1mul(hs[i], attributes[i])
+		cmElems[i] = params.hsMul(i, attributes[i])
 
 	}
 	for _, elem := range cmElems {
@@ -290,7 +456,7 @@ func PrepareBlindSign(params *Params, gamma *Curve.ECP, pubM []*Curve.BIG, privM
 	b := make([]byte, utils.MB+1)
 	cm.ToBytes(b, true)
 
-	h, err := utils.HashBytesToG1(amcl.SHA512, b)
+	h, err := params.hashToG1(commitmentDomain, b)
 	if err != nil {
 		return nil, err
 	}
@@ -304,7 +470,7 @@ func PrepareBlindSign(params *Params, gamma *Curve.ECP, pubM []*Curve.BIG, privM
 		ks[i] = k
 	}
 
-	signerProof, err := ConstructSignerProof(params, gamma, encs, cm, ks, r, pubM, privM)
+	signerProof, err := ConstructSignerProof(params, gamma.Gamma(), encs, cm, ks, r, pubM, privM)
 	if err != nil {
 		return nil, err
 	}
@@ -316,20 +482,32 @@ func PrepareBlindSign(params *Params, gamma *Curve.ECP, pubM []*Curve.BIG, privM
 }
 
 // BlindSign creates a blinded Coconut credential on the attributes provided to PrepareBlindSign.
-func BlindSign(params *Params, sk *SecretKey, blindSignMats *BlindSignMats, gamma *Curve.ECP, pubM []*Curve.BIG) (*BlindedSignature, error) {
+func BlindSign(params *Params, sk *SecretKey, blindSignMats *BlindSignMats, gamma *elgamal.PublicKey, pubM []*Curve.BIG) (*BlindedSignature, error) {
 	hs := params.hs
 
-	if len(blindSignMats.enc)+len(pubM) > len(hs) {
+	if err := sk.Validate(); err != nil {
+		return nil, err
+	}
+	if err := gamma.Validate(); err != nil {
+		return nil, err
+	}
+	if err := blindSignMats.Validate(); err != nil {
+		return nil, err
+	}
+	// the proof covers all the attributes, hence it must have a response for each of them,
+	// and the key of the authority must be able to sign all of them
+	attributes := len(blindSignMats.enc) + len(pubM)
+	if attributes > len(hs) || attributes > len(sk.y) || len(blindSignMats.proof.rm) != attributes {
 		return nil, ErrBlindSignParams
 	}
-	if !VerifySignerProof(params, gamma, blindSignMats.enc, blindSignMats.cm, blindSignMats.proof) {
+	if !VerifySignerProof(params, gamma.Gamma(), blindSignMats.enc, blindSignMats.cm, blindSignMats.proof) {
 		return nil, ErrBlindSignProof
 	}
 
 	b := make([]byte, utils.MB+1)
 	blindSignMats.cm.ToBytes(b, true)
 
-	h, err := utils.HashBytesToG1(amcl.SHA512, b)
+	h, err := params.hashToG1(commitmentDomain, b)
 	if err != nil {
 		return nil, err
 	}
@@ -340,18 +518,12 @@ func BlindSign(params *Params, sk *SecretKey, blindSignMats *BlindSignMats, gamm
 
 	}
 
-	t2 := Curve.G1mul(blindSignMats.enc[0].C1(), sk.y[0])
-	t2Elems := make([]*Curve.ECP, len(blindSignMats.enc)-1)
-	for i := 1; i < len(blindSignMats.enc); i++ {
-		t2Elems[i-1] = Curve.G1mul(blindSignMats.enc[i].C1(), sk.y[i])
-
-	}
-
-	for _, elem := range t2Elems {
-		t2.Add(elem)
+	c1s := make([]*Curve.ECP, len(blindSignMats.enc))
+	for i := range blindSignMats.enc {
+		c1s[i] = blindSignMats.enc[i].C1()
 	}
+	t2 := bpgroup.G1MulSum(c1s, sk.y[:len(c1s)])
 
-	t3 := Curve.G1mul(h, sk.x)
 	tmpSlice := make([]*Curve.ECP, len(blindSignMats.enc))
 	for i := range blindSignMats.enc {
 		tmpSlice[i] = blindSignMats.enc[i].C2()
@@ -359,14 +531,7 @@ func BlindSign(params *Params, sk *SecretKey, blindSignMats *BlindSignMats, gamm
 	tmpSlice = append(tmpSlice, t1...)
 
 	// tmpslice: all B + t1
-	t3Elems := make([]*Curve.ECP, len(sk.y))
-	for i := range sk.y {
-		t3Elems[i] = Curve.G1mul(tmpSlice[i], sk.y[i])
-	}
-
-	for _, elem := range t3Elems {
-		t3.Add(elem)
-	}
+	t3 := bpgroup.G1MulSum(append([]*Curve.ECP{h}, tmpSlice...), append([]*Curve.BIG{sk.x}, sk.y[:len(tmpSlice)]...))
 
 	return &BlindedSignature{
 		sig1:      h,
@@ -374,8 +539,10 @@ func BlindSign(params *Params, sk *SecretKey, blindSignMats *BlindSignMats, gamm
 	}, nil
 }
 
-// Unblind unblinds the blinded Coconut credential.
-func Unblind(params *Params, blindedSignature *BlindedSignature, d *Curve.BIG) *Signature {
+// Unblind unblinds the blinded Coconut credential using the ElGamal private key
+// corresponding to the public key provided to PrepareBlindSign and BlindSign.
+// The result can be checked against the VerificationKey of the issuing authority with VerifySignatureShare.
+func Unblind(params *Params, blindedSignature *BlindedSignature, d *elgamal.PrivateKey) *Signature {
 	G := params.G
 	sig2 := elgamal.Decrypt(G, d, blindedSignature.sig2Tilda)
 	return &Signature{
@@ -389,29 +556,21 @@ func Unblind(params *Params, blindedSignature *BlindedSignature, d *Curve.BIG) *
 func Verify(params *Params, vk *VerificationKey, pubM []*Curve.BIG, sig *Signature) bool {
 	G := params.G
 
-	if len(pubM) != len(vk.beta) {
+	if vk.validateStructure() != nil || sig.Validate() != nil {
 		return false
 	}
-
-	K := Curve.NewECP2()
-	K.Copy(vk.alpha) // K = X
-	tmp := make([]*Curve.ECP2, len(pubM))
-
-	for i := 0; i < len(pubM); i++ {
-		tmp[i] = Curve.G2mul(vk.beta[i], pubM[i]) // (ai * Yi)
-
-	}
-	for i := 0; i < len(pubM); i++ {
-		K.Add(tmp[i]) // K = X + (a1 * Y1) + ...
+	if len(pubM) != len(vk.beta) {
+		return false
 	}
 
-	var Gt1 *Curve.FP12
-	var Gt2 *Curve.FP12
+	K := bpgroup.G2MultiMul(vk.beta, pubM) // K = (a1 * Y1) + ...
+	K.Add(vk.alpha)                        // K = X + (a1 * Y1) + ...
 
-	Gt1 = G.Pair(sig.sig1, K)
-	Gt2 = G.Pair(sig.sig2, vk.g2)
+	// e(sig1, K) == e(sig2, g2) <=> e(sig1, K) * e(-sig2, g2) == 1
+	negSig2 := Curve.NewECP()
+	negSig2.Sub(sig.sig2)
 
-	return !sig.sig1.Is_infinity() && Gt1.Equals(Gt2)
+	return G.PairingCheck([]*Curve.ECP{sig.sig1, negSig2}, []*Curve.ECP2{K, vk.g2})
 }
 
 // ShowBlindSignature builds cryptographic material required for blind verification.
@@ -420,16 +579,20 @@ func Verify(params *Params, vk *VerificationKey, pubM []*Curve.BIG, sig *Signatu
 func ShowBlindSignature(params *Params, vk *VerificationKey, sig *Signature, privM []*Curve.BIG) (*BlindShowMats, error) {
 	p, rng := params.p, params.G.Rng()
 
+	if err := vk.validateStructure(); err != nil {
+		return nil, err
+	}
+	if err := sig.Validate(); err != nil {
+		return nil, err
+	}
 	if len(privM) <= 0 || len(privM) > len(vk.beta) {
 		return nil, ErrShowBlindAttr
 	}
 
 	t := Curve.Randomnum(p, rng)
-	kappa := Curve.G2mul(vk.g2, t)
+	kappa := bpgroup.G2MulSum(vk.beta[:len(privM)], privM)
+	kappa.Add(params.g2Mul(vk.g2, t))
 	kappa.Add(vk.alpha)
-	for i := range privM {
-		kappa.Add(Curve.G2mul(vk.beta[i], privM[i]))
-	}
 	nu := Curve.G1mul(sig.sig1, t)
 
 	verifierProof := ConstructVerifierProof(params, vk, sig, privM, t)
@@ -445,32 +608,24 @@ func ShowBlindSignature(params *Params, vk *VerificationKey, sig *Signature, pri
 func BlindVerify(params *Params, vk *VerificationKey, sig *Signature, showMats *BlindShowMats, pubM []*Curve.BIG) bool {
 	G := params.G
 
+	if vk.validateStructure() != nil || sig.Validate() != nil || showMats.Validate() != nil {
+		return false
+	}
 	privateLen := len(showMats.proof.rm)
 	if len(pubM)+privateLen > len(vk.beta) || !VerifyVerifierProof(params, vk, sig, showMats) {
 		return false
 	}
 
-	var aggr *Curve.ECP2
-	if len(pubM) <= 0 {
-		aggr = Curve.NewECP2() // new point is at infinity
-	} else {
-		aggr = Curve.G2mul(vk.beta[privateLen], pubM[0]) // guaranteed to have at least 1 element
-		for i := 1; i < len(pubM); i++ {
-			aggr.Add(Curve.G2mul(vk.beta[i+privateLen], pubM[i]))
-		}
-	}
-	t1 := Curve.NewECP2()
-	t1.Copy(showMats.kappa)
-	t1.Add(aggr)
+	// if there are no public attributes, t1 = kappa
+	t1 := bpgroup.G2MultiMul(vk.beta[privateLen:privateLen+len(pubM)], pubM)
+	t1.Add(showMats.kappa)
 
+	// e(sig1, t1) == e(sig2 + nu, g2) <=> e(sig1, t1) * e(-(sig2 + nu), g2) == 1
 	t2 := Curve.NewECP()
-	t2.Copy(sig.sig2)
-	t2.Add(showMats.nu)
-
-	Gt1 := G.Pair(sig.sig1, t1)
-	Gt2 := G.Pair(t2, vk.g2)
+	t2.Sub(sig.sig2)
+	t2.Sub(showMats.nu)
 
-	return !sig.sig1.Is_infinity() && Gt1.Equals(Gt2)
+	return G.PairingCheck([]*Curve.ECP{sig.sig1, t2}, []*Curve.ECP2{t1, vk.g2})
 }
 
 // Randomize randomizes the Coconut credential such that it becomes indistinguishable
@@ -488,33 +643,47 @@ func Randomize(params *Params, sig *Signature) *Signature {
 }
 
 // AggregateVerificationKeys aggregates verification keys of the signing authorities.
-// Optionally it does so in a threshold manner.
-func AggregateVerificationKeys(params *Params, vks []*VerificationKey, pp *PolynomialPoints) *VerificationKey {
+// If the keys are tagged with authority ids, i.e. were created by TTPKeygen, it does so in a threshold manner
+// using Lagrange coefficients over their ids, otherwise the keys are simply added together.
+// All the keys are fully validated and must be of the same length.
+func AggregateVerificationKeys(params *Params, vks []*VerificationKey) (*VerificationKey, error) {
 	p := params.p
 
-	var alpha *Curve.ECP2
-	beta := make([]*Curve.ECP2, len(vks[0].beta))
-
-	if pp != nil {
-		t := len(vks)
-		l := make([]*Curve.BIG, t)
-		for i := 0; i < t; i++ {
-			l[i] = utils.LagrangeBasis(i, p, pp.xs, 0)
+	if len(vks) == 0 {
+		return nil, ErrAggregateParams
+	}
+	ids := make([]AuthorityID, len(vks))
+	for i, vk := range vks {
+		if err := vk.Validate(); err != nil {
+			return nil, err
 		}
-
-		alpha = Curve.G2mul(vks[0].alpha, l[0])
-		for i := 1; i < len(vks); i++ {
-			alpha.Add(Curve.G2mul(vks[i].alpha, l[i]))
+		if len(vk.beta) != len(vks[0].beta) {
+			return nil, ErrValidateLength
 		}
+		ids[i] = vk.id
+	}
+	threshold, err := validateAuthorityIDs(ids)
+	if err != nil {
+		return nil, err
+	}
 
-		for i := 0; i < len(vks[0].beta); i++ {
-			beta[i] = Curve.G2mul(vks[0].beta[i], l[0])
+	var alpha *Curve.ECP2
+	beta := make([]*Curve.ECP2, len(vks[0].beta))
+
+	if threshold {
+		l := lagrangeCoefficients(ids, p)
+		alphas := make([]*Curve.ECP2, len(vks))
+		for i := range vks {
+			alphas[i] = vks[i].alpha
 		}
+		alpha = bpgroup.G2MultiMul(alphas, l)
 
-		for i := 1; i < len(vks); i++ { // we already got values from first set of keys
-			for j := 0; j < len(beta); j++ {
-				beta[j].Add(Curve.G2mul(vks[i].beta[j], l[i]))
+		betas := make([]*Curve.ECP2, len(vks))
+		for j := range beta {
+			for i := range vks {
+				betas[i] = vks[i].beta[j]
 			}
+			beta[j] = bpgroup.G2MultiMul(betas, l)
 		}
 
 	} else {
@@ -540,26 +709,44 @@ func AggregateVerificationKeys(params *Params, vks []*VerificationKey, pp *Polyn
 		g2:    vks[0].g2,
 		alpha: alpha,
 		beta:  beta,
-	}
+	}, nil
 }
 
 // AggregateSignatures aggregates Coconut credentials on the same set of attributes
 // that were produced by multiple signing authorities.
-// Optionally it does so in a threshold manner.
-func AggregateSignatures(params *Params, sigs []*Signature, pp *PolynomialPoints) *Signature {
+// If the shares are tagged with authority ids, it does so in a threshold manner
+// using Lagrange coefficients over their ids, otherwise the credentials are simply added together.
+// The shares are not verified, use AggregateVerifiedSignatures to exclude invalid ones.
+func AggregateSignatures(params *Params, shares []*SignatureShare) (*Signature, error) {
 	p := params.p
 
-	var sig2 *Curve.ECP
-	if pp != nil {
-		t := len(sigs)
-		l := make([]*Curve.BIG, t)
-		for i := 0; i < t; i++ {
-			l[i] = utils.LagrangeBasis(i, p, pp.xs, 0)
+	if len(shares) == 0 {
+		return nil, ErrAggregateParams
+	}
+	ids := make([]AuthorityID, len(shares))
+	sigs := make([]*Signature, len(shares))
+	for i, share := range shares {
+		if share == nil {
+			return nil, utils.ErrValidateNil
 		}
-		sig2 = Curve.G1mul(sigs[0].sig2, l[0])
-		for i := 1; i < len(sigs); i++ {
-			sig2.Add(Curve.G1mul(sigs[i].sig2, l[i]))
+		if err := share.sig.Validate(); err != nil {
+			return nil, err
 		}
+		ids[i], sigs[i] = share.id, share.sig
+	}
+	threshold, err := validateAuthorityIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var sig2 *Curve.ECP
+	if threshold {
+		l := lagrangeCoefficients(ids, p)
+		sig2s := make([]*Curve.ECP, len(sigs))
+		for i := range sigs {
+			sig2s[i] = sigs[i].sig2
+		}
+		sig2 = bpgroup.G1MultiMul(sig2s, l)
 	} else {
 		sig2 = Curve.NewECP()
 		sig2.Copy(sigs[0].sig2)
@@ -572,5 +759,5 @@ func AggregateSignatures(params *Params, sigs []*Signature, pp *PolynomialPoints
 	return &Signature{
 		sig1: sigs[0].sig1,
 		sig2: sig2,
-	}
+	}, nil
 }
@@ -16,7 +16,8 @@
 package coconut
 
 import (
-	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -51,7 +52,7 @@ func recoverKeys(t *testing.T, g2 *Curve.ECP2, xHex string, ysHex ...string) (*S
 		beta[i] = Curve.G2mul(g2, y[i])
 	}
 	alpha := Curve.G2mul(g2, x)
-	return &SecretKey{x, y}, &VerificationKey{g2, alpha, beta}
+	return &SecretKey{x: x, y: y}, &VerificationKey{g2: g2, alpha: alpha, beta: beta}
 }
 
 func recoverBIGSlice(t *testing.T, items ...string) []*Curve.BIG {
@@ -63,21 +64,21 @@ func recoverBIGSlice(t *testing.T, items ...string) []*Curve.BIG {
 }
 
 func BIGFromHex(t *testing.T, hexStr string) *Curve.BIG {
-	b, err := hex.DecodeString(hexStr)
+	x, err := utils.BIGFromCoconutString(hexStr)
 	assert.Nil(t, err)
-	return Curve.FromBytes(b)
+	return x
 }
 
 func ECPFromHex(t *testing.T, hexStr string) *Curve.ECP {
-	b, err := hex.DecodeString(hexStr)
+	p, err := utils.ECPFromCoconutString(hexStr)
 	assert.Nil(t, err)
-	return Curve.ECP_fromBytes(b)
+	return p
 }
 
 func ECP2FromHex(t *testing.T, hexStr string) *Curve.ECP2 {
-	b, err := hex.DecodeString(hexStr)
+	p, err := utils.ECP2FromCoconutString(hexStr)
 	assert.Nil(t, err)
-	return Curve.ECP2_fromBytes(b)
+	return p
 }
 
 // modified version with additional arguments to remove randomness
@@ -109,7 +110,7 @@ func constructVerifierProofWitn(witnesses *witnessesV, params *Params, vk *Verif
 		i++
 	}
 
-	c := constructChallenge(ca)
+	c := constructChallenge(params, ca)
 
 	// responses
 	rm := make([]*Curve.BIG, len(privM))
@@ -200,7 +201,7 @@ func constructSignerProofWitn(witnesses *witnessesS, params *Params, gamma *Curv
 		i++
 	}
 
-	c := constructChallenge(ca)
+	c := constructChallenge(params, ca)
 
 	// responses
 	rr := wr.Minus(Curve.Modmul(c, r, p))
@@ -507,7 +508,7 @@ func TestCoconut(t *testing.T) {
 	}
 	assert.Zero(t, Curve.Comp(rrSExp, bsm.proof.rr))
 
-	blindedSig, err := BlindSign(params, sk, bsm, gamma, pubM)
+	blindedSig, err := BlindSign(params, sk, bsm, elgamal.NewPublicKey(gamma), pubM)
 	assert.Nil(t, err)
 
 	// expected:
@@ -519,7 +520,7 @@ func TestCoconut(t *testing.T) {
 	assert.True(t, blindSig2C1Exp.Equals(blindedSig.sig2Tilda.C1()))
 	assert.True(t, blindSig2C2Exp.Equals(blindedSig.sig2Tilda.C2()))
 
-	sig := Unblind(params, blindedSig, d)
+	sig := Unblind(params, blindedSig, elgamal.NewPrivateKey(d))
 
 	sig2Exp := ECPFromHex(t, sig2Hex)
 	assert.True(t, blindSig1Exp.Equals(sig.sig1))
@@ -550,4 +551,41 @@ func TestCoconut(t *testing.T) {
 
 	// finally for sanity checks ensure the credentials verify
 	assert.True(t, BlindVerify(params, vk, sig, bsm2, pubM))
+
+	// ensure the JSON representation uses exactly the same strings as the Python implementation
+	b, err := json.Marshal(bsm)
+	assert.Nil(t, err)
+	bsmJSON := struct {
+		Cm    string            `json:"cm"`
+		Enc   []json.RawMessage `json:"enc"`
+		Proof signerProofJSON   `json:"proof"`
+	}{}
+	assert.Nil(t, json.Unmarshal(b, &bsmJSON))
+	assert.Equal(t, cmHex, bsmJSON.Cm)
+	assert.JSONEq(t, fmt.Sprintf(`{"c1":"%s","c2":"%s"}`, c1Priv1Hex, c2Priv1Hex), string(bsmJSON.Enc[0]))
+	assert.JSONEq(t, fmt.Sprintf(`{"c1":"%s","c2":"%s"}`, c1Priv2Hex, c2Priv2Hex), string(bsmJSON.Enc[1]))
+	assert.Equal(t, chSHex, bsmJSON.Proof.C)
+	assert.Equal(t, rrSHex, bsmJSON.Proof.Rr)
+	assert.Equal(t, []string{rk1SHex, rk2SHex}, bsmJSON.Proof.Rk)
+	assert.Equal(t, []string{rm1SHex, rm2SHex}, bsmJSON.Proof.Rm[:2])
+
+	bsm2JSON := fmt.Sprintf(`{"kappa":"%s","nu":"%s","proof":{"c":"%s","rm":["%s","%s"],"rt":"%s"}}`,
+		kappaHex, nuHex, chVHex, rm1VHex, rm2VHex, rtHex)
+	b, err = json.Marshal(bsm2)
+	assert.Nil(t, err)
+	assert.JSONEq(t, bsm2JSON, string(b))
+
+	bsm2Rec := &BlindShowMats{}
+	assert.Nil(t, json.Unmarshal([]byte(bsm2JSON), bsm2Rec))
+	assert.True(t, BlindVerify(params, vk, sig, bsm2Rec, pubM))
+
+	sigJSON := fmt.Sprintf(`{"sig1":"%s","sig2":"%s"}`, hTildaHex, sig2Hex)
+	sigRec := &Signature{}
+	assert.Nil(t, json.Unmarshal([]byte(sigJSON), sigRec))
+	assert.True(t, Verify(params, vk, append(privM, pubM...), sigRec))
+
+	skJSON := fmt.Sprintf(`{"x":"%s","y":["%s","%s","%s","%s"]}`, xHex, y0Hex, y1Hex, y2Hex, y3Hex)
+	b, err = json.Marshal(sk)
+	assert.Nil(t, err)
+	assert.JSONEq(t, skJSON, string(b))
 }
@@ -18,11 +18,13 @@ package coconut
 import (
 	"fmt"
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/jstuczyn/CoconutGo/bpgroup"
 	"github.com/jstuczyn/CoconutGo/coconut/utils"
 	"github.com/jstuczyn/CoconutGo/elgamal"
 	"github.com/jstuczyn/amcl/version3/go/amcl"
@@ -42,6 +44,64 @@ func TestSchemeSetup(t *testing.T) {
 	params, err := Setup(10)
 	assert.Nil(t, err)
 	assert.Equal(t, 10, len(params.hs))
+
+	_, err = SetupWithGroup(10, nil)
+	assert.Equal(t, ErrSetupParams, err, "Should not allow generating params without a group")
+}
+
+func TestSchemeSetupDeterministic(t *testing.T) {
+	// replays the same issuance twice and ensures all the randomness was derived from the seed
+	run := func() ([]byte, []byte) {
+		G, err := bpgroup.NewDeterministic([]byte("deterministic test seed"))
+		assert.Nil(t, err)
+		params, err := SetupWithGroup(2, G)
+		assert.Nil(t, err)
+
+		pubM := []*Curve.BIG{Curve.NewBIGint(42)}
+		privM := []*Curve.BIG{Curve.NewBIGint(43)}
+		sk, vk, err := Keygen(params)
+		assert.Nil(t, err)
+		d, gamma := elgamal.Keygen(params.G)
+		bsm, err := PrepareBlindSign(params, gamma, pubM, privM)
+		assert.Nil(t, err)
+		blindedSig, err := BlindSign(params, sk, bsm, gamma, pubM)
+		assert.Nil(t, err)
+		sig := Unblind(params, blindedSig, d)
+		assert.True(t, Verify(params, vk, append(privM, pubM...), sig))
+
+		bsmB, err := bsm.MarshalBinary()
+		assert.Nil(t, err)
+		sigB, err := sig.MarshalBinary()
+		assert.Nil(t, err)
+		return bsmB, sigB
+	}
+
+	bsm1, sig1 := run()
+	bsm2, sig2 := run()
+	assert.Equal(t, bsm1, bsm2)
+	assert.Equal(t, sig1, sig2)
+
+	// decoded parameters use the provided group
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	b, err := params.MarshalBinary()
+	assert.Nil(t, err)
+	keys := func() []byte {
+		G, err := bpgroup.NewDeterministic([]byte("deterministic test seed"))
+		assert.Nil(t, err)
+		paramsRec, err := UnmarshalParamsWithGroup(b, G)
+		assert.Nil(t, err)
+		assert.Equal(t, G, paramsRec.G)
+		sk, _, err := Keygen(paramsRec)
+		assert.Nil(t, err)
+		skB, err := sk.MarshalBinary()
+		assert.Nil(t, err)
+		return skB
+	}
+	assert.Equal(t, keys(), keys())
+
+	_, err = UnmarshalParamsWithGroup(b, nil)
+	assert.Equal(t, ErrSetupParams, err)
 }
 
 func keygenTest(t *testing.T, params *Params, sk *SecretKey, vk *VerificationKey) {
@@ -51,7 +111,7 @@ func keygenTest(t *testing.T, params *Params, sk *SecretKey, vk *VerificationKey
 	assert.True(t, Curve.G2mul(vk.g2, sk.x).Equals(vk.alpha))
 	assert.Equal(t, len(sk.y), len(vk.beta))
 	for i := range vk.beta {
-		assert.Equal(t, vk.beta[i], Curve.G2mul(vk.g2, sk.y[i]))
+		assert.True(t, vk.beta[i].Equals(Curve.G2mul(vk.g2, sk.y[i])))
 	}
 }
 
@@ -73,13 +133,22 @@ func TestSchemeTTPKeygen(t *testing.T) {
 	params, err := Setup(10)
 	assert.Nil(t, err)
 
-	_, _, err = TTPKeygen(params, 6, 5)
+	_, _, _, err = TTPKeygen(params, 6, AuthorityIDs(5))
 	assert.Equal(t, ErrTTPKeygenParams, err)
 
-	_, _, err = TTPKeygen(params, 0, 6)
+	_, _, _, err = TTPKeygen(params, 0, AuthorityIDs(6))
 	assert.Equal(t, ErrTTPKeygenParams, err)
 
-	_, _, err = TTPKeygen(&Params{G: params.G, p: params.p, g1: params.g1, g2: params.g2, hs: nil}, 6, 6)
+	_, _, _, err = TTPKeygen(&Params{G: params.G, p: params.p, g1: params.g1, g2: params.g2, hs: nil}, 6, AuthorityIDs(6))
+	assert.Equal(t, ErrTTPKeygenParams, err)
+
+	_, _, _, err = TTPKeygen(params, 2, []AuthorityID{1, 2, 1})
+	assert.Equal(t, ErrTTPKeygenParams, err)
+
+	_, _, _, err = TTPKeygen(params, 2, []AuthorityID{0, 1, 2})
+	assert.Equal(t, ErrTTPKeygenParams, err)
+
+	_, _, _, err = TTPKeygen(params, 2, []AuthorityID{-1, 1, 2})
 	assert.Equal(t, ErrTTPKeygenParams, err)
 
 	tests := []struct {
@@ -97,13 +166,15 @@ func TestSchemeTTPKeygen(t *testing.T) {
 
 		p := params.p
 
-		sks, vks, err := TTPKeygen(params, test.t, test.n)
+		sks, vks, _, err := TTPKeygen(params, test.t, AuthorityIDs(test.n))
 		assert.Nil(t, err)
 		assert.Equal(t, len(sks), len(vks))
 
 		// first check if they work as normal keys
 		for i := range sks {
 			keygenTest(t, params, sks[i], vks[i])
+			assert.Equal(t, AuthorityID(i+1), sks[i].ID())
+			assert.Equal(t, AuthorityID(i+1), vks[i].ID())
 		}
 
 		// choose random 2 subsets of t keys and ensure that when multiplied by langrage basis they converge to same value
@@ -380,16 +451,16 @@ func TestSchemeRandomize(t *testing.T) {
 func TestSchemeKeyAggregation(t *testing.T) {
 	tests := []struct {
 		attrs []string
-		pp    *PolynomialPoints
+		id    AuthorityID
 		msg   string
 	}{
-		{attrs: []string{"Hello World!"}, pp: nil,
+		{attrs: []string{"Hello World!"}, id: 0,
 			msg: "Should verify a signature when single set of verification keys is aggregated (single attribute)"},
-		{attrs: []string{"Foo", "Bar", "Baz"}, pp: nil,
+		{attrs: []string{"Foo", "Bar", "Baz"}, id: 0,
 			msg: "Should verify a signature when single set of verification keys is aggregated (three attributes)"},
-		{attrs: []string{"Hello World!"}, pp: &PolynomialPoints{[]*Curve.BIG{Curve.NewBIGint(1)}},
+		{attrs: []string{"Hello World!"}, id: 1,
 			msg: "Should verify a signature when single set of verification keys is aggregated (single attribute)"},
-		{attrs: []string{"Foo", "Bar", "Baz"}, pp: &PolynomialPoints{[]*Curve.BIG{Curve.NewBIGint(1)}},
+		{attrs: []string{"Foo", "Bar", "Baz"}, id: 1,
 			msg: "Should verify a signature when single set of verification keys is aggregated (three attributes)"},
 	}
 
@@ -409,7 +480,9 @@ func TestSchemeKeyAggregation(t *testing.T) {
 		sig, err := Sign(params, sk, attrsBig)
 		assert.Nil(t, err)
 
-		avk := AggregateVerificationKeys(params, []*VerificationKey{vk}, test.pp)
+		vk.id = test.id
+		avk, err := AggregateVerificationKeys(params, []*VerificationKey{vk})
+		assert.Nil(t, err)
 		assert.True(t, Verify(params, avk, attrsBig, sig), test.msg)
 	}
 }
@@ -421,47 +494,47 @@ func TestSchemeAggregateVerification(t *testing.T) {
 		authorities    int
 		maliciousAuth  int
 		maliciousAttrs []string
-		pp             *PolynomialPoints
+		threshold      bool
 		t              int
 		msg            string
 	}{
-		{attrs: []string{"Hello World!"}, authorities: 1, maliciousAuth: 0, maliciousAttrs: []string{}, pp: nil, t: 0,
+		{attrs: []string{"Hello World!"}, authorities: 1, maliciousAuth: 0, maliciousAttrs: []string{}, threshold: false, t: 0,
 			msg: "Should verify aggregated signature when only single signature was used for aggregation"},
-		{attrs: []string{"Hello World!"}, authorities: 3, maliciousAuth: 0, maliciousAttrs: []string{}, pp: nil, t: 0,
+		{attrs: []string{"Hello World!"}, authorities: 3, maliciousAuth: 0, maliciousAttrs: []string{}, threshold: false, t: 0,
 			msg: "Should verify aggregated signature when three signatures were used for aggregation"},
-		{attrs: []string{"Foo", "Bar", "Baz"}, authorities: 1, maliciousAuth: 0, maliciousAttrs: []string{}, pp: nil, t: 0,
+		{attrs: []string{"Foo", "Bar", "Baz"}, authorities: 1, maliciousAuth: 0, maliciousAttrs: []string{}, threshold: false, t: 0,
 			msg: "Should verify aggregated signature when only single signature was used for aggregation"},
-		{attrs: []string{"Foo", "Bar", "Baz"}, authorities: 3, maliciousAuth: 0, maliciousAttrs: []string{}, pp: nil, t: 0,
+		{attrs: []string{"Foo", "Bar", "Baz"}, authorities: 3, maliciousAuth: 0, maliciousAttrs: []string{}, threshold: false, t: 0,
 			msg: "Should verify aggregated signature when three signatures were used for aggregation"},
 		{attrs: []string{"Hello World!"}, authorities: 1, maliciousAuth: 2,
 			maliciousAttrs: []string{"Malicious Hello World!"},
-			pp:             nil,
+			threshold:      false,
 			t:              0,
 			msg:            "Should fail to verify aggregated where malicious signatures were introduced"},
 		{attrs: []string{"Foo", "Bar", "Baz"}, authorities: 3, maliciousAuth: 2,
 			maliciousAttrs: []string{"Foo2", "Bar2", "Baz2"},
-			pp:             nil,
+			threshold:      false,
 			t:              0,
 			msg:            "Should fail to verify aggregated where malicious signatures were introduced"},
 
 		{attrs: []string{"Hello World!"}, authorities: 1, maliciousAuth: 0,
 			maliciousAttrs: []string{},
-			pp:             &PolynomialPoints{[]*Curve.BIG{Curve.NewBIGint(1)}},
+			threshold:      true,
 			t:              1,
 			msg:            "Should verify aggregated signature when only single signature was used for aggregation +threshold"},
 		{attrs: []string{"Hello World!"}, authorities: 3, maliciousAuth: 0,
 			maliciousAttrs: []string{},
-			pp:             &PolynomialPoints{[]*Curve.BIG{Curve.NewBIGint(1), Curve.NewBIGint(2), Curve.NewBIGint(3)}},
+			threshold:      true,
 			t:              2,
 			msg:            "Should verify aggregated signature when three signatures were used for aggregation +threshold"},
 		{attrs: []string{"Foo", "Bar", "Baz"}, authorities: 1, maliciousAuth: 0,
 			maliciousAttrs: []string{},
-			pp:             &PolynomialPoints{[]*Curve.BIG{Curve.NewBIGint(1)}},
+			threshold:      true,
 			t:              1,
 			msg:            "Should verify aggregated signature when only single signature was used for aggregation +threshold"},
 		{attrs: []string{"Foo", "Bar", "Baz"}, authorities: 3, maliciousAuth: 0,
 			maliciousAttrs: []string{},
-			pp:             &PolynomialPoints{[]*Curve.BIG{Curve.NewBIGint(1), Curve.NewBIGint(2), Curve.NewBIGint(3)}},
+			threshold:      true,
 			t:              2,
 			msg:            "Should verify aggregated signature when three signatures were used for aggregation +threshold"},
 	}
@@ -473,7 +546,7 @@ func TestSchemeAggregateVerification(t *testing.T) {
 		var sks []*SecretKey
 		var vks []*VerificationKey
 
-		if test.pp == nil {
+		if !test.threshold {
 			sks = make([]*SecretKey, test.authorities)
 			vks = make([]*VerificationKey, test.authorities)
 			for i := 0; i < test.authorities; i++ {
@@ -483,7 +556,7 @@ func TestSchemeAggregateVerification(t *testing.T) {
 				vks[i] = vk
 			}
 		} else {
-			sks, vks, err = TTPKeygen(params, test.t, test.authorities)
+			sks, vks, _, err = TTPKeygen(params, test.t, AuthorityIDs(test.authorities))
 			assert.Nil(t, err)
 		}
 
@@ -493,14 +566,17 @@ func TestSchemeAggregateVerification(t *testing.T) {
 			assert.Nil(t, err)
 		}
 
-		signatures := make([]*Signature, test.authorities)
+		signatures := make([]*SignatureShare, test.authorities)
 		for i := 0; i < test.authorities; i++ {
-			signatures[i], err = Sign(params, sks[i], attrsBig)
+			sig, err := Sign(params, sks[i], attrsBig)
 			assert.Nil(t, err)
+			signatures[i] = NewSignatureShare(sks[i].ID(), sig)
 		}
 
-		aSig := AggregateSignatures(params, signatures, test.pp)
-		avk := AggregateVerificationKeys(params, vks, test.pp)
+		aSig, err := AggregateSignatures(params, signatures)
+		assert.Nil(t, err)
+		avk, err := AggregateVerificationKeys(params, vks)
+		assert.Nil(t, err)
 
 		assert.True(t, Verify(params, avk, attrsBig, aSig), test.msg)
 
@@ -520,17 +596,22 @@ func TestSchemeAggregateVerification(t *testing.T) {
 				assert.Nil(t, err)
 			}
 
-			mSignatures := make([]*Signature, test.maliciousAuth)
+			mSignatures := make([]*SignatureShare, test.maliciousAuth)
 			for i := 0; i < test.maliciousAuth; i++ {
-				mSignatures[i], err = Sign(params, msks[i], mAttrsBig)
+				sig, err := Sign(params, msks[i], mAttrsBig)
 				assert.Nil(t, err)
+				mSignatures[i] = NewSignatureShare(msks[i].ID(), sig)
 			}
 
-			maSig := AggregateSignatures(params, mSignatures, test.pp)
-			mavk := AggregateVerificationKeys(params, mvks, test.pp)
+			maSig, err := AggregateSignatures(params, mSignatures)
+			assert.Nil(t, err)
+			mavk, err := AggregateVerificationKeys(params, mvks)
+			assert.Nil(t, err)
 			// todo: think of some way to test it if malicious authorities are present?
-			maSig2 := AggregateSignatures(params, append(signatures, mSignatures...), test.pp)
-			mavk2 := AggregateVerificationKeys(params, append(vks, mvks...), test.pp)
+			maSig2, err := AggregateSignatures(params, append(signatures, mSignatures...))
+			assert.Nil(t, err)
+			mavk2, err := AggregateVerificationKeys(params, append(vks, mvks...))
+			assert.Nil(t, err)
 
 			assert.False(t, Verify(params, mavk, attrsBig, maSig), test.msg)
 			assert.False(t, Verify(params, mavk2, attrsBig, maSig2), test.msg)
@@ -600,10 +681,10 @@ func TestSchemeBlindVerify(t *testing.T) {
 		assert.Equal(t, ErrPrepareBlindSignParams, err, test.msg)
 
 		incorrectGamma := Curve.NewECP()
-		incorrectGamma.Copy(gamma)
+		incorrectGamma.Copy(gamma.Gamma())
 		incorrectGamma.Add(Curve.NewECP()) // adds point in infinity
 		// just to ensure the error is returned; proofs of knowledge are properly tested in their own test file
-		_, err = BlindSign(params, sk, blindSignMats, incorrectGamma, append(pubBig, Curve.NewBIG()))
+		_, err = BlindSign(params, sk, blindSignMats, elgamal.NewPublicKey(incorrectGamma), append(pubBig, Curve.NewBIG()))
 		assert.Equal(t, ErrPrepareBlindSignPrivate, err, test.msg)
 
 		blindedSignature, err := BlindSign(params, sk, blindSignMats, gamma, pubBig)
@@ -685,7 +766,7 @@ func TestThresholdAuthorities(t *testing.T) {
 		blindSignMats, err := PrepareBlindSign(params, gamma, pubBig, privBig)
 		assert.Nil(t, err)
 
-		sks, vks, err := TTPKeygen(params, test.t, test.n)
+		sks, vks, _, err := TTPKeygen(params, test.t, AuthorityIDs(test.n))
 		assert.Nil(t, err)
 
 		// repeat the test repeat number of times to ensure it works with different subsets of keys/sigs
@@ -696,34 +777,26 @@ func TestThresholdAuthorities(t *testing.T) {
 			for i := range vks2 {
 				vks2[i] = vks[indices[i]-1]
 			}
-			// right now each point of vk has value of index + 1
-			indices12 := make([]*Curve.BIG, test.t)
-			for i, val := range indices {
-				indices12[i] = Curve.NewBIGint(val)
-			}
 
-			avk := AggregateVerificationKeys(params, vks2, &PolynomialPoints{indices12})
+			avk, err := AggregateVerificationKeys(params, vks2)
+			assert.Nil(t, err)
 
-			signatures := make([]*Signature, test.n)
+			signatures := make([]*SignatureShare, test.n)
 			for i := 0; i < test.n; i++ {
 				blindedSignature, err := BlindSign(params, sks[i], blindSignMats, gamma, pubBig)
 				assert.Nil(t, err)
-				signatures[i] = Unblind(params, blindedSignature, d)
+				signatures[i] = NewSignatureShare(sks[i].ID(), Unblind(params, blindedSignature, d))
 			}
 
 			// and choose some other subset of t signatures
 			indices2 := randomInts(test.t, test.n)
-			sigs2 := make([]*Signature, test.t)
+			sigs2 := make([]*SignatureShare, test.t)
 			for i := range vks2 {
 				sigs2[i] = signatures[indices2[i]-1]
 			}
-			// right now each point of sig has value of index + 1
-			indices22 := make([]*Curve.BIG, test.t)
-			for i, val := range indices2 {
-				indices22[i] = Curve.NewBIGint(val)
-			}
 
-			aSig := AggregateSignatures(params, sigs2, &PolynomialPoints{indices22})
+			aSig, err := AggregateSignatures(params, sigs2)
+			assert.Nil(t, err)
 			rSig := Randomize(params, aSig)
 
 			blindShowMats, err := ShowBlindSignature(params, avk, rSig, privBig)
@@ -734,6 +807,39 @@ func TestThresholdAuthorities(t *testing.T) {
 	}
 }
 
+// TestConcurrentIssuance runs issuance and verification in parallel using shared Params and keys.
+// It is mostly meaningful when run with the race detector, i.e. go test -race.
+func TestConcurrentIssuance(t *testing.T) {
+	goroutines := 8
+	params, err := Setup(4)
+	assert.Nil(t, err)
+	sk, vk, err := Keygen(params)
+	assert.Nil(t, err)
+
+	pubM := []*Curve.BIG{Curve.NewBIGint(1), Curve.NewBIGint(2)}
+	privM := []*Curve.BIG{Curve.NewBIGint(3), Curve.NewBIGint(4)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d, gamma := elgamal.Keygen(params.G)
+			blindSignMats, err := PrepareBlindSign(params, gamma, pubM, privM)
+			assert.Nil(t, err)
+			blindedSignature, err := BlindSign(params, sk, blindSignMats, gamma, pubM)
+			assert.Nil(t, err)
+			sig := Randomize(params, Unblind(params, blindedSignature, d))
+			assert.True(t, Verify(params, vk, append(privM, pubM...), sig))
+
+			blindShowMats, err := ShowBlindSignature(params, vk, sig, privM)
+			assert.Nil(t, err)
+			assert.True(t, BlindVerify(params, vk, sig, blindShowMats, pubM))
+		}()
+	}
+	wg.Wait()
+}
+
 func BenchmarkSetup(b *testing.B) {
 	qs := []int{1, 3, 5, 10, 20}
 	for _, q := range qs {
@@ -748,6 +854,68 @@ func BenchmarkSetup(b *testing.B) {
 	}
 }
 
+var fixedBaseG1Res *Curve.ECP
+var fixedBaseG2Res *Curve.ECP2
+
+// BenchmarkFixedBaseMul compares multiplications of the fixed bases (g1, g2 and hs) using
+// precomputation tables, as done by Keygen, PrepareBlindSign and the proofs, against plain G1mul and G2mul.
+func BenchmarkFixedBaseMul(b *testing.B) {
+	params, _ := Setup(1)
+	p, rng := params.p, params.G.Rng()
+	// ensure the tables are built before the measurement
+	params.G.Gen1Mul(p)
+	params.G.Gen2Mul(p)
+
+	b.Run("g1/precomputed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			x := Curve.Randomnum(p, rng)
+			b.StartTimer()
+			fixedBaseG1Res = params.G.Gen1Mul(x)
+		}
+	})
+	b.Run("g1/G1mul", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			x := Curve.Randomnum(p, rng)
+			b.StartTimer()
+			fixedBaseG1Res = Curve.G1mul(params.g1, x)
+		}
+	})
+	b.Run("g2/precomputed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			x := Curve.Randomnum(p, rng)
+			b.StartTimer()
+			fixedBaseG2Res = params.G.Gen2Mul(x)
+		}
+	})
+	b.Run("g2/G2mul", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			x := Curve.Randomnum(p, rng)
+			b.StartTimer()
+			fixedBaseG2Res = Curve.G2mul(params.g2, x)
+		}
+	})
+	b.Run("hs/precomputed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			x := Curve.Randomnum(p, rng)
+			b.StartTimer()
+			fixedBaseG1Res = params.hsMul(0, x)
+		}
+	})
+	b.Run("hs/G1mul", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			x := Curve.Randomnum(p, rng)
+			b.StartTimer()
+			fixedBaseG1Res = Curve.G1mul(params.hs[0], x)
+		}
+	})
+}
+
 func BenchmarkKeygen(b *testing.B) {
 	qs := []int{1, 3, 5, 10}
 	for _, q := range qs {
@@ -780,7 +948,7 @@ func BenchmarkTTPKeygen(b *testing.B) {
 						b.StopTimer()
 						params, _ := Setup(q)
 						b.StartTimer()
-						_, _, err := TTPKeygen(params, t, n)
+						_, _, _, err := TTPKeygen(params, t, AuthorityIDs(n))
 						if err != nil {
 							panic(err)
 						}
@@ -1026,7 +1194,7 @@ func Example() {
 	blindSignMats, _ := PrepareBlindSign(params, gamma, pubMBig, privMBig)
 
 	// Generate keys for all authorities
-	sks, vks, _ := TTPKeygen(params, t, n)
+	sks, vks, _, _ := TTPKeygen(params, t, AuthorityIDs(n))
 
 	// Blindly Sign attributes by each authoritiy
 	blindSignatures := make([]*BlindedSignature, n)
@@ -1034,23 +1202,19 @@ func Example() {
 		blindSignatures[i], _ = BlindSign(params, sks[i], blindSignMats, gamma, pubMBig)
 	}
 
-	// Unblind all signatures
-	signatures := make([]*Signature, n)
+	// Unblind all signatures and tag them with the ids of their authorities
+	signatures := make([]*SignatureShare, n)
 	for i := range blindSignatures {
-		signatures[i] = Unblind(params, blindSignatures[i], d)
+		signatures[i] = NewSignatureShare(vks[i].ID(), Unblind(params, blindSignatures[i], d))
 	}
 
-	// Simple slice of indices
-	pp1 := &PolynomialPoints{[]*Curve.BIG{Curve.NewBIGint(1), Curve.NewBIGint(2)}}
-	pp2 := &PolynomialPoints{[]*Curve.BIG{Curve.NewBIGint(2), Curve.NewBIGint(3)}}
-
 	// Aggregate any subset of t verification keys
-	avk1 := AggregateVerificationKeys(params, vks[1:], pp2)
-	avk2 := AggregateVerificationKeys(params, vks[:len(vks)-1], pp1)
+	avk1, _ := AggregateVerificationKeys(params, vks[1:])
+	avk2, _ := AggregateVerificationKeys(params, vks[:len(vks)-1])
 
 	// Aggregate any subset of t credentials
-	aSig1 := AggregateSignatures(params, signatures[1:], pp2)
-	aSig2 := AggregateSignatures(params, signatures[:len(signatures)-1], pp1)
+	aSig1, _ := AggregateSignatures(params, signatures[1:])
+	aSig2, _ := AggregateSignatures(params, signatures[:len(signatures)-1])
 
 	// Randomize the credentials
 	rSig1 := Randomize(params, aSig1)
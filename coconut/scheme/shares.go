@@ -0,0 +1,186 @@
+// shares.go - verification and aggregation of credentials issued by individual authorities
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package coconut provides the functionalities required by the Coconut Scheme.
+package coconut
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+var (
+	// ErrAggregateNoValidShares indicates that none of the signature shares was valid.
+	ErrAggregateNoValidShares = errors.New("No valid signature shares to aggregate")
+)
+
+// ThresholdError indicates that fewer than threshold valid signature shares were available for aggregation.
+// It lists the authorities that produced invalid shares and those that did not send any.
+type ThresholdError struct {
+	Threshold int
+	Valid     int
+	Invalid   []AuthorityID
+	Missing   []AuthorityID
+}
+
+// Error returns a description of the ThresholdError.
+func (e *ThresholdError) Error() string {
+	return fmt.Sprintf("Only %v out of required %v valid signature shares; invalid: %v, missing: %v",
+		e.Valid, e.Threshold, e.Invalid, e.Missing)
+}
+
+// VerifySignatureShare verifies the credential issued by a single authority, for example one obtained with Unblind,
+// against the VerificationKey of that authority. As in Verify, m are all the attributes,
+// i.e. the private ones followed by the public ones. The share must be tagged with the id of the key.
+func VerifySignatureShare(params *Params, vk *VerificationKey, m []*Curve.BIG, share *SignatureShare) bool {
+	if share == nil || vk == nil || share.id != vk.id {
+		return false
+	}
+	return Verify(params, vk, m, share.sig)
+}
+
+// verifySignatureShares verifies each of the shares against the VerificationKey of its authority
+// and returns the valid ones alongside the ids of the authorities whose shares were invalid,
+// had no corresponding key or were sent more than once. If an authority sent more than one share,
+// none of them is used and its id is reported only once.
+// Valid credentials are only aggregated if they have the same sig1, hence if they differ,
+// only the largest group of valid shares with the same sig1 is kept and the remaining ones are considered invalid.
+func verifySignatureShares(params *Params, vks []*VerificationKey, m []*Curve.BIG, shares []*SignatureShare) ([]*SignatureShare, []AuthorityID, error) {
+	ids := make([]AuthorityID, len(vks))
+	keys := make(map[AuthorityID]*VerificationKey, len(vks))
+	for i, vk := range vks {
+		if vk == nil {
+			return nil, nil, utils.ErrValidateNil
+		}
+		ids[i] = vk.id
+		keys[vk.id] = vk
+	}
+	if positive, err := validateAuthorityIDs(ids); err != nil || !positive {
+		return nil, nil, ErrAggregateParams
+	}
+
+	counts := make(map[AuthorityID]int, len(shares))
+	for _, share := range shares {
+		if share == nil {
+			return nil, nil, utils.ErrValidateNil
+		}
+		counts[share.id]++
+	}
+
+	// none of the shares of an authority that sent more than one is used, as it is unknown which one to trust
+	var valid []*SignatureShare
+	var invalid []AuthorityID
+	reported := make(map[AuthorityID]bool)
+	for _, share := range shares {
+		if counts[share.id] > 1 {
+			if !reported[share.id] {
+				invalid = append(invalid, share.id)
+				reported[share.id] = true
+			}
+			continue
+		}
+		vk, ok := keys[share.id]
+		if !ok || !VerifySignatureShare(params, vk, m, share) {
+			invalid = append(invalid, share.id)
+		} else {
+			valid = append(valid, share)
+		}
+	}
+
+	// group the valid shares by sig1, which is compared on copies as the points are owned by the caller
+	sig1s := make([]*Curve.ECP, len(valid))
+	for i, share := range valid {
+		sig1s[i] = Curve.NewECP()
+		sig1s[i].Copy(share.sig.sig1)
+	}
+	var best []int
+	for i := range valid {
+		var group []int
+		for j := range valid {
+			if sig1s[j].Equals(sig1s[i]) {
+				group = append(group, j)
+			}
+		}
+		if len(group) > len(best) {
+			best = group
+		}
+	}
+	var aggregated []*SignatureShare
+	for i, share := range valid {
+		if sig1s[i].Equals(sig1s[best[0]]) {
+			aggregated = append(aggregated, share)
+		} else {
+			invalid = append(invalid, share.id)
+		}
+	}
+	return aggregated, invalid, nil
+}
+
+// AggregateVerifiedSignatures verifies each of the shares against the VerificationKey of its authority
+// using VerifySignatureShare and aggregates only the valid ones. m are all the attributes,
+// i.e. the private ones followed by the public ones.
+// It returns the ids of the authorities whose shares were invalid, had no corresponding key
+// or were sent more than once, in which case none of their shares is aggregated. The caller is responsible for ensuring enough shares remain,
+// otherwise the aggregated credential will not verify.
+func AggregateVerifiedSignatures(params *Params, vks []*VerificationKey, m []*Curve.BIG, shares []*SignatureShare) (*Signature, []AuthorityID, error) {
+	if len(shares) == 0 || len(vks) == 0 {
+		return nil, nil, ErrAggregateParams
+	}
+	valid, invalid, err := verifySignatureShares(params, vks, m, shares)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(valid) == 0 {
+		return nil, invalid, ErrAggregateNoValidShares
+	}
+	sig, err := AggregateSignatures(params, valid)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sig, invalid, nil
+}
+
+// AggregateThresholdSignatures verifies the shares received from any number of authorities
+// against their VerificationKeys and aggregates the credential using the first t valid shares,
+// where t is the threshold of the keys. m are all the attributes, i.e. the private ones followed by the public ones.
+// If fewer than t shares are valid, it returns a *ThresholdError listing the authorities
+// whose shares were invalid and those with a VerificationKey that did not send any share.
+func AggregateThresholdSignatures(params *Params, t int, vks []*VerificationKey, m []*Curve.BIG, shares []*SignatureShare) (*Signature, error) {
+	if t <= 0 || len(vks) < t {
+		return nil, ErrAggregateParams
+	}
+	valid, invalid, err := verifySignatureShares(params, vks, m, shares)
+	if err != nil {
+		return nil, err
+	}
+	if len(valid) < t {
+		received := make(map[AuthorityID]bool, len(shares))
+		for _, share := range shares {
+			received[share.id] = true
+		}
+		var missing []AuthorityID
+		for _, vk := range vks {
+			if !received[vk.id] {
+				missing = append(missing, vk.id)
+			}
+		}
+		return nil, &ThresholdError{Threshold: t, Valid: len(valid), Invalid: invalid, Missing: missing}
+	}
+	return AggregateSignatures(params, valid[:t])
+}
@@ -0,0 +1,244 @@
+// shares_test.go - tests for verification and aggregation of credentials issued by individual authorities
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package coconut
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	"github.com/jstuczyn/CoconutGo/elgamal"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// blindSignShares issues a blind credential on privM and pubM with all the keys and unblinds each of the shares.
+func blindSignShares(t *testing.T, params *Params, sks []*SecretKey, privM []*Curve.BIG, pubM []*Curve.BIG) []*SignatureShare {
+	d, gamma := elgamal.Keygen(params.G)
+	bsm, err := PrepareBlindSign(params, gamma, pubM, privM)
+	assert.Nil(t, err)
+
+	shares := make([]*SignatureShare, len(sks))
+	for i, sk := range sks {
+		blindedSig, err := BlindSign(params, sk, bsm, gamma, pubM)
+		assert.Nil(t, err)
+		shares[i] = NewSignatureShare(sk.ID(), Unblind(params, blindedSig, d))
+	}
+	return shares
+}
+
+func TestVerifySignatureShare(t *testing.T) {
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	privM := []*Curve.BIG{Curve.NewBIGint(42)}
+	pubM := []*Curve.BIG{Curve.NewBIGint(43)}
+	m := append(privM, pubM...)
+
+	sks, vks, _, err := TTPKeygen(params, 2, AuthorityIDs(3))
+	assert.Nil(t, err)
+	shares := blindSignShares(t, params, sks, privM, pubM)
+
+	for i, share := range shares {
+		assert.True(t, VerifySignatureShare(params, vks[i], m, share))
+		assert.False(t, VerifySignatureShare(params, vks[(i+1)%len(vks)], m, share), "Share verified with the key of another authority")
+		assert.False(t, VerifySignatureShare(params, vks[i], []*Curve.BIG{pubM[0], privM[0]}, share))
+		assert.False(t, VerifySignatureShare(params, vks[i], m, NewSignatureShare(share.ID()+1, share.Signature())), "Share tagged with a different id")
+	}
+	assert.False(t, VerifySignatureShare(params, vks[0], m, nil))
+	assert.False(t, VerifySignatureShare(params, nil, m, shares[0]))
+}
+
+func TestAggregateVerifiedSignatures(t *testing.T) {
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	privM := []*Curve.BIG{Curve.NewBIGint(42)}
+	pubM := []*Curve.BIG{Curve.NewBIGint(43)}
+	m := append(privM, pubM...)
+
+	sks, vks, cms, err := TTPKeygen(params, 3, AuthorityIDs(5))
+	assert.Nil(t, err)
+	avk, err := DeriveVerificationKey(params, cms, 0)
+	assert.Nil(t, err)
+
+	// shares issued with a key of an authority unrelated to the threshold keys
+	rogue, _, err := Keygen(params)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		tamper  func(shares []*SignatureShare) []*SignatureShare
+		invalid []AuthorityID
+		valid   bool
+		msg     string
+	}{
+		{tamper: func(shares []*SignatureShare) []*SignatureShare { return shares },
+			invalid: nil, valid: true, msg: "All shares are valid"},
+		{tamper: func(shares []*SignatureShare) []*SignatureShare {
+			sig, err := Sign(params, rogue, m)
+			assert.Nil(t, err)
+			shares[1] = NewSignatureShare(2, &Signature{sig1: shares[1].sig.sig1, sig2: sig.sig2})
+			return shares
+		}, invalid: []AuthorityID{2}, valid: true, msg: "Share signed with a wrong key"},
+		{tamper: func(shares []*SignatureShare) []*SignatureShare {
+			sig2 := Curve.NewECP()
+			sig2.Copy(shares[0].sig.sig2)
+			sig2.Add(params.G.Gen1())
+			shares[0] = NewSignatureShare(1, &Signature{sig1: shares[0].sig.sig1, sig2: sig2})
+			shares[4] = NewSignatureShare(5, &Signature{sig1: shares[4].sig.sig2, sig2: shares[4].sig.sig1})
+			return shares
+		}, invalid: []AuthorityID{1, 5}, valid: true, msg: "Tampered shares"},
+		{tamper: func(shares []*SignatureShare) []*SignatureShare {
+			// a valid credential, but on a different sig1
+			sig, err := Sign(params, sks[2], m)
+			assert.Nil(t, err)
+			shares[2] = NewSignatureShare(3, sig)
+			return shares
+		}, invalid: []AuthorityID{3}, valid: true, msg: "Share with a different sig1"},
+		{tamper: func(shares []*SignatureShare) []*SignatureShare {
+			return append(shares, NewSignatureShare(6, shares[0].sig), shares[1])
+		}, invalid: []AuthorityID{2, 6}, valid: true, msg: "Unknown and duplicate authorities"},
+		{tamper: func(shares []*SignatureShare) []*SignatureShare {
+			// even valid shares of the authorities are not used once they are sent more than once
+			return append(shares, shares[0], shares[1], shares[2])
+		}, invalid: []AuthorityID{1, 2, 3}, valid: false, msg: "Duplicates of valid shares"},
+		{tamper: func(shares []*SignatureShare) []*SignatureShare {
+			shares[0] = NewSignatureShare(1, shares[1].sig)
+			shares[3] = NewSignatureShare(4, shares[1].sig)
+			shares[4] = NewSignatureShare(5, shares[1].sig)
+			return shares
+		}, invalid: []AuthorityID{1, 4, 5}, valid: false, msg: "Fewer than t valid shares"},
+	}
+
+	for _, test := range tests {
+		shares := test.tamper(blindSignShares(t, params, sks, privM, pubM))
+		aSig, invalid, err := AggregateVerifiedSignatures(params, vks, m, shares)
+		assert.Nil(t, err, test.msg)
+		assert.Equal(t, test.invalid, invalid, test.msg)
+		assert.Equal(t, test.valid, Verify(params, avk, m, aSig), test.msg)
+
+		// the unverified aggregation produces an invalid credential
+		if len(test.invalid) > 0 && len(shares) == len(sks) {
+			aSig, err := AggregateSignatures(params, shares)
+			assert.Nil(t, err, test.msg)
+			assert.False(t, Verify(params, avk, m, aSig), test.msg)
+		}
+	}
+}
+
+func TestAggregateVerifiedSignaturesInvalid(t *testing.T) {
+	params, err := Setup(1)
+	assert.Nil(t, err)
+	m := []*Curve.BIG{Curve.NewBIGint(42)}
+
+	sks, vks, _, err := TTPKeygen(params, 2, AuthorityIDs(3))
+	assert.Nil(t, err)
+	shares := make([]*SignatureShare, len(sks))
+	for i, sk := range sks {
+		sig, err := Sign(params, sk, m)
+		assert.Nil(t, err)
+		shares[i] = NewSignatureShare(sk.ID(), sig)
+	}
+
+	_, _, err = AggregateVerifiedSignatures(params, vks, m, nil)
+	assert.Equal(t, ErrAggregateParams, err)
+	_, _, err = AggregateVerifiedSignatures(params, nil, m, shares)
+	assert.Equal(t, ErrAggregateParams, err)
+	_, _, err = AggregateVerifiedSignatures(params, []*VerificationKey{vks[0], vks[0]}, m, shares)
+	assert.Equal(t, ErrAggregateParams, err)
+	_, _, err = AggregateVerifiedSignatures(params, vks, m, []*SignatureShare{shares[0], nil})
+	assert.Equal(t, utils.ErrValidateNil, err)
+
+	_, vk, err := Keygen(params)
+	assert.Nil(t, err)
+	_, _, err = AggregateVerifiedSignatures(params, []*VerificationKey{vk}, m, shares)
+	assert.Equal(t, ErrAggregateParams, err, "Keys not tagged with authority ids")
+
+	aSig, invalid, err := AggregateVerifiedSignatures(params, vks, []*Curve.BIG{Curve.NewBIGint(43)}, shares)
+	assert.Equal(t, ErrAggregateNoValidShares, err)
+	assert.Nil(t, aSig)
+	assert.Equal(t, AuthorityIDs(3), invalid)
+}
+
+func TestAggregateThresholdSignatures(t *testing.T) {
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	privM := []*Curve.BIG{Curve.NewBIGint(42)}
+	pubM := []*Curve.BIG{Curve.NewBIGint(43)}
+	m := append(privM, pubM...)
+
+	thr := 3
+	sks, vks, cms, err := TTPKeygen(params, thr, AuthorityIDs(6))
+	assert.Nil(t, err)
+	avk, err := DeriveVerificationKey(params, cms, 0)
+	assert.Nil(t, err)
+
+	// corrupt replaces the share of the authority with the given id with a share of another one
+	corrupt := func(shares []*SignatureShare, id AuthorityID) {
+		for i, share := range shares {
+			if share.ID() == id {
+				shares[i] = NewSignatureShare(id, shares[(i+1)%len(shares)].Signature())
+			}
+		}
+	}
+
+	tests := []struct {
+		received []int
+		bad      []AuthorityID
+		err      *ThresholdError
+		msg      string
+	}{
+		{received: []int{0, 1, 2, 3, 4, 5}, bad: nil, msg: "All authorities responded correctly"},
+		{received: []int{0, 1, 2}, bad: nil, msg: "Exactly t authorities responded"},
+		{received: []int{5, 0, 3, 1}, bad: []AuthorityID{6}, msg: "First share is invalid"},
+		{received: []int{0, 1, 2, 3, 4, 5}, bad: []AuthorityID{1, 3, 5}, msg: "Half of the authorities are faulty"},
+		{received: []int{0, 1, 2, 3}, bad: []AuthorityID{2, 4},
+			err: &ThresholdError{Threshold: thr, Valid: 2, Invalid: []AuthorityID{2, 4}, Missing: []AuthorityID{5, 6}},
+			msg: "Too many faulty authorities"},
+		{received: []int{4, 1}, bad: nil,
+			err: &ThresholdError{Threshold: thr, Valid: 2, Missing: []AuthorityID{1, 3, 4, 6}},
+			msg: "Too few authorities responded"},
+		{received: []int{}, bad: nil,
+			err: &ThresholdError{Threshold: thr, Valid: 0, Missing: AuthorityIDs(6)},
+			msg: "No authority responded"},
+	}
+
+	all := blindSignShares(t, params, sks, privM, pubM)
+	for _, test := range tests {
+		shares := make([]*SignatureShare, len(test.received))
+		for i, r := range test.received {
+			shares[i] = all[r]
+		}
+		for _, id := range test.bad {
+			corrupt(shares, id)
+		}
+
+		aSig, err := AggregateThresholdSignatures(params, thr, vks, m, shares)
+		if test.err != nil {
+			assert.Nil(t, aSig, test.msg)
+			assert.Equal(t, test.err, err, test.msg)
+			assert.NotEmpty(t, err.Error())
+			continue
+		}
+		assert.Nil(t, err, test.msg)
+		assert.True(t, Verify(params, avk, m, aSig), test.msg)
+	}
+
+	_, err = AggregateThresholdSignatures(params, 0, vks, m, all)
+	assert.Equal(t, ErrAggregateParams, err)
+	_, err = AggregateThresholdSignatures(params, len(vks)+1, vks, m, all)
+	assert.Equal(t, ErrAggregateParams, err)
+	_, err = AggregateThresholdSignatures(params, thr, vks, m, append(all, nil))
+	assert.Equal(t, utils.ErrValidateNil, err)
+}
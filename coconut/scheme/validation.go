@@ -0,0 +1,232 @@
+// validation.go - validation of Coconut objects
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package coconut provides the functionalities required by the Coconut Scheme.
+package coconut
+
+import (
+	"errors"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// All objects are validated when they are decoded, hence the objects created by this package
+// either by the scheme functions or by decoding are always valid.
+// The scheme functions validate all the objects sent between the parties of the protocol.
+// However, as subgroup checks on G2 are relatively expensive, they only validate
+// the structure of verification keys and leave the full check to decoding and aggregation.
+
+var (
+	// ErrValidateLength indicates that the object had inconsistent or invalid number of elements.
+	ErrValidateLength = errors.New("Invalid number of elements")
+)
+
+// Validate ensures the SecretKey has all the elements, which are smaller than the order of the groups,
+// and a non-negative authority id.
+func (sk *SecretKey) Validate() error {
+	if sk == nil || sk.x == nil {
+		return utils.ErrValidateNil
+	}
+	if sk.id < 0 {
+		return ErrAuthorityID
+	}
+	if len(sk.y) == 0 {
+		return ErrValidateLength
+	}
+	return utils.ValidateScalars(append([]*Curve.BIG{sk.x}, sk.y...))
+}
+
+// validateStructure ensures the VerificationKey has all the elements, none of which is the point at infinity.
+func (vk *VerificationKey) validateStructure() error {
+	if vk == nil || vk.g2 == nil || vk.alpha == nil {
+		return utils.ErrValidateNil
+	}
+	if len(vk.beta) == 0 {
+		return ErrValidateLength
+	}
+	if utils.IsInfinity2(vk.g2) || utils.IsInfinity2(vk.alpha) {
+		return utils.ErrValidateIdentity
+	}
+	for _, b := range vk.beta {
+		if b == nil {
+			return utils.ErrValidateNil
+		}
+		if utils.IsInfinity2(b) {
+			return utils.ErrValidateIdentity
+		}
+	}
+	return nil
+}
+
+// Validate ensures the VerificationKey has all the elements, none of which is the point at infinity,
+// and that all of them are in the prime order subgroup.
+func (vk *VerificationKey) Validate() error {
+	if err := vk.validateStructure(); err != nil {
+		return err
+	}
+	if err := utils.ValidateECP2(vk.g2); err != nil {
+		return err
+	}
+	if err := utils.ValidateECP2(vk.alpha); err != nil {
+		return err
+	}
+	return utils.ValidateECP2s(vk.beta)
+}
+
+// validateStructure ensures the KeygenCommitments commit to at least one polynomial v and w[i] of the same degree
+// and none of the commitments is missing or is the point at infinity.
+func (cms *KeygenCommitments) validateStructure() error {
+	if cms == nil {
+		return utils.ErrValidateNil
+	}
+	if len(cms.alpha) == 0 || len(cms.beta) == 0 {
+		return ErrValidateLength
+	}
+	for _, cm := range append([][]*Curve.ECP2{cms.alpha}, cms.beta...) {
+		if len(cm) != len(cms.alpha) {
+			return ErrValidateLength
+		}
+		for _, c := range cm {
+			if c == nil {
+				return utils.ErrValidateNil
+			}
+			if utils.IsInfinity2(c) {
+				return utils.ErrValidateIdentity
+			}
+		}
+	}
+	return nil
+}
+
+// Validate ensures the KeygenCommitments have a valid structure
+// and that all the commitments are in the prime order subgroup.
+func (cms *KeygenCommitments) Validate() error {
+	if err := cms.validateStructure(); err != nil {
+		return err
+	}
+	if err := utils.ValidateECP2s(cms.alpha); err != nil {
+		return err
+	}
+	for _, cm := range cms.beta {
+		if err := utils.ValidateECP2s(cm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate ensures both elements of the Signature are present, are not the point at infinity
+// and are in the prime order subgroup.
+func (sig *Signature) Validate() error {
+	if sig == nil {
+		return utils.ErrValidateNil
+	}
+	if err := utils.ValidateECP(sig.sig1); err != nil {
+		return err
+	}
+	return utils.ValidateECP(sig.sig2)
+}
+
+// Validate ensures both elements of the BlindedSignature are present, are not the point at infinity
+// and are in the prime order subgroup.
+func (blindedSig *BlindedSignature) Validate() error {
+	if blindedSig == nil {
+		return utils.ErrValidateNil
+	}
+	if err := utils.ValidateECP(blindedSig.sig1); err != nil {
+		return err
+	}
+	return blindedSig.sig2Tilda.Validate()
+}
+
+// Validate ensures the BlindSignMats contains commitment and encryptions that are not the point at infinity
+// and are in the prime order subgroup, and a proof with number of elements consistent with the encryptions.
+func (blindSignMats *BlindSignMats) Validate() error {
+	if blindSignMats == nil || blindSignMats.proof == nil {
+		return utils.ErrValidateNil
+	}
+	if err := utils.ValidateECP(blindSignMats.cm); err != nil {
+		return err
+	}
+	if len(blindSignMats.enc) == 0 {
+		return ErrValidateLength
+	}
+	for _, enc := range blindSignMats.enc {
+		if err := enc.Validate(); err != nil {
+			return err
+		}
+	}
+	return blindSignMats.proof.validate(len(blindSignMats.enc))
+}
+
+// Validate ensures the BlindShowMats contains kappa and nu that are not the point at infinity
+// and are in the prime order subgroup, and a complete proof.
+func (blindShowMats *BlindShowMats) Validate() error {
+	if blindShowMats == nil || blindShowMats.proof == nil {
+		return utils.ErrValidateNil
+	}
+	if err := utils.ValidateECP2(blindShowMats.kappa); err != nil {
+		return err
+	}
+	if err := utils.ValidateECP(blindShowMats.nu); err != nil {
+		return err
+	}
+	return blindShowMats.proof.validate()
+}
+
+// validate ensures the proof is complete and is consistent with the given number of encryptions.
+func (proof *SignerProof) validate(encs int) error {
+	if proof.c == nil || proof.rr == nil {
+		return utils.ErrValidateNil
+	}
+	if len(proof.rk) != encs || len(proof.rm) < encs {
+		return ErrValidateLength
+	}
+	if err := utils.ValidateBIGs(proof.rk); err != nil {
+		return err
+	}
+	return utils.ValidateBIGs(proof.rm)
+}
+
+// validate ensures the proof is complete.
+func (proof *VerifierProof) validate() error {
+	if proof.c == nil || proof.rt == nil {
+		return utils.ErrValidateNil
+	}
+	if len(proof.rm) == 0 {
+		return ErrValidateLength
+	}
+	return utils.ValidateBIGs(proof.rm)
+}
+
+// validateProofScalars ensures the challenge and the responses of a proof are present and in range.
+// On BN254 the challenge is not reduced for compatibility with the Python implementation,
+// hence any value that fits the encoding is accepted for it.
+func validateProofScalars(c *Curve.BIG, responses ...[]*Curve.BIG) error {
+	if c == nil {
+		return utils.ErrValidateNil
+	}
+	var scalars []*Curve.BIG
+	if bpgroup.CurrentCurve() != bpgroup.BN254 {
+		scalars = append(scalars, c)
+	}
+	for _, rs := range responses {
+		scalars = append(scalars, rs...)
+	}
+	return utils.ValidateScalars(scalars)
+}
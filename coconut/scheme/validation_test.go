@@ -0,0 +1,199 @@
+// validation_test.go - tests of validation of Coconut objects
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package coconut
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	"github.com/jstuczyn/CoconutGo/elgamal"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// nonSubgroupECP2 returns a point on the G2 curve outside the prime order subgroup.
+func nonSubgroupECP2() *Curve.ECP2 {
+	for i := 1; ; i++ {
+		P := Curve.NewECP2fp2(Curve.NewFP2int(i))
+		if !P.Is_infinity() && !P.Mul(Curve.NewBIGints(Curve.CURVE_Order)).Is_infinity() {
+			return P
+		}
+	}
+}
+
+func TestValidateVerificationKey(t *testing.T) {
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	_, vk, err := Keygen(params)
+	assert.Nil(t, err)
+	assert.Nil(t, vk.Validate())
+
+	badVks := []*VerificationKey{
+		nil,
+		{g2: vk.g2, alpha: nil, beta: vk.beta},
+		{g2: vk.g2, alpha: vk.alpha, beta: nil},
+		{g2: vk.g2, alpha: Curve.NewECP2(), beta: vk.beta},
+		{g2: vk.g2, alpha: vk.alpha, beta: []*Curve.ECP2{vk.beta[0], Curve.NewECP2()}},
+		{g2: vk.g2, alpha: vk.alpha, beta: []*Curve.ECP2{vk.beta[0], nonSubgroupECP2()}},
+	}
+	for _, badVk := range badVks {
+		assert.NotNil(t, badVk.Validate())
+	}
+
+	// a key outside the subgroup can be encoded, but is rejected when decoded
+	badVk := &VerificationKey{g2: vk.g2, alpha: nonSubgroupECP2(), beta: vk.beta}
+	b, err := badVk.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, utils.ErrValidateSubgroup, (&VerificationKey{}).UnmarshalBinary(b))
+	b, err = badVk.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, utils.ErrValidateSubgroup, (&VerificationKey{}).UnmarshalJSON(b))
+}
+
+func TestAggregationValidation(t *testing.T) {
+	params, err := Setup(3)
+	assert.Nil(t, err)
+	_, vks, _, err := TTPKeygen(params, 2, AuthorityIDs(3))
+	assert.Nil(t, err)
+	_, untaggedVk, err := Keygen(params)
+	assert.Nil(t, err)
+
+	_, err = AggregateVerificationKeys(params, nil)
+	assert.Equal(t, ErrAggregateParams, err)
+	_, err = AggregateVerificationKeys(params, []*VerificationKey{vks[0], vks[0]})
+	assert.Equal(t, ErrAggregateParams, err)
+	_, err = AggregateVerificationKeys(params, []*VerificationKey{vks[0], untaggedVk})
+	assert.Equal(t, ErrAggregateParams, err)
+
+	shortVk := &VerificationKey{id: 2, g2: vks[1].g2, alpha: vks[1].alpha, beta: vks[1].beta[:2]}
+	_, err = AggregateVerificationKeys(params, []*VerificationKey{vks[0], shortVk})
+	assert.Equal(t, ErrValidateLength, err)
+	shortVk.id = 0
+	_, err = AggregateVerificationKeys(params, []*VerificationKey{untaggedVk, shortVk})
+	assert.Equal(t, ErrValidateLength, err)
+
+	_, err = AggregateSignatures(params, nil)
+	assert.Equal(t, ErrAggregateParams, err)
+	_, err = AggregateSignatures(params, []*SignatureShare{nil})
+	assert.Equal(t, utils.ErrValidateNil, err)
+	sig := &Signature{sig1: params.g1, sig2: Curve.NewECP()}
+	_, err = AggregateSignatures(params, []*SignatureShare{NewSignatureShare(0, sig)})
+	assert.Equal(t, utils.ErrValidateIdentity, err)
+	sig = &Signature{sig1: params.g1, sig2: params.g1}
+	_, err = AggregateSignatures(params, []*SignatureShare{NewSignatureShare(1, sig), NewSignatureShare(1, sig)})
+	assert.Equal(t, ErrAggregateParams, err)
+	_, err = AggregateSignatures(params, []*SignatureShare{NewSignatureShare(1, sig), NewSignatureShare(0, sig)})
+	assert.Equal(t, ErrAggregateParams, err)
+	_, err = AggregateSignatures(params, []*SignatureShare{NewSignatureShare(-1, sig)})
+	assert.Equal(t, ErrAggregateParams, err)
+}
+
+func TestIssuanceValidation(t *testing.T) {
+	params, err := Setup(2)
+	assert.Nil(t, err)
+	sk, vk, err := Keygen(params)
+	assert.Nil(t, err)
+	d, gamma := elgamal.Keygen(params.G)
+
+	pubM := []*Curve.BIG{Curve.NewBIGint(1)}
+	privM := []*Curve.BIG{Curve.NewBIGint(2)}
+
+	_, err = PrepareBlindSign(params, elgamal.NewPublicKey(Curve.NewECP()), pubM, privM)
+	assert.Equal(t, utils.ErrValidateIdentity, err)
+	_, err = PrepareBlindSign(params, nil, pubM, privM)
+	assert.Equal(t, utils.ErrValidateNil, err)
+
+	blindSignMats, err := PrepareBlindSign(params, gamma, pubM, privM)
+	assert.Nil(t, err)
+	assert.Nil(t, blindSignMats.Validate())
+
+	_, err = BlindSign(params, sk, blindSignMats, elgamal.NewPublicKey(Curve.NewECP()), pubM)
+	assert.Equal(t, utils.ErrValidateIdentity, err)
+
+	badMats := []*BlindSignMats{
+		{cm: Curve.NewECP(), enc: blindSignMats.enc, proof: blindSignMats.proof},
+		{cm: blindSignMats.cm, enc: nil, proof: blindSignMats.proof},
+		{cm: blindSignMats.cm, enc: []*elgamal.Encryption{nil}, proof: blindSignMats.proof},
+		{cm: blindSignMats.cm, enc: blindSignMats.enc, proof: nil},
+		{cm: blindSignMats.cm, enc: blindSignMats.enc, proof: &SignerProof{
+			c:  blindSignMats.proof.c,
+			rr: blindSignMats.proof.rr,
+			rk: nil,
+			rm: blindSignMats.proof.rm,
+		}},
+	}
+	for _, mats := range badMats {
+		_, err = BlindSign(params, sk, mats, gamma, pubM)
+		assert.NotNil(t, err)
+	}
+
+	// responses for more attributes than the parameters support
+	oversized := &BlindSignMats{cm: blindSignMats.cm, enc: blindSignMats.enc, proof: &SignerProof{
+		c:  blindSignMats.proof.c,
+		rr: blindSignMats.proof.rr,
+		rk: blindSignMats.proof.rk,
+		rm: append(blindSignMats.proof.rm, Curve.NewBIGint(42), Curve.NewBIGint(43)),
+	}}
+	assert.Nil(t, oversized.Validate())
+	_, err = BlindSign(params, sk, oversized, gamma, pubM)
+	assert.Equal(t, ErrBlindSignParams, err)
+	_, err = BlindSign(params, sk, oversized, gamma, nil)
+	assert.Equal(t, ErrBlindSignParams, err)
+	assert.False(t, VerifySignerProof(params, gamma.Gamma(), oversized.enc, oversized.cm, oversized.proof))
+
+	// responses for fewer attributes than the public ones provided
+	_, err = BlindSign(params, sk, blindSignMats, gamma, nil)
+	assert.Equal(t, ErrBlindSignParams, err)
+
+	// key of the authority supports fewer attributes than the parameters
+	smallSk := &SecretKey{x: sk.x, y: sk.y[:1]}
+	_, err = BlindSign(params, smallSk, blindSignMats, gamma, pubM)
+	assert.Equal(t, ErrBlindSignParams, err)
+	_, err = BlindSign(params, nil, blindSignMats, gamma, pubM)
+	assert.Equal(t, utils.ErrValidateNil, err)
+	_, err = BlindSign(params, &SecretKey{x: sk.x}, blindSignMats, gamma, pubM)
+	assert.Equal(t, ErrValidateLength, err)
+
+	blindedSignature, err := BlindSign(params, sk, blindSignMats, gamma, pubM)
+	assert.Nil(t, err)
+	assert.Nil(t, blindedSignature.Validate())
+	sig := Unblind(params, blindedSignature, d)
+	assert.Nil(t, sig.Validate())
+
+	identitySig := &Signature{sig1: Curve.NewECP(), sig2: Curve.NewECP()}
+	assert.False(t, Verify(params, vk, append(privM, pubM...), identitySig))
+	_, err = ShowBlindSignature(params, vk, identitySig, privM)
+	assert.Equal(t, utils.ErrValidateIdentity, err)
+	_, err = ShowBlindSignature(params, &VerificationKey{g2: vk.g2, alpha: vk.alpha}, sig, privM)
+	assert.Equal(t, ErrValidateLength, err)
+
+	blindShowMats, err := ShowBlindSignature(params, vk, sig, privM)
+	assert.Nil(t, err)
+	assert.Nil(t, blindShowMats.Validate())
+	assert.True(t, BlindVerify(params, vk, sig, blindShowMats, pubM))
+
+	badShowMats := []*BlindShowMats{
+		{kappa: Curve.NewECP2(), nu: blindShowMats.nu, proof: blindShowMats.proof},
+		{kappa: nonSubgroupECP2(), nu: blindShowMats.nu, proof: blindShowMats.proof},
+		{kappa: blindShowMats.kappa, nu: nil, proof: blindShowMats.proof},
+		{kappa: blindShowMats.kappa, nu: blindShowMats.nu, proof: &VerifierProof{c: blindShowMats.proof.c}},
+	}
+	for _, mats := range badShowMats {
+		assert.NotNil(t, mats.Validate())
+		assert.False(t, BlindVerify(params, vk, sig, mats, pubM))
+	}
+}
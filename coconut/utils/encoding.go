@@ -0,0 +1,462 @@
+// encoding.go - binary encoding of curve elements
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+const (
+	// BIGLen is the length of an encoded BIG number.
+	BIGLen = int(Curve.MODBYTES)
+
+	// ECPLen is the length of an encoded (compressed) point on the G1 curve.
+	ECPLen = int(Curve.MODBYTES) + 1
+
+	// ECP2Len is the length of an encoded point on the G2 curve.
+	ECP2Len = 4 * int(Curve.MODBYTES)
+
+	// HeaderLen is the length of the header preceding every encoded object.
+	// It consists of the encoding version, identifier of the curve and type of the object.
+	HeaderLen = 3
+
+	// IDLen is the length of an encoded identifier of a party.
+	IDLen = 4
+
+	// lenPrefix is the length of the prefix preceding variable-length data.
+	lenPrefix = 4
+)
+
+var (
+	// ErrDecodeBIG indicates that the provided bytes do not represent a BIG number.
+	ErrDecodeBIG = errors.New("Invalid BIG encoding")
+
+	// ErrDecodeECP indicates that the provided bytes do not represent a point on the G1 curve.
+	ErrDecodeECP = errors.New("Invalid ECP encoding")
+
+	// ErrDecodeECP2 indicates that the provided bytes do not represent a point on the G2 curve.
+	ErrDecodeECP2 = errors.New("Invalid ECP2 encoding")
+
+	// ErrEncodeNil indicates that the object to encode was incomplete.
+	ErrEncodeNil = errors.New("Can't encode nil element")
+
+	// ErrEncodeInfinity indicates that the object to encode contained point at infinity,
+	// which has no unambiguous representation.
+	ErrEncodeInfinity = errors.New("Can't encode point at infinity")
+
+	// ErrDecodeHeader indicates that the encoded object has unsupported version, unexpected type
+	// or was created for a different curve.
+	ErrDecodeHeader = errors.New("Invalid encoding header")
+
+	// ErrEncodeID indicates that the identifier of a party to encode was negative or too large.
+	ErrEncodeID = errors.New("Can't encode identifier out of range")
+
+	// ErrDecodeID indicates that the encoded identifier of a party was out of range.
+	ErrDecodeID = errors.New("Invalid identifier encoding")
+
+	// ErrDecodeLength indicates that the encoded object was either truncated or contained trailing bytes.
+	ErrDecodeLength = errors.New("Invalid encoding length")
+)
+
+// BIGToBytes returns big-endian representation of the BIG number.
+func BIGToBytes(x *Curve.BIG) []byte {
+	b := make([]byte, BIGLen)
+	x.ToBytes(b)
+	return b
+}
+
+// BIGFromBytes recovers BIG number from its big-endian representation.
+func BIGFromBytes(b []byte) (*Curve.BIG, error) {
+	if len(b) != BIGLen {
+		return nil, ErrDecodeBIG
+	}
+	return Curve.FromBytes(b), nil
+}
+
+// ECPToBytes returns compressed representation of the point on the G1 curve.
+// It is the same representation as used by ToCoconutString.
+func ECPToBytes(p *Curve.ECP) []byte {
+	b := make([]byte, ECPLen)
+	p.ToBytes(b, true)
+	return b
+}
+
+// ECPFromBytes recovers point on the G1 curve from its compressed representation.
+// It returns an error if the point is not on the curve or is the point at infinity.
+func ECPFromBytes(b []byte) (*Curve.ECP, error) {
+	if len(b) != ECPLen || (b[0] != 0x02 && b[0] != 0x03) {
+		return nil, ErrDecodeECP
+	}
+	// ECP_fromBytes returns point at infinity for x >= p and points not on the curve
+	p := Curve.ECP_fromBytes(b)
+	if p.Is_infinity() {
+		return nil, ErrDecodeECP
+	}
+	return p, nil
+}
+
+// ECP2ToBytes returns representation of the point on the G2 curve.
+// It is the same representation as used by ToCoconutString.
+func ECP2ToBytes(p *Curve.ECP2) []byte {
+	b := make([]byte, ECP2Len)
+	p.ToBytes(b)
+	return b
+}
+
+// ECP2FromBytes recovers point on the G2 curve from its representation.
+// It returns an error if the point is not on the curve or is the point at infinity.
+func ECP2FromBytes(b []byte) (*Curve.ECP2, error) {
+	if len(b) != ECP2Len {
+		return nil, ErrDecodeECP2
+	}
+	// unlike ECP_fromBytes, ECP2_fromBytes silently reduces coordinates, so they need to be checked explicitly
+	q := Curve.NewBIGints(Curve.Modulus)
+	for i := 0; i < 4; i++ {
+		if Curve.Comp(Curve.FromBytes(b[i*BIGLen:(i+1)*BIGLen]), q) >= 0 {
+			return nil, ErrDecodeECP2
+		}
+	}
+	p := Curve.ECP2_fromBytes(b)
+	if p.Is_infinity() {
+		return nil, ErrDecodeECP2
+	}
+	return p, nil
+}
+
+// Encoder builds versioned, length-prefixed binary representation of an object.
+// The first error encountered is retained and returned by Bytes, so that the callers
+// do not need to check errors after every write.
+type Encoder struct {
+	buf []byte
+	err error
+}
+
+// NewEncoder creates an Encoder for object of the given type, written using the given encoding version.
+// The header also includes identifier of the curve the library was built against.
+func NewEncoder(version byte, tag byte) *Encoder {
+	return &Encoder{
+		buf: []byte{version, byte(bpgroup.CurrentCurve()), tag},
+	}
+}
+
+// PutLen writes length prefix (or number of elements) of the following data.
+func (e *Encoder) PutLen(n int) {
+	if e.err != nil {
+		return
+	}
+	var b [lenPrefix]byte
+	binary.BigEndian.PutUint32(b[:], uint32(n))
+	e.buf = append(e.buf, b[:]...)
+}
+
+// PutID writes the identifier of a party, such as an authority, as 4-byte big-endian number.
+func (e *Encoder) PutID(id int) {
+	if e.err != nil {
+		return
+	}
+	if id < 0 || uint64(id) > math.MaxInt32 {
+		e.err = ErrEncodeID
+		return
+	}
+	var b [IDLen]byte
+	binary.BigEndian.PutUint32(b[:], uint32(id))
+	e.buf = append(e.buf, b[:]...)
+}
+
+// PutByte writes a single byte.
+func (e *Encoder) PutByte(b byte) {
+	if e.err != nil {
+		return
+	}
+	e.buf = append(e.buf, b)
+}
+
+// PutBytes writes length of the byte slice followed by its content.
+func (e *Encoder) PutBytes(b []byte) {
+	e.PutLen(len(b))
+	if e.err != nil {
+		return
+	}
+	e.buf = append(e.buf, b...)
+}
+
+// PutBIG writes the BIG number.
+func (e *Encoder) PutBIG(x *Curve.BIG) {
+	if e.err != nil {
+		return
+	}
+	if x == nil {
+		e.err = ErrEncodeNil
+		return
+	}
+	e.buf = append(e.buf, BIGToBytes(x)...)
+}
+
+// PutECP writes the point on the G1 curve.
+func (e *Encoder) PutECP(p *Curve.ECP) {
+	if e.err != nil {
+		return
+	}
+	if p == nil {
+		e.err = ErrEncodeNil
+		return
+	}
+	if IsInfinity(p) {
+		e.err = ErrEncodeInfinity
+		return
+	}
+	e.buf = append(e.buf, ECPToBytes(p)...)
+}
+
+// PutECP2 writes the point on the G2 curve.
+func (e *Encoder) PutECP2(p *Curve.ECP2) {
+	if e.err != nil {
+		return
+	}
+	if p == nil {
+		e.err = ErrEncodeNil
+		return
+	}
+	if IsInfinity2(p) {
+		e.err = ErrEncodeInfinity
+		return
+	}
+	e.buf = append(e.buf, ECP2ToBytes(p)...)
+}
+
+// PutBIGs writes the number of BIG numbers followed by each of them.
+func (e *Encoder) PutBIGs(xs []*Curve.BIG) {
+	e.PutLen(len(xs))
+	for _, x := range xs {
+		e.PutBIG(x)
+	}
+}
+
+// PutECPs writes the number of G1 points followed by each of them.
+func (e *Encoder) PutECPs(ps []*Curve.ECP) {
+	e.PutLen(len(ps))
+	for _, p := range ps {
+		e.PutECP(p)
+	}
+}
+
+// PutECP2s writes the number of G2 points followed by each of them.
+func (e *Encoder) PutECP2s(ps []*Curve.ECP2) {
+	e.PutLen(len(ps))
+	for _, p := range ps {
+		e.PutECP2(p)
+	}
+}
+
+// PutObject writes length of the encoded object followed by the encoding itself.
+// MarshalBinary of m is expected to return ErrEncodeNil for nil receivers.
+func (e *Encoder) PutObject(m encoding.BinaryMarshaler) {
+	if e.err != nil {
+		return
+	}
+	b, err := m.MarshalBinary()
+	if err != nil {
+		e.err = err
+		return
+	}
+	e.PutLen(len(b))
+	e.buf = append(e.buf, b...)
+}
+
+// Bytes returns the encoded object or the first error that occurred during encoding.
+func (e *Encoder) Bytes() ([]byte, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.buf, nil
+}
+
+// Decoder reads objects written by an Encoder.
+// Similarly to Encoder, the first error encountered is retained
+// and all subsequent reads return zero values.
+type Decoder struct {
+	buf []byte
+	err error
+}
+
+// NewDecoder creates a Decoder for the given data
+// and ensures it represents an object of the expected type and encoding version created for the same curve.
+func NewDecoder(b []byte, version byte, tag byte) *Decoder {
+	d := &Decoder{}
+	if len(b) < HeaderLen {
+		d.err = ErrDecodeLength
+	} else if b[0] != version || b[1] != byte(bpgroup.CurrentCurve()) || b[2] != tag {
+		d.err = ErrDecodeHeader
+	} else {
+		d.buf = b[HeaderLen:]
+	}
+	return d
+}
+
+func (d *Decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.buf) < n {
+		d.err = ErrDecodeLength
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+// GetLen reads the length prefix. elemLen is the minimum length of each element,
+// which allows rejecting prefixes that could not possibly be satisfied by the remaining data.
+func (d *Decoder) GetLen(elemLen int) int {
+	b := d.next(lenPrefix)
+	if b == nil {
+		return 0
+	}
+	n := binary.BigEndian.Uint32(b)
+	if uint64(n)*uint64(elemLen) > uint64(len(d.buf)) {
+		d.err = ErrDecodeLength
+		return 0
+	}
+	return int(n)
+}
+
+// GetID reads the identifier of a party written by PutID.
+func (d *Decoder) GetID() int {
+	b := d.next(IDLen)
+	if b == nil {
+		return 0
+	}
+	id := binary.BigEndian.Uint32(b)
+	if id > math.MaxInt32 {
+		d.err = ErrDecodeID
+		return 0
+	}
+	return int(id)
+}
+
+// GetByte reads a single byte.
+func (d *Decoder) GetByte() byte {
+	b := d.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+// GetBytes reads a length-prefixed byte slice.
+func (d *Decoder) GetBytes() []byte {
+	b := d.next(d.GetLen(1))
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+// GetBIG reads a BIG number.
+func (d *Decoder) GetBIG() *Curve.BIG {
+	b := d.next(BIGLen)
+	if b == nil {
+		return nil
+	}
+	x, err := BIGFromBytes(b)
+	if err != nil {
+		d.err = err
+	}
+	return x
+}
+
+// GetECP reads a point on the G1 curve.
+func (d *Decoder) GetECP() *Curve.ECP {
+	b := d.next(ECPLen)
+	if b == nil {
+		return nil
+	}
+	p, err := ECPFromBytes(b)
+	if err != nil {
+		d.err = err
+	}
+	return p
+}
+
+// GetECP2 reads a point on the G2 curve.
+func (d *Decoder) GetECP2() *Curve.ECP2 {
+	b := d.next(ECP2Len)
+	if b == nil {
+		return nil
+	}
+	p, err := ECP2FromBytes(b)
+	if err != nil {
+		d.err = err
+	}
+	return p
+}
+
+// GetBIGs reads a length-prefixed slice of BIG numbers.
+func (d *Decoder) GetBIGs() []*Curve.BIG {
+	n := d.GetLen(BIGLen)
+	xs := make([]*Curve.BIG, n)
+	for i := range xs {
+		xs[i] = d.GetBIG()
+	}
+	return xs
+}
+
+// GetECPs reads a length-prefixed slice of points on the G1 curve.
+func (d *Decoder) GetECPs() []*Curve.ECP {
+	n := d.GetLen(ECPLen)
+	ps := make([]*Curve.ECP, n)
+	for i := range ps {
+		ps[i] = d.GetECP()
+	}
+	return ps
+}
+
+// GetECP2s reads a length-prefixed slice of points on the G2 curve.
+func (d *Decoder) GetECP2s() []*Curve.ECP2 {
+	n := d.GetLen(ECP2Len)
+	ps := make([]*Curve.ECP2, n)
+	for i := range ps {
+		ps[i] = d.GetECP2()
+	}
+	return ps
+}
+
+// GetObject reads a length-prefixed encoded object into u.
+func (d *Decoder) GetObject(u encoding.BinaryUnmarshaler) {
+	b := d.next(d.GetLen(1))
+	if b == nil {
+		return
+	}
+	if err := u.UnmarshalBinary(b); err != nil {
+		d.err = err
+	}
+}
+
+// Finish ensures the entire data was consumed and returns the first error that occurred during decoding.
+func (d *Decoder) Finish() error {
+	if d.err == nil && len(d.buf) > 0 {
+		return ErrDecodeLength
+	}
+	return d.err
+}
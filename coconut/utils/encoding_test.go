@@ -0,0 +1,192 @@
+// encoding_test.go - tests for binary encoding of curve elements
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// offCurveECPBytes returns compressed encoding of x coordinate that does not correspond to any point on G1 curve.
+func offCurveECPBytes() []byte {
+	for i := 1; ; i++ {
+		x := Curve.NewBIGint(i)
+		if Curve.NewECPbigint(x, 0).Is_infinity() {
+			b := make([]byte, ECPLen)
+			b[0] = 0x02
+			x.ToBytes(b[1:])
+			return b
+		}
+	}
+}
+
+func TestPointEncoding(t *testing.T) {
+	G := bpgroup.New()
+	r := Curve.Randomnum(G.Order(), G.Rng())
+	g1r := Curve.G1mul(G.Gen1(), r)
+	g2r := Curve.G2mul(G.Gen2(), r)
+
+	rRec, err := BIGFromBytes(BIGToBytes(r))
+	assert.Nil(t, err)
+	assert.Zero(t, Curve.Comp(r, rRec))
+
+	g1rRec, err := ECPFromBytes(ECPToBytes(g1r))
+	assert.Nil(t, err)
+	assert.True(t, g1r.Equals(g1rRec))
+
+	g2rRec, err := ECP2FromBytes(ECP2ToBytes(g2r))
+	assert.Nil(t, err)
+	assert.True(t, g2r.Equals(g2rRec))
+
+	_, err = BIGFromBytes(BIGToBytes(r)[1:])
+	assert.Equal(t, ErrDecodeBIG, err)
+
+	_, err = ECPFromBytes(ECPToBytes(g1r)[1:])
+	assert.Equal(t, ErrDecodeECP, err, "Should not decode truncated point")
+
+	uncompressed := make([]byte, 2*BIGLen+1)
+	g1r.ToBytes(uncompressed, false)
+	_, err = ECPFromBytes(uncompressed)
+	assert.Equal(t, ErrDecodeECP, err, "Should only accept compressed points")
+
+	_, err = ECPFromBytes(offCurveECPBytes())
+	assert.Equal(t, ErrDecodeECP, err, "Should not decode point that is not on the curve")
+
+	b := ECP2ToBytes(g2r)
+	b[len(b)-1] ^= 0x01 // modify y coordinate
+	_, err = ECP2FromBytes(b)
+	assert.Equal(t, ErrDecodeECP2, err, "Should not decode point that is not on the curve")
+
+	b = ECP2ToBytes(g2r)
+	for i := 0; i < BIGLen; i++ {
+		b[i] = 0xff // x coordinate larger than the field modulus
+	}
+	_, err = ECP2FromBytes(b)
+	assert.Equal(t, ErrDecodeECP2, err, "Should not decode non-canonical coordinates")
+}
+
+func TestEncoderDecoder(t *testing.T) {
+	G := bpgroup.New()
+	x := Curve.Randomnum(G.Order(), G.Rng())
+	xs := []*Curve.BIG{Curve.Randomnum(G.Order(), G.Rng()), Curve.Randomnum(G.Order(), G.Rng())}
+	g1s := []*Curve.ECP{Curve.G1mul(G.Gen1(), x)}
+	g2s := []*Curve.ECP2{Curve.G2mul(G.Gen2(), x), G.Gen2()}
+
+	enc := NewEncoder(1, 42)
+	enc.PutBIG(x)
+	enc.PutBIGs(xs)
+	enc.PutECPs(g1s)
+	enc.PutECP2s(g2s)
+	b, err := enc.Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{1, byte(bpgroup.CurrentCurve()), 42}, b[:HeaderLen])
+
+	dec := NewDecoder(b, 1, 42)
+	xRec := dec.GetBIG()
+	xsRec := dec.GetBIGs()
+	g1sRec := dec.GetECPs()
+	g2sRec := dec.GetECP2s()
+	assert.Nil(t, dec.Finish())
+
+	assert.Zero(t, Curve.Comp(x, xRec))
+	assert.Len(t, xsRec, len(xs))
+	for i := range xs {
+		assert.Zero(t, Curve.Comp(xs[i], xsRec[i]))
+	}
+	assert.Len(t, g1sRec, len(g1s))
+	assert.True(t, g1s[0].Equals(g1sRec[0]))
+	assert.Len(t, g2sRec, len(g2s))
+	for i := range g2s {
+		assert.True(t, g2s[i].Equals(g2sRec[i]))
+	}
+
+	assert.Equal(t, ErrDecodeHeader, NewDecoder(b, 2, 42).Finish(), "Should reject unknown version")
+	assert.Equal(t, ErrDecodeHeader, NewDecoder(b, 1, 43).Finish(), "Should reject unexpected type")
+	assert.Equal(t, ErrDecodeLength, NewDecoder(b[:HeaderLen-1], 1, 42).Finish())
+
+	otherCurve := append([]byte{}, b...)
+	if bpgroup.CurrentCurve() == bpgroup.BN254 {
+		otherCurve[1] = byte(bpgroup.BLS381)
+	} else {
+		otherCurve[1] = byte(bpgroup.BN254)
+	}
+	assert.Equal(t, ErrDecodeHeader, NewDecoder(otherCurve, 1, 42).Finish(), "Should reject data for a different curve")
+
+	// truncated
+	dec = NewDecoder(b[:len(b)-1], 1, 42)
+	dec.GetBIG()
+	dec.GetBIGs()
+	dec.GetECPs()
+	dec.GetECP2s()
+	assert.Equal(t, ErrDecodeLength, dec.Finish(), "Should reject truncated data")
+
+	// trailing bytes
+	dec = NewDecoder(append(b, 0), 1, 42)
+	dec.GetBIG()
+	dec.GetBIGs()
+	dec.GetECPs()
+	dec.GetECP2s()
+	assert.Equal(t, ErrDecodeLength, dec.Finish(), "Should reject trailing data")
+
+	// length prefix that can't be satisfied by remaining data
+	dec = NewDecoder([]byte{1, byte(bpgroup.CurrentCurve()), 42, 0xff, 0xff, 0xff, 0xff}, 1, 42)
+	assert.Empty(t, dec.GetECP2s())
+	assert.Equal(t, ErrDecodeLength, dec.Finish())
+
+	enc = NewEncoder(1, 42)
+	enc.PutECP(nil)
+	_, err = enc.Bytes()
+	assert.Equal(t, ErrEncodeNil, err)
+
+	enc = NewEncoder(1, 42)
+	enc.PutECP2(Curve.NewECP2())
+	_, err = enc.Bytes()
+	assert.Equal(t, ErrEncodeInfinity, err)
+}
+
+func TestIDEncoding(t *testing.T) {
+	enc := NewEncoder(1, 42)
+	enc.PutID(0)
+	enc.PutID(7)
+	enc.PutID(math.MaxInt32)
+	b, err := enc.Bytes()
+	assert.Nil(t, err)
+	assert.Len(t, b, HeaderLen+3*IDLen)
+
+	dec := NewDecoder(b, 1, 42)
+	assert.Equal(t, 0, dec.GetID())
+	assert.Equal(t, 7, dec.GetID())
+	assert.Equal(t, math.MaxInt32, dec.GetID())
+	assert.Nil(t, dec.Finish())
+
+	enc = NewEncoder(1, 42)
+	enc.PutID(-1)
+	_, err = enc.Bytes()
+	assert.Equal(t, ErrEncodeID, err)
+
+	dec = NewDecoder([]byte{1, byte(bpgroup.CurrentCurve()), 42, 0xff, 0xff, 0xff, 0xff}, 1, 42)
+	assert.Zero(t, dec.GetID())
+	assert.Equal(t, ErrDecodeID, dec.Finish())
+
+	dec = NewDecoder([]byte{1, byte(bpgroup.CurrentCurve()), 42, 0, 0, 1}, 1, 42)
+	assert.Zero(t, dec.GetID())
+	assert.Equal(t, ErrDecodeLength, dec.Finish())
+}
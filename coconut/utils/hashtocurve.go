@@ -0,0 +1,371 @@
+// hashtocurve.go - hashing to field and to the G1 curve as defined by RFC 9380
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/amcl/version3/go/amcl"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// The functions follow RFC 9380 (Hashing to Elliptic Curves).
+// Points on the G1 curve are derived with the BLS12381G1_XMD:SHA-256_SSWU_RO_ suite,
+// while hash_to_field is used with both the base field and the scalar field,
+// in which case it serves as hash to scalar.
+// Unlike amcl, the field arithmetic is performed with math/big and is not constant-time.
+// This is acceptable as all the data hashed by the scheme is public.
+
+// G1SuiteID is the identifier of the hash_to_curve suite implemented by HashToCurveG1.
+// It is meant to be used as the suffix of domain separation tags.
+const G1SuiteID = "BLS12381G1_XMD:SHA-256_SSWU_RO_"
+
+const (
+	// securityBits is the target security level used to derive the length of hashed data in hash_to_field.
+	securityBits = 128
+
+	// sha256Len and sha256BlockLen are output and input block sizes of SHA256 in bytes.
+	sha256Len      = 32
+	sha256BlockLen = 64
+
+	// maxDSTLen is the maximum length of domain separation tag. Longer tags are hashed first.
+	maxDSTLen = 255
+)
+
+var (
+	// ErrExpandMessage indicates invalid domain separation tag or requested length in expand_message_xmd.
+	ErrExpandMessage = errors.New("Invalid domain separation tag or length to expand the message to")
+
+	// ErrHashToCurveSuite indicates that hashing to curve was attempted on a curve other than BLS12-381.
+	ErrHashToCurveSuite = errors.New("Hashing to curve is only supported on BLS12-381")
+)
+
+// fieldP and fieldR are the characteristic of the base field and the order of the groups respectively.
+var (
+	fieldP = bigFromBIG(Curve.NewBIGints(Curve.Modulus))
+	fieldR = bigFromBIG(Curve.NewBIGints(Curve.CURVE_Order))
+)
+
+// Parameters of the simplified SWU map to the curve E' isogenous to the G1 curve of BLS12-381:
+// E': y^2 = x^3 + A' * x + B', and of the 11-isogeny from E' to the G1 curve.
+// The coefficients of the isogeny polynomials are ordered by increasing degree.
+var (
+	g1SSWUZ = big.NewInt(11)
+	g1IsoA  = bigFromHex("144698a3b8e9433d693a02c96d4982b0ea985383ee66a8d8e8981aefd881ac98936f8da0e0f97f5cf428082d584c1d")
+	g1IsoB  = bigFromHex("12e2908d11688030018b12e8753eee3b2016c1f0f24f4070a0b9c14fcef35ef55a23215a316ceaa5d1cc48e98e172be0")
+
+	g1IsoXNum = bigsFromHex(
+		"11a05f2b1e833340b809101dd99815856b303e88a2d7005ff2627b56cdb4e2c85610c2d5f2e62d6eaeac1662734649b7",
+		"17294ed3e943ab2f0588bab22147a81c7c17e75b2f6a8417f565e33c70d1e86b4838f2a6f318c356e834eef1b3cb83bb",
+		"0d54005db97678ec1d1048c5d10a9a1bce032473295983e56878e501ec68e25c958c3e3d2a09729fe0179f9dac9edcb0",
+		"1778e7166fcc6db74e0609d307e55412d7f5e4656a8dbf25f1b33289f1b330835336e25ce3107193c5b388641d9b6861",
+		"0e99726a3199f4436642b4b3e4118e5499db995a1257fb3f086eeb65982fac18985a286f301e77c451154ce9ac8895d9",
+		"1630c3250d7313ff01d1201bf7a74ab5db3cb17dd952799b9ed3ab9097e68f90a0870d2dcae73d19cd13c1c66f652983",
+		"0d6ed6553fe44d296a3726c38ae652bfb11586264f0f8ce19008e218f9c86b2a8da25128c1052ecaddd7f225a139ed84",
+		"17b81e7701abdbe2e8743884d1117e53356de5ab275b4db1a682c62ef0f2753339b7c8f8c8f475af9ccb5618e3f0c88e",
+		"080d3cf1f9a78fc47b90b33563be990dc43b756ce79f5574a2c596c928c5d1de4fa295f296b74e956d71986a8497e317",
+		"169b1f8e1bcfa7c42e0c37515d138f22dd2ecb803a0c5c99676314baf4bb1b7fa3190b2edc0327797f241067be390c9e",
+		"10321da079ce07e272d8ec09d2565b0dfa7dccdde6787f96d50af36003b14866f69b771f8c285decca67df3f1605fb7b",
+		"06e08c248e260e70bd1e962381edee3d31d79d7e22c837bc23c0bf1bc24c6b68c24b1b80b64d391fa9c8ba2e8ba2d229",
+	)
+	g1IsoXDen = bigsFromHex(
+		"08ca8d548cff19ae18b2e62f4bd3fa6f01d5ef4ba35b48ba9c9588617fc8ac62b558d681be343df8993cf9fa40d21b1c",
+		"12561a5deb559c4348b4711298e536367041e8ca0cf0800c0126c2588c48bf5713daa8846cb026e9e5c8276ec82b3bff",
+		"0b2962fe57a3225e8137e629bff2991f6f89416f5a718cd1fca64e00b11aceacd6a3d0967c94fedcfcc239ba5cb83e19",
+		"03425581a58ae2fec83aafef7c40eb545b08243f16b1655154cca8abc28d6fd04976d5243eecf5c4130de8938dc62cd8",
+		"13a8e162022914a80a6f1d5f43e7a07dffdfc759a12062bb8d6b44e833b306da9bd29ba81f35781d539d395b3532a21e",
+		"0e7355f8e4e667b955390f7f0506c6e9395735e9ce9cad4d0a43bcef24b8982f7400d24bc4228f11c02df9a29f6304a5",
+		"0772caacf16936190f3e0c63e0596721570f5799af53a1894e2e073062aede9cea73b3538f0de06cec2574496ee84a3a",
+		"14a7ac2a9d64a8b230b3f5b074cf01996e7f63c21bca68a81996e1cdf9822c580fa5b9489d11e2d311f7d99bbdcc5a5e",
+		"0a10ecf6ada54f825e920b3dafc7a3cce07f8d1d7161366b74100da67f39883503826692abba43704776ec3a79a1d641",
+		"095fc13ab9e92ad4476d6e3eb3a56680f682b4ee96f7d03776df533978f31c1593174e4b4b7865002d6384d168ecdd0a",
+		"1",
+	)
+	g1IsoYNum = bigsFromHex(
+		"090d97c81ba24ee0259d1f094980dcfa11ad138e48a869522b52af6c956543d3cd0c7aee9b3ba3c2be9845719707bb33",
+		"134996a104ee5811d51036d776fb46831223e96c254f383d0f906343eb67ad34d6c56711962fa8bfe097e75a2e41c696",
+		"00cc786baa966e66f4a384c86a3b49942552e2d658a31ce2c344be4b91400da7d26d521628b00523b8dfe240c72de1f6",
+		"01f86376e8981c217898751ad8746757d42aa7b90eeb791c09e4a3ec03251cf9de405aba9ec61deca6355c77b0e5f4cb",
+		"08cc03fdefe0ff135caf4fe2a21529c4195536fbe3ce50b879833fd221351adc2ee7f8dc099040a841b6daecf2e8fedb",
+		"16603fca40634b6a2211e11db8f0a6a074a7d0d4afadb7bd76505c3d3ad5544e203f6326c95a807299b23ab13633a5f0",
+		"04ab0b9bcfac1bbcb2c977d027796b3ce75bb8ca2be184cb5231413c4d634f3747a87ac2460f415ec961f8855fe9d6f2",
+		"0987c8d5333ab86fde9926bd2ca6c674170a05bfe3bdd81ffd038da6c26c842642f64550fedfe935a15e4ca31870fb29",
+		"09fc4018bd96684be88c9e221e4da1bb8f3abd16679dc26c1e8b6e6a1f20cabe69d65201c78607a360370e577bdba587",
+		"0e1bba7a1186bdb5223abde7ada14a23c42a0ca7915af6fe06985e7ed1e4d43b9b3f7055dd4eba6f2bafaaebca731c30",
+		"19713e47937cd1be0dfd0b8f1d43fb93cd2fcbcb6caf493fd1183e416389e61031bf3a5cce3fbafce813711ad011c132",
+		"18b46a908f36f6deb918c143fed2edcc523559b8aaf0c2462e6bfe7f911f643249d9cdf41b44d606ce07c8a4d0074d8e",
+		"0b182cac101b9399d155096004f53f447aa7b12a3426b08ec02710e807b4633f06c851c1919211f20d4c04f00b971ef8",
+		"0245a394ad1eca9b72fc00ae7be315dc757b3b080d4c158013e6632d3c40659cc6cf90ad1c232a6442d9d3f5db980133",
+		"05c129645e44cf1102a159f748c4a3fc5e673d81d7e86568d9ab0f5d396a7ce46ba1049b6579afb7866b1e715475224b",
+		"15e6be4e990f03ce4ea50b3b42df2eb5cb181d8f84965a3957add4fa95af01b2b665027efec01c7704b456be69c8b604",
+	)
+	g1IsoYDen = bigsFromHex(
+		"16112c4c3a9c98b252181140fad0eae9601a6de578980be6eec3232b5be72e7a07f3688ef60c206d01479253b03663c1",
+		"1962d75c2381201e1a0cbd6c43c348b885c84ff731c4d59ca4a10356f453e01f78a4260763529e3532f6102c2e49a03d",
+		"058df3306640da276faaae7d6e8eb15778c4855551ae7f310c35a5dd279cd2eca6757cd636f96f891e2538b53dbf67f2",
+		"16b7d288798e5395f20d23bf89edb4d1d115c5dbddbcd30e123da489e726af41727364f2c28297ada8d26d98445f5416",
+		"0be0e079545f43e4b00cc912f8228ddcc6d19c9f0f69bbb0542eda0fc9dec916a20b15dc0fd2ededda39142311a5001d",
+		"08d9e5297186db2d9fb266eaac783182b70152c65550d881c5ecd87b6f0f5a6449f38db9dfa9cce202c6477faaf9b7ac",
+		"166007c08a99db2fc3ba8734ace9824b5eecfdfa8d0cf8ef5dd365bc400a0051d5fa9c01a58b1fb93d1a1399126a775c",
+		"16a3ef08be3ea7ea03bcddfabba6ff6ee5a4375efa1f4fd7feb34fd206357132b920f5b00801dee460ee415a15812ed9",
+		"1866c8ed336c61231a1be54fd1d74cc4f9fb0ce4c6af5920abc5750c4bf39b4852cfe2f7bb9248836b233d9d55535d4a",
+		"167a55cda70a6e1cea820597d94a84903216f763e13d87bb5308592e7ea7d4fbc7385ea3d529b35e346ef48bb8913f55",
+		"04d2f259eea405bd48f010a01ad2911d9c6dd039bb61a6290e591b36e636a5c871a5c29f4f83060400f8b49cba8f6aa8",
+		"0accbb67481d033ff5852c1e48c50c477f94ff8aefce42d28c0f9a88cea7913516f968986f7ebbea9684b529e2561092",
+		"0ad6b9514c767fe3c3613144b45f1496543346d98adf02267d5ceef9a00d9b8693000763e3b90ac11e99b138573345cc",
+		"02660400eb2e4f3b628bdd0d53cd76f2bf565b94e72927c1cb748df27942480e420517bd8714cc80d1fadc1326ed06f7",
+		"0e0fa1d816ddc03e6b24255e0d7819c171c40f65e273b853324efcd6356caa205ca2f570f13497804415473a1d634b8f",
+		"1",
+	)
+
+	// g1HEff is the scalar used for clearing the cofactor of points on the G1 curve.
+	g1HEff = bigFromHex("d201000000010001")
+)
+
+func bigFromHex(s string) *big.Int {
+	x, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid hash to curve constant")
+	}
+	return x
+}
+
+func bigsFromHex(ss ...string) []*big.Int {
+	xs := make([]*big.Int, len(ss))
+	for i := range ss {
+		xs[i] = bigFromHex(ss[i])
+	}
+	return xs
+}
+
+// bigFromBIG converts BIG number to big.Int.
+func bigFromBIG(x *Curve.BIG) *big.Int {
+	return new(big.Int).SetBytes(BIGToBytes(x))
+}
+
+// bigToBIG converts big.Int, which must fit in MODBYTES bytes, to BIG number.
+func bigToBIG(x *big.Int) *Curve.BIG {
+	b := x.Bytes()
+	padded := make([]byte, BIGLen)
+	copy(padded[BIGLen-len(b):], b)
+	return Curve.FromBytes(padded)
+}
+
+// sha256Sum returns SHA256 hash of concatenation of all the parts.
+func sha256Sum(parts ...[]byte) []byte {
+	H := amcl.NewHASH256()
+	for _, part := range parts {
+		H.Process_array(part)
+	}
+	return H.Hash()
+}
+
+// ExpandMessageXMD implements expand_message_xmd with SHA256 as defined in section 5.3.1 of RFC 9380.
+// It returns lenInBytes pseudorandom bytes derived from msg and the domain separation tag dst.
+func ExpandMessageXMD(msg []byte, dst []byte, lenInBytes int) ([]byte, error) {
+	ell := (lenInBytes + sha256Len - 1) / sha256Len
+	if len(dst) == 0 || lenInBytes <= 0 || lenInBytes > 65535 || ell > 255 {
+		return nil, ErrExpandMessage
+	}
+	if len(dst) > maxDSTLen {
+		dst = sha256Sum([]byte("H2C-OVERSIZE-DST-"), dst)
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	zPad := make([]byte, sha256BlockLen)
+	lIBStr := []byte{byte(lenInBytes >> 8), byte(lenInBytes)}
+	b0 := sha256Sum(zPad, msg, lIBStr, []byte{0}, dstPrime)
+
+	bi := sha256Sum(b0, []byte{1}, dstPrime)
+	uniformBytes := append([]byte{}, bi...)
+	for i := 2; i <= ell; i++ {
+		x := make([]byte, sha256Len)
+		for j := range x {
+			x[j] = b0[j] ^ bi[j]
+		}
+		bi = sha256Sum(x, []byte{byte(i)}, dstPrime)
+		uniformBytes = append(uniformBytes, bi...)
+	}
+	return uniformBytes[:lenInBytes], nil
+}
+
+// hashToField implements hash_to_field as defined in section 5.2 of RFC 9380
+// for a prime field of the given modulus, i.e. with extension degree 1.
+func hashToField(msg []byte, dst []byte, count int, modulus *big.Int) ([]*big.Int, error) {
+	L := (modulus.BitLen() + securityBits + 7) / 8
+	uniformBytes, err := ExpandMessageXMD(msg, dst, count*L)
+	if err != nil {
+		return nil, err
+	}
+	us := make([]*big.Int, count)
+	for i := range us {
+		us[i] = new(big.Int).SetBytes(uniformBytes[i*L : (i+1)*L])
+		us[i].Mod(us[i], modulus)
+	}
+	return us, nil
+}
+
+// HashToFieldFp hashes msg to count elements of the base field of the curve using hash_to_field
+// with the domain separation tag dst.
+func HashToFieldFp(msg []byte, dst []byte, count int) ([]*Curve.BIG, error) {
+	us, err := hashToField(msg, dst, count, fieldP)
+	if err != nil {
+		return nil, err
+	}
+	xs := make([]*Curve.BIG, count)
+	for i := range us {
+		xs[i] = bigToBIG(us[i])
+	}
+	return xs, nil
+}
+
+// HashToScalar hashes msg to a BIG number modulo the order of the groups using hash_to_field
+// with the domain separation tag dst. Contrary to HashBytesToBig, the result is uniformly distributed.
+func HashToScalar(msg []byte, dst []byte) (*Curve.BIG, error) {
+	us, err := hashToField(msg, dst, 1, fieldR)
+	if err != nil {
+		return nil, err
+	}
+	return bigToBIG(us[0]), nil
+}
+
+func fpAdd(a, b *big.Int) *big.Int {
+	r := new(big.Int).Add(a, b)
+	return r.Mod(r, fieldP)
+}
+
+func fpMul(a, b *big.Int) *big.Int {
+	r := new(big.Int).Mul(a, b)
+	return r.Mod(r, fieldP)
+}
+
+func fpNeg(a *big.Int) *big.Int {
+	r := new(big.Int).Neg(a)
+	return r.Mod(r, fieldP)
+}
+
+// fpInv0 returns the inverse of a, or 0 if a is 0.
+func fpInv0(a *big.Int) *big.Int {
+	if a.Sign() == 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).ModInverse(a, fieldP)
+}
+
+// fpSqrt returns a square root of a and whether it exists.
+// Both supported curves have p = 3 mod 4, so the root is a^((p+1)/4).
+func fpSqrt(a *big.Int) (*big.Int, bool) {
+	e := new(big.Int).Add(fieldP, big.NewInt(1))
+	e.Rsh(e, 2)
+	r := new(big.Int).Exp(a, e, fieldP)
+	return r, fpMul(r, r).Cmp(a) == 0
+}
+
+// fpSgn0 implements sgn0 for elements of prime field.
+func fpSgn0(a *big.Int) uint {
+	return a.Bit(0)
+}
+
+// fpPoly evaluates polynomial with the given coefficients ordered by increasing degree at x.
+func fpPoly(coeffs []*big.Int, x *big.Int) *big.Int {
+	r := new(big.Int).Set(coeffs[len(coeffs)-1])
+	for i := len(coeffs) - 2; i >= 0; i-- {
+		r = fpAdd(fpMul(r, x), coeffs[i])
+	}
+	return r
+}
+
+// sswuG1 maps u to a point on E' using the simplified SWU method described in section 6.6.2 of RFC 9380.
+func sswuG1(u *big.Int) (*big.Int, *big.Int) {
+	curve := func(x *big.Int) *big.Int {
+		// x^3 + A' * x + B'
+		return fpAdd(fpMul(fpAdd(fpMul(x, x), g1IsoA), x), g1IsoB)
+	}
+
+	zu2 := fpMul(g1SSWUZ, fpMul(u, u))
+	tv1 := fpInv0(fpAdd(fpMul(zu2, zu2), zu2)) // 1 / (Z^2 * u^4 + Z * u^2)
+
+	var x1 *big.Int
+	if tv1.Sign() == 0 {
+		x1 = fpMul(g1IsoB, fpInv0(fpMul(g1SSWUZ, g1IsoA))) // B' / (Z * A')
+	} else {
+		x1 = fpMul(fpNeg(fpMul(g1IsoB, fpInv0(g1IsoA))), fpAdd(big.NewInt(1), tv1)) // (-B' / A') * (1 + tv1)
+	}
+
+	x := x1
+	y, ok := fpSqrt(curve(x1))
+	if !ok {
+		x = fpMul(zu2, x1)
+		// if gx1 is not square, gx2 is
+		y, _ = fpSqrt(curve(x))
+	}
+	if fpSgn0(u) != fpSgn0(y) {
+		y = fpNeg(y)
+	}
+	return x, y
+}
+
+// isoMapG1 maps the point on E' to the G1 curve using the 11-isogeny.
+// It returns nil if the result is the point at infinity.
+func isoMapG1(x, y *big.Int) (*big.Int, *big.Int) {
+	xDen := fpPoly(g1IsoXDen, x)
+	yDen := fpPoly(g1IsoYDen, x)
+	if xDen.Sign() == 0 || yDen.Sign() == 0 {
+		return nil, nil
+	}
+	xr := fpMul(fpPoly(g1IsoXNum, x), fpInv0(xDen))
+	yr := fpMul(y, fpMul(fpPoly(g1IsoYNum, x), fpInv0(yDen)))
+	return xr, yr
+}
+
+// MapToCurveG1 implements map_to_curve of the BLS12381G1_XMD:SHA-256_SSWU_RO_ suite,
+// i.e. the simplified SWU map followed by the 11-isogeny. It maps an element of the base field
+// to a point on the G1 curve, which is not necessarily in the prime order subgroup.
+func MapToCurveG1(u *Curve.BIG) (*Curve.ECP, error) {
+	if bpgroup.CurrentCurve() != bpgroup.BLS381 {
+		return nil, ErrHashToCurveSuite
+	}
+	x, y := isoMapG1(sswuG1(new(big.Int).Mod(bigFromBIG(u), fieldP)))
+	if x == nil {
+		return Curve.NewECP(), nil
+	}
+	return Curve.NewECPbigs(bigToBIG(x), bigToBIG(y)), nil
+}
+
+// HashToCurveG1 hashes msg to a point in the prime order subgroup of the G1 curve
+// using the BLS12381G1_XMD:SHA-256_SSWU_RO_ suite with the domain separation tag dst.
+func HashToCurveG1(msg []byte, dst []byte) (*Curve.ECP, error) {
+	if bpgroup.CurrentCurve() != bpgroup.BLS381 {
+		return nil, ErrHashToCurveSuite
+	}
+	us, err := HashToFieldFp(msg, dst, 2)
+	if err != nil {
+		return nil, err
+	}
+	Q0, err := MapToCurveG1(us[0])
+	if err != nil {
+		return nil, err
+	}
+	Q1, err := MapToCurveG1(us[1])
+	if err != nil {
+		return nil, err
+	}
+	Q0.Add(Q1)
+	return Q0.Mul(bigToBIG(g1HEff)), nil
+}
@@ -0,0 +1,213 @@
+// hashtocurve_test.go - tests of hashing to field and to the G1 curve
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package utils
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// The test vectors come from appendices J.9.1 and K.1 of RFC 9380.
+
+func TestExpandMessageXMD(t *testing.T) {
+	longDST := "QUUX-V01-CS02-with-expander-SHA256-128-long-DST-" + strings.Repeat("1", 208)
+	tests := []struct {
+		dst          string
+		msg          string
+		lenInBytes   int
+		uniformBytes string
+	}{
+		{dst: "QUUX-V01-CS02-with-expander-SHA256-128", msg: "", lenInBytes: 32,
+			uniformBytes: "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{dst: "QUUX-V01-CS02-with-expander-SHA256-128", msg: "abc", lenInBytes: 32,
+			uniformBytes: "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+		{dst: "QUUX-V01-CS02-with-expander-SHA256-128", msg: "abcdef0123456789", lenInBytes: 32,
+			uniformBytes: "eff31487c770a893cfb36f912fbfcbff40d5661771ca4b2cb4eafe524333f5c1"},
+		{dst: "QUUX-V01-CS02-with-expander-SHA256-128", msg: "q128_" + strings.Repeat("q", 128), lenInBytes: 32,
+			uniformBytes: "b23a1d2b4d97b2ef7785562a7e8bac7eed54ed6e97e29aa51bfe3f12ddad1ff9"},
+		{dst: "QUUX-V01-CS02-with-expander-SHA256-128", msg: "a512_" + strings.Repeat("a", 512), lenInBytes: 32,
+			uniformBytes: "4623227bcc01293b8c130bf771da8c298dede7383243dc0993d2d94823958c4c"},
+		{dst: "QUUX-V01-CS02-with-expander-SHA256-128", msg: "", lenInBytes: 128,
+			uniformBytes: "af84c27ccfd45d41914fdff5df25293e221afc53d8ad2ac06d5e3e29485dadbee0d121587713a3e0dd4d5e69e93eb7cd4f5df4cd103e188cf60cb02edc3edf18eda8576c412b18ffb658e3dd6ec849469b979d444cf7b26911a08e63cf31f9dcc541708d3491184472c2c29bb749d4286b004ceb5ee6b9a7fa5b646c993f0ced"},
+		{dst: "QUUX-V01-CS02-with-expander-SHA256-128", msg: "abc", lenInBytes: 128,
+			uniformBytes: "abba86a6129e366fc877aab32fc4ffc70120d8996c88aee2fe4b32d6c7b6437a647e6c3163d40b76a73cf6a5674ef1d890f95b664ee0afa5359a5c4e07985635bbecbac65d747d3d2da7ec2b8221b17b0ca9dc8a1ac1c07ea6a1e60583e2cb00058e77b7b72a298425cd1b941ad4ec65e8afc50303a22c0f99b0509b4c895f40"},
+		{dst: "QUUX-V01-CS02-with-expander-SHA256-128", msg: "abcdef0123456789", lenInBytes: 128,
+			uniformBytes: "ef904a29bffc4cf9ee82832451c946ac3c8f8058ae97d8d629831a74c6572bd9ebd0df635cd1f208e2038e760c4994984ce73f0d55ea9f22af83ba4734569d4bc95e18350f740c07eef653cbb9f87910d833751825f0ebefa1abe5420bb52be14cf489b37fe1a72f7de2d10be453b2c9d9eb20c7e3f6edc5a60629178d9478df"},
+		{dst: "QUUX-V01-CS02-with-expander-SHA256-128", msg: "q128_" + strings.Repeat("q", 128), lenInBytes: 128,
+			uniformBytes: "80be107d0884f0d881bb460322f0443d38bd222db8bd0b0a5312a6fedb49c1bbd88fd75d8b9a09486c60123dfa1d73c1cc3169761b17476d3c6b7cbbd727acd0e2c942f4dd96ae3da5de368d26b32286e32de7e5a8cb2949f866a0b80c58116b29fa7fabb3ea7d520ee603e0c25bcaf0b9a5e92ec6a1fe4e0391d1cdbce8c68a"},
+		{dst: "QUUX-V01-CS02-with-expander-SHA256-128", msg: "a512_" + strings.Repeat("a", 512), lenInBytes: 128,
+			uniformBytes: "546aff5444b5b79aa6148bd81728704c32decb73a3ba76e9e75885cad9def1d06d6792f8a7d12794e90efed817d96920d728896a4510864370c207f99bd4a608ea121700ef01ed879745ee3e4ceef777eda6d9e5e38b90c86ea6fb0b36504ba4a45d22e86f6db5dd43d98a294bebb9125d5b794e9d2a81181066eb954966a487"},
+		{dst: longDST, msg: "", lenInBytes: 32,
+			uniformBytes: "e8dc0c8b686b7ef2074086fbdd2f30e3f8bfbd3bdf177f73f04b97ce618a3ed3"},
+		{dst: longDST, msg: "abc", lenInBytes: 32,
+			uniformBytes: "52dbf4f36cf560fca57dedec2ad924ee9c266341d8f3d6afe5171733b16bbb12"},
+		{dst: longDST, msg: "abcdef0123456789", lenInBytes: 32,
+			uniformBytes: "35387dcf22618f3728e6c686490f8b431f76550b0b2c61cbc1ce7001536f4521"},
+		{dst: longDST, msg: "q128_" + strings.Repeat("q", 128), lenInBytes: 32,
+			uniformBytes: "01b637612bb18e840028be900a833a74414140dde0c4754c198532c3a0ba42bc"},
+		{dst: longDST, msg: "a512_" + strings.Repeat("a", 512), lenInBytes: 32,
+			uniformBytes: "20cce7033cabc5460743180be6fa8aac5a103f56d481cf369a8accc0c374431b"},
+		{dst: longDST, msg: "", lenInBytes: 128,
+			uniformBytes: "14604d85432c68b757e485c8894db3117992fc57e0e136f71ad987f789a0abc287c47876978e2388a02af86b1e8d1342e5ce4f7aaa07a87321e691f6fba7e0072eecc1218aebb89fb14a0662322d5edbd873f0eb35260145cd4e64f748c5dfe60567e126604bcab1a3ee2dc0778102ae8a5cfd1429ebc0fa6bf1a53c36f55dfc"},
+		{dst: longDST, msg: "abc", lenInBytes: 128,
+			uniformBytes: "1a30a5e36fbdb87077552b9d18b9f0aee16e80181d5b951d0471d55b66684914aef87dbb3626eaabf5ded8cd0686567e503853e5c84c259ba0efc37f71c839da2129fe81afdaec7fbdc0ccd4c794727a17c0d20ff0ea55e1389d6982d1241cb8d165762dbc39fb0cee4474d2cbbd468a835ae5b2f20e4f959f56ab24cd6fe267"},
+		{dst: longDST, msg: "abcdef0123456789", lenInBytes: 128,
+			uniformBytes: "d2ecef3635d2397f34a9f86438d772db19ffe9924e28a1caf6f1c8f15603d4028f40891044e5c7e39ebb9b31339979ff33a4249206f67d4a1e7c765410bcd249ad78d407e303675918f20f26ce6d7027ed3774512ef5b00d816e51bfcc96c3539601fa48ef1c07e494bdc37054ba96ecb9dbd666417e3de289d4f424f502a982"},
+		{dst: longDST, msg: "q128_" + strings.Repeat("q", 128), lenInBytes: 128,
+			uniformBytes: "ed6e8c036df90111410431431a232d41a32c86e296c05d426e5f44e75b9a50d335b2412bc6c91e0a6dc131de09c43110d9180d0a70f0d6289cb4e43b05f7ee5e9b3f42a1fad0f31bac6a625b3b5c50e3a83316783b649e5ecc9d3b1d9471cb5024b7ccf40d41d1751a04ca0356548bc6e703fca02ab521b505e8e45600508d32"},
+		{dst: longDST, msg: "a512_" + strings.Repeat("a", 512), lenInBytes: 128,
+			uniformBytes: "78b53f2413f3c688f07732c10e5ced29a17c6a16f717179ffbe38d92d6c9ec296502eb9889af83a1928cd162e845b0d3c5424e83280fed3d10cffb2f8431f14e7a23f4c68819d40617589e4c41169d0b56e0e3535be1fd71fbb08bb70c5b5ffed953d6c14bf7618b35fc1f4c4b30538236b4b08c9fbf90462447a8ada60be495"},
+	}
+
+	for _, test := range tests {
+		uniformBytes, err := ExpandMessageXMD([]byte(test.msg), []byte(test.dst), test.lenInBytes)
+		assert.Nil(t, err)
+		assert.Equal(t, test.uniformBytes, hex.EncodeToString(uniformBytes))
+	}
+
+	_, err := ExpandMessageXMD([]byte("abc"), nil, 32)
+	assert.Equal(t, ErrExpandMessage, err)
+	_, err = ExpandMessageXMD([]byte("abc"), []byte("DST"), 0)
+	assert.Equal(t, ErrExpandMessage, err)
+	_, err = ExpandMessageXMD([]byte("abc"), []byte("DST"), 256*32)
+	assert.Equal(t, ErrExpandMessage, err)
+}
+
+// nolint: lll
+func TestHashToCurveG1(t *testing.T) {
+	if bpgroup.CurrentCurve() != bpgroup.BLS381 {
+		_, err := HashToCurveG1([]byte("abc"), []byte("DST"))
+		assert.Equal(t, ErrHashToCurveSuite, err)
+		return
+	}
+
+	dst := []byte("QUUX-V01-CS02-with-BLS12381G1_XMD:SHA-256_SSWU_RO_")
+	tests := []struct {
+		msg      string
+		u0, u1   string
+		q0x, q0y string
+		q1x, q1y string
+		px, py   string
+	}{
+		{
+			msg: "",
+			u0:  "0ba14bd907ad64a016293ee7c2d276b8eae71f25a4b941eece7b0d89f17f75cb3ae5438a614fb61d6835ad59f29c564f",
+			u1:  "019b9bd7979f12657976de2884c7cce192b82c177c80e0ec604436a7f538d231552f0d96d9f7babe5fa3b19b3ff25ac9",
+			q0x: "11a3cce7e1d90975990066b2f2643b9540fa40d6137780df4e753a8054d07580db3b7f1f03396333d4a359d1fe3766fe",
+			q0y: "0eeaf6d794e479e270da10fdaf768db4c96b650a74518fc67b04b03927754bac66f3ac720404f339ecdcc028afa091b7",
+			q1x: "160003aaf1632b13396dbad518effa00fff532f604de1a7fc2082ff4cb0afa2d63b2c32da1bef2bf6c5ca62dc6b72f9c",
+			q1y: "0d8bb2d14e20cf9f6036152ed386d79189415b6d015a20133acb4e019139b94e9c146aaad5817f866c95d609a361735e",
+			px:  "052926add2207b76ca4fa57a8734416c8dc95e24501772c814278700eed6d1e4e8cf62d9c09db0fac349612b759e79a1",
+			py:  "08ba738453bfed09cb546dbb0783dbb3a5f1f566ed67bb6be0e8c67e2e81a4cc68ee29813bb7994998f3eae0c9c6a265",
+		},
+		{
+			msg: "abc",
+			u0:  "0d921c33f2bad966478a03ca35d05719bdf92d347557ea166e5bba579eea9b83e9afa5c088573c2281410369fbd32951",
+			u1:  "003574a00b109ada2f26a37a91f9d1e740dffd8d69ec0c35e1e9f4652c7dba61123e9dd2e76c655d956e2b3462611139",
+			q0x: "125435adce8e1cbd1c803e7123f45392dc6e326d292499c2c45c5865985fd74fe8f042ecdeeec5ecac80680d04317d80",
+			q0y: "0e8828948c989126595ee30e4f7c931cbd6f4570735624fd25aef2fa41d3f79cfb4b4ee7b7e55a8ce013af2a5ba20bf2",
+			q1x: "11def93719829ecda3b46aa8c31fc3ac9c34b428982b898369608e4f042babee6c77ab9218aad5c87ba785481eff8ae4",
+			q1y: "0007c9cef122ccf2efd233d6eb9bfc680aa276652b0661f4f820a653cec1db7ff69899f8e52b8e92b025a12c822a6ce6",
+			px:  "03567bc5ef9c690c2ab2ecdf6a96ef1c139cc0b2f284dca0a9a7943388a49a3aee664ba5379a7655d3c68900be2f6903",
+			py:  "0b9c15f3fe6e5cf4211f346271d7b01c8f3b28be689c8429c85b67af215533311f0b8dfaaa154fa6b88176c229f2885d",
+		},
+		{
+			msg: "abcdef0123456789",
+			u0:  "062d1865eb80ebfa73dcfc45db1ad4266b9f3a93219976a3790ab8d52d3e5f1e62f3b01795e36834b17b70e7b76246d4",
+			u1:  "0cdc3e2f271f29c4ff75020857ce6c5d36008c9b48385ea2f2bf6f96f428a3deb798aa033cd482d1cdc8b30178b08e3a",
+			q0x: "08834484878c217682f6d09a4b51444802fdba3d7f2df9903a0ddadb92130ebbfa807fffa0eabf257d7b48272410afff",
+			q0y: "0b318f7ecf77f45a0f038e62d7098221d2dbbca2a394164e2e3fe953dc714ac2cde412d8f2d7f0c03b259e6795a2508e",
+			q1x: "158418ed6b27e2549f05531a8281b5822b31c3bf3144277fbb977f8d6e2694fedceb7011b3c2b192f23e2a44b2bd106e",
+			q1y: "1879074f344471fac5f839e2b4920789643c075792bec5af4282c73f7941cda5aa77b00085eb10e206171b9787c4169f",
+			px:  "11e0b079dea29a68f0383ee94fed1b940995272407e3bb916bbf268c263ddd57a6a27200a784cbc248e84f357ce82d98",
+			py:  "03a87ae2caf14e8ee52e51fa2ed8eefe80f02457004ba4d486d6aa1f517c0889501dc7413753f9599b099ebcbbd2d709",
+		},
+		{
+			msg: "q128_" + strings.Repeat("q", 128),
+			u0:  "010476f6a060453c0b1ad0b628f3e57c23039ee16eea5e71bb87c3b5419b1255dc0e5883322e563b84a29543823c0e86",
+			u1:  "0b1a912064fb0554b180e07af7e787f1f883a0470759c03c1b6509eb8ce980d1670305ae7b928226bb58fdc0a419f46e",
+			q0x: "0cbd7f84ad2c99643fea7a7ac8f52d63d66cefa06d9a56148e58b984b3dd25e1f41ff47154543343949c64f88d48a710",
+			q0y: "052c00e4ed52d000d94881a5638ae9274d3efc8bc77bc0e5c650de04a000b2c334a9e80b85282a00f3148dfdface0865",
+			q1x: "06493fb68f0d513af08be0372f849436a787e7b701ae31cb964d968021d6ba6bd7d26a38aaa5a68e8c21a6b17dc8b579",
+			q1y: "02e98f2ccf5802b05ffaac7c20018bc0c0b2fd580216c4aa2275d2909dc0c92d0d0bdc979226adeb57a29933536b6bb4",
+			px:  "15f68eaa693b95ccb85215dc65fa81038d69629f70aeee0d0f677cf22285e7bf58d7cb86eefe8f2e9bc3f8cb84fac488",
+			py:  "1807a1d50c29f430b8cafc4f8638dfeeadf51211e1602a5f184443076715f91bb90a48ba1e370edce6ae1062f5e6dd38",
+		},
+		{
+			msg: "a512_" + strings.Repeat("a", 512),
+			u0:  "0a8ffa7447f6be1c5a2ea4b959c9454b431e29ccc0802bc052413a9c5b4f9aac67a93431bd480d15be1e057c8a08e8c6",
+			u1:  "05d487032f602c90fa7625dbafe0f4a49ef4a6b0b33d7bb349ff4cf5410d297fd6241876e3e77b651cfc8191e40a68b7",
+			q0x: "0cf97e6dbd0947857f3e578231d07b309c622ade08f2c08b32ff372bd90db19467b2563cc997d4407968d4ac80e154f8",
+			q0y: "127f0cddf2613058101a5701f4cb9d0861fd6c2a1b8e0afe194fccf586a3201a53874a2761a9ab6d7220c68661a35ab3",
+			q1x: "092f1acfa62b05f95884c6791fba989bbe58044ee6355d100973bf9553ade52b47929264e6ae770fb264582d8dce512a",
+			q1y: "028e6d0169a72cfedb737be45db6c401d3adfb12c58c619c82b93a5dfcccef12290de530b0480575ddc8397cda0bbebf",
+			px:  "082aabae8b7dedb0e78aeb619ad3bfd9277a2f77ba7fad20ef6aabdc6c31d19ba5a6d12283553294c1825c4b3ca2dcfe",
+			py:  "05b84ae5a942248eea39e1d91030458c40153f3b654ab7872d779ad1e942856a20c438e8d99bc8abfbf74729ce1f7ac8",
+		},
+	}
+
+	toHex := func(x *Curve.BIG) string {
+		return hex.EncodeToString(BIGToBytes(x))
+	}
+
+	for _, test := range tests {
+		us, err := HashToFieldFp([]byte(test.msg), dst, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, test.u0, toHex(us[0]))
+		assert.Equal(t, test.u1, toHex(us[1]))
+
+		Q0, err := MapToCurveG1(us[0])
+		assert.Nil(t, err)
+		assert.Equal(t, test.q0x, toHex(Q0.GetX()))
+		assert.Equal(t, test.q0y, toHex(Q0.GetY()))
+
+		Q1, err := MapToCurveG1(us[1])
+		assert.Nil(t, err)
+		assert.Equal(t, test.q1x, toHex(Q1.GetX()))
+		assert.Equal(t, test.q1y, toHex(Q1.GetY()))
+
+		P, err := HashToCurveG1([]byte(test.msg), dst)
+		assert.Nil(t, err)
+		assert.Equal(t, test.px, toHex(P.GetX()))
+		assert.Equal(t, test.py, toHex(P.GetY()))
+		assert.Nil(t, ValidateECP(P))
+	}
+}
+
+func TestHashToScalar(t *testing.T) {
+	order := Curve.NewBIGints(Curve.CURVE_Order)
+
+	x1, err := HashToScalar([]byte("foo"), []byte("DST-1"))
+	assert.Nil(t, err)
+	x2, err := HashToScalar([]byte("foo"), []byte("DST-1"))
+	assert.Nil(t, err)
+	x3, err := HashToScalar([]byte("foo"), []byte("DST-2"))
+	assert.Nil(t, err)
+
+	assert.Zero(t, Curve.Comp(x1, x2))
+	assert.NotZero(t, Curve.Comp(x1, x3), "Different domain separation tags should result in different scalars")
+	assert.True(t, Curve.Comp(x1, order) < 0)
+	assert.True(t, Curve.Comp(x3, order) < 0)
+
+	_, err = HashToScalar([]byte("foo"), nil)
+	assert.Equal(t, ErrExpandMessage, err)
+}
@@ -0,0 +1,414 @@
+// hashtog2.go - hashing to the G2 curve
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"math/big"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// Hashing to G2 follows the hash_to_curve construction of RFC 9380 on both supported curves:
+// the message is hashed to two elements of the quadratic extension field, each of them is mapped
+// to a point on the twisted curve, the points are added and the cofactor of the result is cleared.
+// On BLS12-381 it is the BLS12381G2_XMD:SHA-256_SSWU_RO_ suite. The RFC does not define a suite
+// for the BN254 curve of amcl, so there the generic Shallue-van de Woestijne map is used instead,
+// with Z chosen as described in appendix H.1 of the RFC.
+
+const (
+	// G2SuiteIDBLS381 is the identifier of the suite used for hashing to G2 on BLS12-381.
+	G2SuiteIDBLS381 = "BLS12381G2_XMD:SHA-256_SSWU_RO_"
+
+	// G2SuiteIDBN254 is the identifier of the suite used for hashing to G2 on BN254.
+	G2SuiteIDBN254 = "BN254G2_XMD:SHA-256_SVDW_RO_"
+)
+
+// fp2 is an element a + b * i of the quadratic extension of the base field, where i^2 = -1.
+// It is the case for both supported curves.
+type fp2 struct {
+	a, b *big.Int
+}
+
+// Parameters of the simplified SWU map to the curve E' isogenous to the G2 curve of BLS12-381:
+// E': y^2 = x^3 + A' * x + B', and of the 3-isogeny from E' to the G2 curve.
+// The coefficients of the isogeny polynomials are ordered by increasing degree.
+var (
+	g2SSWUZ = fp2FromHex("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaa9",
+		"1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaaa")
+	g2IsoA = fp2FromHex("0", "f0")
+	g2IsoB = fp2FromHex("3f4", "3f4")
+
+	g2IsoXNum = []*fp2{
+		fp2FromHex("05c759507e8e333ebb5b7a9a47d7ed8532c52d39fd3a042a88b58423c50ae15d5c2638e343d9c71c6238aaaaaaaa97d6",
+			"05c759507e8e333ebb5b7a9a47d7ed8532c52d39fd3a042a88b58423c50ae15d5c2638e343d9c71c6238aaaaaaaa97d6"),
+		fp2FromHex("0",
+			"11560bf17baa99bc32126fced787c88f984f87adf7ae0c7f9a208c6b4f20a4181472aaa9cb8d555526a9ffffffffc71a"),
+		fp2FromHex("11560bf17baa99bc32126fced787c88f984f87adf7ae0c7f9a208c6b4f20a4181472aaa9cb8d555526a9ffffffffc71e",
+			"08ab05f8bdd54cde190937e76bc3e447cc27c3d6fbd7063fcd104635a790520c0a395554e5c6aaaa9354ffffffffe38d"),
+		fp2FromHex("171d6541fa38ccfaed6dea691f5fb614cb14b4e7f4e810aa22d6108f142b85757098e38d0f671c7188e2aaaaaaaa5ed1",
+			"0"),
+	}
+	g2IsoXDen = []*fp2{
+		fp2FromHex("0",
+			"1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaa63"),
+		fp2FromHex("c",
+			"1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaa9f"),
+		fp2FromHex("1", "0"),
+	}
+	g2IsoYNum = []*fp2{
+		fp2FromHex("1530477c7ab4113b59a4c18b076d11930f7da5d4a07f649bf54439d87d27e500fc8c25ebf8c92f6812cfc71c71c6d706",
+			"1530477c7ab4113b59a4c18b076d11930f7da5d4a07f649bf54439d87d27e500fc8c25ebf8c92f6812cfc71c71c6d706"),
+		fp2FromHex("0",
+			"05c759507e8e333ebb5b7a9a47d7ed8532c52d39fd3a042a88b58423c50ae15d5c2638e343d9c71c6238aaaaaaaa97be"),
+		fp2FromHex("11560bf17baa99bc32126fced787c88f984f87adf7ae0c7f9a208c6b4f20a4181472aaa9cb8d555526a9ffffffffc71c",
+			"08ab05f8bdd54cde190937e76bc3e447cc27c3d6fbd7063fcd104635a790520c0a395554e5c6aaaa9354ffffffffe38f"),
+		fp2FromHex("124c9ad43b6cf79bfbf7043de3811ad0761b0f37a1e26286b0e977c69aa274524e79097a56dc4bd9e1b371c71c718b10",
+			"0"),
+	}
+	g2IsoYDen = []*fp2{
+		fp2FromHex("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffa8fb",
+			"1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffa8fb"),
+		fp2FromHex("0",
+			"1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffa9d3"),
+		fp2FromHex("12",
+			"1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaa99"),
+		fp2FromHex("1", "0"),
+	}
+
+	// g2HEffBLS381 is the scalar used for clearing the cofactor of points on the G2 curve of BLS12-381.
+	g2HEffBLS381 = bigFromHex("0bc69f08f2ee75b3584c6a0ea91b352888e2a8e9145ad7689986ff031508ffe1329c2f178731db956d82bf015d1212b02ec0ec69d7477c1ae954cbc06689f6a359894c0adebbf6b4e8020005aaa95551")
+)
+
+// svdwParams are the parameters of the Shallue-van de Woestijne map to the curve y^2 = x^3 + B,
+// as defined in section 6.6.1 of RFC 9380 for A = 0.
+type svdwParams struct {
+	b, z, c1, c2, c3, c4 *fp2
+}
+
+// g2SVDW holds the parameters of the map to the G2 curve of BN254. It is nil on BLS12-381.
+var g2SVDW *svdwParams
+
+func init() {
+	if bpgroup.CurrentCurve() == bpgroup.BN254 {
+		g2SVDW = newSVDWParams(fp2FromFP2(Curve.RHS2(Curve.NewFP2int(0))))
+	}
+}
+
+func fp2FromHex(a, b string) *fp2 {
+	return &fp2{a: bigFromHex(a), b: bigFromHex(b)}
+}
+
+func fp2FromInt(x int64) *fp2 {
+	return &fp2{a: new(big.Int).Mod(big.NewInt(x), fieldP), b: new(big.Int)}
+}
+
+// fp2FromFP2 converts FP2 element to its math/big representation.
+func fp2FromFP2(x *Curve.FP2) *fp2 {
+	return &fp2{a: bigFromBIG(x.GetA()), b: bigFromBIG(x.GetB())}
+}
+
+// toFP2 converts the element to FP2.
+func (x *fp2) toFP2() *Curve.FP2 {
+	return Curve.NewFP2bigs(bigToBIG(x.a), bigToBIG(x.b))
+}
+
+func (x *fp2) isZero() bool {
+	return x.a.Sign() == 0 && x.b.Sign() == 0
+}
+
+func (x *fp2) equals(y *fp2) bool {
+	return x.a.Cmp(y.a) == 0 && x.b.Cmp(y.b) == 0
+}
+
+func fpSub(a, b *big.Int) *big.Int {
+	return fpAdd(a, fpNeg(b))
+}
+
+func fp2Add(x, y *fp2) *fp2 {
+	return &fp2{a: fpAdd(x.a, y.a), b: fpAdd(x.b, y.b)}
+}
+
+func fp2Sub(x, y *fp2) *fp2 {
+	return &fp2{a: fpSub(x.a, y.a), b: fpSub(x.b, y.b)}
+}
+
+func fp2Neg(x *fp2) *fp2 {
+	return &fp2{a: fpNeg(x.a), b: fpNeg(x.b)}
+}
+
+func fp2Mul(x, y *fp2) *fp2 {
+	return &fp2{
+		a: fpSub(fpMul(x.a, y.a), fpMul(x.b, y.b)),
+		b: fpAdd(fpMul(x.a, y.b), fpMul(x.b, y.a)),
+	}
+}
+
+// fp2Inv0 returns the inverse of x, or 0 if x is 0.
+func fp2Inv0(x *fp2) *fp2 {
+	normInv := fpInv0(fpAdd(fpMul(x.a, x.a), fpMul(x.b, x.b)))
+	return &fp2{a: fpMul(x.a, normInv), b: fpNeg(fpMul(x.b, normInv))}
+}
+
+// fp2Sqrt returns a square root of x and whether it exists.
+// It uses the complex method, i.e. it reduces the problem to square roots in the base field.
+func fp2Sqrt(x *fp2) (*fp2, bool) {
+	if x.b.Sign() == 0 {
+		if r, ok := fpSqrt(x.a); ok {
+			return &fp2{a: r, b: new(big.Int)}, true
+		}
+		// (r * i)^2 = -r^2
+		r, ok := fpSqrt(fpNeg(x.a))
+		return &fp2{a: new(big.Int), b: r}, ok
+	}
+	s, ok := fpSqrt(fpAdd(fpMul(x.a, x.a), fpMul(x.b, x.b)))
+	if !ok {
+		return nil, false
+	}
+	halfInv := fpInv0(big.NewInt(2))
+	r0, ok := fpSqrt(fpMul(fpAdd(x.a, s), halfInv))
+	if !ok {
+		r0, ok = fpSqrt(fpMul(fpSub(x.a, s), halfInv))
+		if !ok {
+			return nil, false
+		}
+	}
+	// r0 is non-zero as otherwise x.b would be zero
+	r1 := fpMul(x.b, fpInv0(fpAdd(r0, r0)))
+	return &fp2{a: r0, b: r1}, true
+}
+
+// fp2Sgn0 implements sgn0 for elements of the quadratic extension field.
+func fp2Sgn0(x *fp2) uint {
+	if x.a.Sign() == 0 {
+		return fpSgn0(x.b)
+	}
+	return fpSgn0(x.a)
+}
+
+// fp2Poly evaluates polynomial with the given coefficients ordered by increasing degree at x.
+func fp2Poly(coeffs []*fp2, x *fp2) *fp2 {
+	r := coeffs[len(coeffs)-1]
+	for i := len(coeffs) - 2; i >= 0; i-- {
+		r = fp2Add(fp2Mul(r, x), coeffs[i])
+	}
+	return r
+}
+
+// hashToFieldFp2 hashes msg to count elements of the quadratic extension field using hash_to_field.
+func hashToFieldFp2(msg []byte, dst []byte, count int) ([]*fp2, error) {
+	// the elements are derived from consecutive chunks of the expanded message,
+	// hence it is equivalent to hashing to twice as many elements of the base field
+	es, err := hashToField(msg, dst, 2*count, fieldP)
+	if err != nil {
+		return nil, err
+	}
+	us := make([]*fp2, count)
+	for i := range us {
+		us[i] = &fp2{a: es[2*i], b: es[2*i+1]}
+	}
+	return us, nil
+}
+
+// sswuG2 maps u to a point on E' using the simplified SWU method described in section 6.6.2 of RFC 9380.
+func sswuG2(u *fp2) (*fp2, *fp2) {
+	curve := func(x *fp2) *fp2 {
+		// x^3 + A' * x + B'
+		return fp2Add(fp2Mul(fp2Add(fp2Mul(x, x), g2IsoA), x), g2IsoB)
+	}
+
+	zu2 := fp2Mul(g2SSWUZ, fp2Mul(u, u))
+	tv1 := fp2Inv0(fp2Add(fp2Mul(zu2, zu2), zu2)) // 1 / (Z^2 * u^4 + Z * u^2)
+
+	var x1 *fp2
+	if tv1.isZero() {
+		x1 = fp2Mul(g2IsoB, fp2Inv0(fp2Mul(g2SSWUZ, g2IsoA))) // B' / (Z * A')
+	} else {
+		x1 = fp2Mul(fp2Neg(fp2Mul(g2IsoB, fp2Inv0(g2IsoA))), fp2Add(fp2FromInt(1), tv1)) // (-B' / A') * (1 + tv1)
+	}
+
+	x := x1
+	y, ok := fp2Sqrt(curve(x1))
+	if !ok {
+		x = fp2Mul(zu2, x1)
+		// if gx1 is not square, gx2 is
+		y, _ = fp2Sqrt(curve(x))
+	}
+	if fp2Sgn0(u) != fp2Sgn0(y) {
+		y = fp2Neg(y)
+	}
+	return x, y
+}
+
+// isoMapG2 maps the point on E' to the G2 curve using the 3-isogeny.
+// It returns nil if the result is the point at infinity.
+func isoMapG2(x, y *fp2) (*fp2, *fp2) {
+	xDen := fp2Poly(g2IsoXDen, x)
+	yDen := fp2Poly(g2IsoYDen, x)
+	if xDen.isZero() || yDen.isZero() {
+		return nil, nil
+	}
+	xr := fp2Mul(fp2Poly(g2IsoXNum, x), fp2Inv0(xDen))
+	yr := fp2Mul(y, fp2Mul(fp2Poly(g2IsoYNum, x), fp2Inv0(yDen)))
+	return xr, yr
+}
+
+// newSVDWParams finds Z for the curve y^2 = x^3 + b as described in appendix H.1 of RFC 9380
+// and derives the constants of the Shallue-van de Woestijne map.
+func newSVDWParams(b *fp2) *svdwParams {
+	g := func(x *fp2) *fp2 {
+		return fp2Add(fp2Mul(fp2Mul(x, x), x), b)
+	}
+	// h(Z) = -(3 * Z^2) / (4 * g(Z))
+	h := func(z *fp2) *fp2 {
+		return fp2Neg(fp2Mul(fp2Mul(fp2FromInt(3), fp2Mul(z, z)), fp2Inv0(fp2Mul(fp2FromInt(4), g(z)))))
+	}
+	isSquare := func(x *fp2) bool {
+		_, ok := fp2Sqrt(x)
+		return ok
+	}
+	halfInv := fp2Inv0(fp2FromInt(2))
+
+	var z *fp2
+	for ctr := int64(1); z == nil; ctr++ {
+		for _, cand := range []*fp2{fp2FromInt(ctr), fp2FromInt(-ctr)} {
+			if g(cand).isZero() || h(cand).isZero() || !isSquare(h(cand)) {
+				continue
+			}
+			if isSquare(g(cand)) || isSquare(g(fp2Neg(fp2Mul(cand, halfInv)))) {
+				z = cand
+				break
+			}
+		}
+	}
+
+	gz := g(z)
+	threeZ2 := fp2Mul(fp2FromInt(3), fp2Mul(z, z))
+	// -g(Z) * 3 * Z^2 is a square due to the choice of Z
+	c3, _ := fp2Sqrt(fp2Neg(fp2Mul(gz, threeZ2)))
+	if fp2Sgn0(c3) == 1 {
+		c3 = fp2Neg(c3)
+	}
+	return &svdwParams{
+		b:  b,
+		z:  z,
+		c1: gz,
+		c2: fp2Neg(fp2Mul(z, halfInv)),
+		c3: c3,
+		c4: fp2Neg(fp2Mul(fp2Mul(fp2FromInt(4), gz), fp2Inv0(threeZ2))),
+	}
+}
+
+// svdw maps u to a point on the curve using the Shallue-van de Woestijne method
+// described in section 6.6.1 of RFC 9380.
+func (sp *svdwParams) svdw(u *fp2) (*fp2, *fp2) {
+	g := func(x *fp2) *fp2 {
+		return fp2Add(fp2Mul(fp2Mul(x, x), x), sp.b)
+	}
+
+	tv1 := fp2Mul(fp2Mul(u, u), sp.c1)
+	tv2 := fp2Add(fp2FromInt(1), tv1)
+	tv1 = fp2Sub(fp2FromInt(1), tv1)
+	tv3 := fp2Inv0(fp2Mul(tv1, tv2))
+	tv4 := fp2Mul(fp2Mul(fp2Mul(u, tv1), tv3), sp.c3)
+
+	x := fp2Sub(sp.c2, tv4)
+	y, ok := fp2Sqrt(g(x))
+	if !ok {
+		x = fp2Add(sp.c2, tv4)
+		y, ok = fp2Sqrt(g(x))
+	}
+	if !ok {
+		// (tv2^2 * tv3)^2 * c4 + Z, g of which is guaranteed to be square
+		x3 := fp2Mul(fp2Mul(tv2, tv2), tv3)
+		x = fp2Add(fp2Mul(fp2Mul(x3, x3), sp.c4), sp.z)
+		y, _ = fp2Sqrt(g(x))
+	}
+	if fp2Sgn0(u) != fp2Sgn0(y) {
+		y = fp2Neg(y)
+	}
+	return x, y
+}
+
+// mapToCurveG2 maps an element of the quadratic extension field to a point on the G2 curve,
+// which is not necessarily in the prime order subgroup.
+func mapToCurveG2(u *fp2) *Curve.ECP2 {
+	var x, y *fp2
+	if bpgroup.CurrentCurve() == bpgroup.BLS381 {
+		x, y = isoMapG2(sswuG2(u))
+		if x == nil {
+			return Curve.NewECP2()
+		}
+	} else {
+		x, y = g2SVDW.svdw(u)
+	}
+	return Curve.NewECP2fp2s(x.toFP2(), y.toFP2())
+}
+
+// mulECP2 multiplies P by a non-negative scalar k, which, unlike for ECP2.Mul, can exceed the size of BIG.
+func mulECP2(P *Curve.ECP2, k *big.Int) *Curve.ECP2 {
+	// the scalar is processed in chunks that safely fit in BIG
+	chunkBits := uint(8 * (BIGLen - 1))
+	shift := bigToBIG(new(big.Int).Lsh(big.NewInt(1), chunkBits))
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), chunkBits), big.NewInt(1))
+
+	R := Curve.NewECP2()
+	for i := (k.BitLen() - 1) / int(chunkBits); i >= 0; i-- {
+		R = R.Mul(shift)
+		chunk := new(big.Int).Rsh(k, uint(i)*chunkBits)
+		R.Add(P.Mul(bigToBIG(chunk.And(chunk, mask))))
+	}
+	return R
+}
+
+// clearCofactorG2 maps the point on the G2 curve to its prime order subgroup.
+func clearCofactorG2(P *Curve.ECP2) *Curve.ECP2 {
+	if bpgroup.CurrentCurve() == bpgroup.BLS381 {
+		return mulECP2(P, g2HEffBLS381)
+	}
+	// the order of the twist of BN curve is r * (2p - r), where the cofactor is coprime with r
+	h := new(big.Int).Sub(new(big.Int).Lsh(fieldP, 1), fieldR)
+	return mulECP2(P, h)
+}
+
+// G2SuiteID returns the identifier of the suite used by HashBytesToG2 on the current curve.
+// It is meant to be used as the suffix of domain separation tags.
+func G2SuiteID() string {
+	if bpgroup.CurrentCurve() == bpgroup.BLS381 {
+		return G2SuiteIDBLS381
+	}
+	return G2SuiteIDBN254
+}
+
+// HashStringToG2 takes a string message and maps it to a point in the prime order subgroup of G2 Curve
+// with the domain separation tag dst.
+func HashStringToG2(m string, dst []byte) (*Curve.ECP2, error) {
+	return HashBytesToG2([]byte(m), dst)
+}
+
+// HashBytesToG2 takes a bytes message and maps it to a point in the prime order subgroup of G2 Curve
+// with the domain separation tag dst using the suite identified by G2SuiteID.
+// Points derived with different tags are independent, hence each application should use a distinct tag.
+func HashBytesToG2(b []byte, dst []byte) (*Curve.ECP2, error) {
+	us, err := hashToFieldFp2(b, dst, 2)
+	if err != nil {
+		return nil, err
+	}
+	Q := mapToCurveG2(us[0])
+	Q.Add(mapToCurveG2(us[1]))
+	return clearCofactorG2(Q), nil
+}
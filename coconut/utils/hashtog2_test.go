@@ -0,0 +1,238 @@
+// hashtog2_test.go - tests of hashing to the G2 curve
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package utils
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+func fp2ToHex(x *Curve.FP2) [2]string {
+	return [2]string{hex.EncodeToString(BIGToBytes(x.GetA())), hex.EncodeToString(BIGToBytes(x.GetB()))}
+}
+
+// The test vectors come from appendix J.10.1 of RFC 9380.
+// nolint: lll
+func TestHashBytesToG2BLS381(t *testing.T) {
+	if bpgroup.CurrentCurve() != bpgroup.BLS381 {
+		t.Skip("The test vectors are defined for BLS12-381")
+	}
+
+	dst := []byte("QUUX-V01-CS02-with-" + G2SuiteIDBLS381)
+	tests := []struct {
+		msg    string
+		u0, u1 [2]string
+		px, py [2]string
+	}{
+		{
+			msg: "",
+			u0: [2]string{"03dbc2cce174e91ba93cbb08f26b917f98194a2ea08d1cce75b2b9cc9f21689d80bd79b594a613d0a68eb807dfdc1cf8",
+				"05a2acec64114845711a54199ea339abd125ba38253b70a92c876df10598bd1986b739cad67961eb94f7076511b3b39a"},
+			u1: [2]string{"02f99798e8a5acdeed60d7e18e9120521ba1f47ec090984662846bc825de191b5b7641148c0dbc237726a334473eee94",
+				"145a81e418d4010cc027a68f14391b30074e89e60ee7a22f87217b2f6eb0c4b94c9115b436e6fa4607e95a98de30a435"},
+			px: [2]string{"0141ebfbdca40eb85b87142e130ab689c673cf60f1a3e98d69335266f30d9b8d4ac44c1038e9dcdd5393faf5c41fb78a",
+				"05cb8437535e20ecffaef7752baddf98034139c38452458baeefab379ba13dff5bf5dd71b72418717047f5b0f37da03d"},
+			py: [2]string{"0503921d7f6a12805e72940b963c0cf3471c7b2a524950ca195d11062ee75ec076daf2d4bc358c4b190c0c98064fdd92",
+				"12424ac32561493f3fe3c260708a12b7c620e7be00099a974e259ddc7d1f6395c3c811cdd19f1e8dbf3e9ecfdcbab8d6"},
+		},
+		{
+			msg: "abc",
+			u0: [2]string{"15f7c0aa8f6b296ab5ff9c2c7581ade64f4ee6f1bf18f55179ff44a2cf355fa53dd2a2158c5ecb17d7c52f63e7195771",
+				"01c8067bf4c0ba709aa8b9abc3d1cef589a4758e09ef53732d670fd8739a7274e111ba2fcaa71b3d33df2a3a0c8529dd"},
+			u1: [2]string{"187111d5e088b6b9acfdfad078c4dacf72dcd17ca17c82be35e79f8c372a693f60a033b461d81b025864a0ad051a06e4",
+				"08b852331c96ed983e497ebc6dee9b75e373d923b729194af8e72a051ea586f3538a6ebb1e80881a082fa2b24df9f566"},
+			px: [2]string{"02c2d18e033b960562aae3cab37a27ce00d80ccd5ba4b7fe0e7a210245129dbec7780ccc7954725f4168aff2787776e6",
+				"139cddbccdc5e91b9623efd38c49f81a6f83f175e80b06fc374de9eb4b41dfe4ca3a230ed250fbe3a2acf73a41177fd8"},
+			py: [2]string{"1787327b68159716a37440985269cf584bcb1e621d3a7202be6ea05c4cfe244aeb197642555a0645fb87bf7466b2ba48",
+				"00aa65dae3c8d732d10ecd2c50f8a1baf3001578f71c694e03866e9f3d49ac1e1ce70dd94a733534f106d4cec0eddd16"},
+		},
+		{
+			msg: "abcdef0123456789",
+			u0: [2]string{"0313d9325081b415bfd4e5364efaef392ecf69b087496973b229303e1816d2080971470f7da112c4eb43053130b785e1",
+				"062f84cb21ed89406890c051a0e8b9cf6c575cf6e8e18ecf63ba86826b0ae02548d83b483b79e48512b82a6c0686df8f"},
+			u1: [2]string{"1739123845406baa7be5c5dc74492051b6d42504de008c635f3535bb831d478a341420e67dcc7b46b2e8cba5379cca97",
+				"01897665d9cb5db16a27657760bbea7951f67ad68f8d55f7113f24ba6ddd82caef240a9bfa627972279974894701d975"},
+			px: [2]string{"121982811d2491fde9ba7ed31ef9ca474f0e1501297f68c298e9f4c0028add35aea8bb83d53c08cfc007c1e005723cd0",
+				"190d119345b94fbd15497bcba94ecf7db2cbfd1e1fe7da034d26cbba169fb3968288b3fafb265f9ebd380512a71c3f2c"},
+			py: [2]string{"05571a0f8d3c08d094576981f4a3b8eda0a8e771fcdcc8ecceaf1356a6acf17574518acb506e435b639353c2e14827c8",
+				"0bb5e7572275c567462d91807de765611490205a941a5a6af3b1691bfe596c31225d3aabdf15faff860cb4ef17c7c3be"},
+		},
+		{
+			msg: "q128_" + strings.Repeat("q", 128),
+			u0: [2]string{"025820cefc7d06fd38de7d8e370e0da8a52498be9b53cba9927b2ef5c6de1e12e12f188bbc7bc923864883c57e49e253",
+				"034147b77ce337a52e5948f66db0bab47a8d038e712123bb381899b6ab5ad20f02805601e6104c29df18c254b8618c7b"},
+			u1: [2]string{"0930315cae1f9a6017c3f0c8f2314baa130e1cf13f6532bff0a8a1790cd70af918088c3db94bda214e896e1543629795",
+				"10c4df2cacf67ea3cb3108b00d4cbd0b3968031ebc8eac4b1ebcefe84d6b715fde66bef0219951ece29d1facc8a520ef"},
+			px: [2]string{"19a84dd7248a1066f737cc34502ee5555bd3c19f2ecdb3c7d9e24dc65d4e25e50d83f0f77105e955d78f4762d33c17da",
+				"0934aba516a52d8ae479939a91998299c76d39cc0c035cd18813bec433f587e2d7a4fef038260eef0cef4d02aae3eb91"},
+			py: [2]string{"14f81cd421617428bc3b9fe25afbb751d934a00493524bc4e065635b0555084dd54679df1536101b2c979c0152d09192",
+				"09bcccfa036b4847c9950780733633f13619994394c23ff0b32fa6b795844f4a0673e20282d07bc69641cee04f5e5662"},
+		},
+		{
+			msg: "a512_" + strings.Repeat("a", 512),
+			u0: [2]string{"190b513da3e66fc9a3587b78c76d1d132b1152174d0b83e3c1114066392579a45824c5fa17649ab89299ddd4bda54935",
+				"12ab625b0fe0ebd1367fe9fac57bb1168891846039b4216b9d94007b674de2d79126870e88aeef54b2ec717a887dcf39"},
+			u1: [2]string{"0e6a42010cf435fb5bacc156a585e1ea3294cc81d0ceb81924d95040298380b164f702275892cedd81b62de3aba3f6b5",
+				"117d9a0defc57a33ed208428cb84e54c85a6840e7648480ae428838989d25d97a0af8e3255be62b25c2a85630d2dddd8"},
+			px: [2]string{"01a6ba2f9a11fa5598b2d8ace0fbe0a0eacb65deceb476fbbcb64fd24557c2f4b18ecfc5663e54ae16a84f5ab7f62534",
+				"11fca2ff525572795a801eed17eb12785887c7b63fb77a42be46ce4a34131d71f7a73e95fee3f812aea3de78b4d01569"},
+			py: [2]string{"0b6798718c8aed24bc19cb27f866f1c9effcdbf92397ad6448b5c9db90d2b9da6cbabf48adc1adf59a1a28344e79d57e",
+				"03a47f8e6d1763ba0cad63d6114c0accbef65707825a511b251a660a9b3994249ae4e63fac38b23da0c398689ee2ab52"},
+		},
+	}
+
+	for _, test := range tests {
+		us, err := hashToFieldFp2([]byte(test.msg), dst, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, test.u0, fp2ToHex(us[0].toFP2()))
+		assert.Equal(t, test.u1, fp2ToHex(us[1].toFP2()))
+
+		P, err := HashBytesToG2([]byte(test.msg), dst)
+		assert.Nil(t, err)
+		assert.Equal(t, test.px, fp2ToHex(P.GetX()))
+		assert.Equal(t, test.py, fp2ToHex(P.GetY()))
+	}
+}
+
+// There is no standard suite for BN254 of amcl, so the values were generated by this implementation
+// and serve as regression tests.
+// nolint: lll
+func TestHashBytesToG2BN254(t *testing.T) {
+	if bpgroup.CurrentCurve() != bpgroup.BN254 {
+		t.Skip("The test vectors are defined for BN254")
+	}
+
+	// Z found as in appendix H.1 of RFC 9380
+	assert.True(t, g2SVDW.z.equals(fp2FromInt(-1)))
+
+	dst := []byte("QUUX-V01-CS02-with-" + G2SuiteIDBN254)
+	tests := []struct {
+		msg    string
+		px, py [2]string
+	}{
+		{
+			msg: "",
+			px: [2]string{"0657b6cab275ebe52c2dc0b1d1dc19932803581adf03995af408db3e6fb4f82d",
+				"1eea15d0380b7463c01447958f2aced0ae93fe06535ae19279e945002b5ca683"},
+			py: [2]string{"11748c87a87293e7500b4d4bf363f1d381cfe696521bec507f052782f8d43e91",
+				"067abc7a317d074e5d329f988ded0c116211c835a7cb67babbdd14f83e2c420e"},
+		},
+		{
+			msg: "abc",
+			px: [2]string{"1d03934156b2e97d7b1a0f6b8e6b5ed68df8ce8075446cd9720e756bf3b94a88",
+				"10219cdbbb03da8430f2c2230c723c7e7c5888853ae8d17cc68599e8b206a326"},
+			py: [2]string{"202f3c9d7970c5cdb55141332a258ea4bb77692f1e46b6fb7d9ecf7e6a39be63",
+				"162c422ebff651d1ecdc60800aefbb340644b1a24fa76794cf0a6181c049200a"},
+		},
+		{
+			msg: "abcdef0123456789",
+			px: [2]string{"20b3047b3e1c253faca2f07cbca8a3ceb63e04a45fe892f69398e7bb83675199",
+				"240b70b62924ee4ae384417ea936430b20a7d637aa91ca4e4bd6fe7e87827c6a"},
+			py: [2]string{"12f717471cb0d3824dadec79c056784cc7b303398bfefab54216788df17444ed",
+				"048544544a9093a5e3ddad375f1ec52c68105f1afafd91bee4c065b0e85e0209"},
+		},
+		{
+			msg: "q128_" + strings.Repeat("q", 128),
+			px: [2]string{"00b783bc9a9396787a685fde8b5272a922c1cd493d52da6e760500ac24e569b8",
+				"17df45a02bfcff0fbf501444f68836ea1489f105c37ceaec2568bd107f9f952b"},
+			py: [2]string{"19b120b712cc8b8ce6921ea30f5f976486a204cc717b5ffd6c394630fab13211",
+				"1e912ae38b3cd8257486004a77733942017fdddb8f36c95a5ae377402674a80a"},
+		},
+		{
+			msg: "a512_" + strings.Repeat("a", 512),
+			px: [2]string{"1c16737adb112be52f5fde5f0952a876db5f17bd6e89899af05373714c20a5f7",
+				"1e294a2e361ffa97dc763be3b2c01b451d1db3f1184bb9e13fe6d0dd80ff5e18"},
+			py: [2]string{"0fa2b8cd7a2b800152961497abb22aac2c8feff531185532e070e90cfc64c6a4",
+				"0a89fcc0e610c0f842bef32c6a9750fb13b0c2d0bc1951ed723b885ec6cd1210"},
+		},
+	}
+
+	for _, test := range tests {
+		P, err := HashBytesToG2([]byte(test.msg), dst)
+		assert.Nil(t, err)
+		assert.Equal(t, test.px, fp2ToHex(P.GetX()))
+		assert.Equal(t, test.py, fp2ToHex(P.GetY()))
+	}
+}
+
+func TestMapToCurveG2(t *testing.T) {
+	us, err := hashToFieldFp2([]byte("map to curve"), []byte("DST"), 16)
+	assert.Nil(t, err)
+	// include the exceptional cases of the maps
+	us = append(us, fp2FromInt(0), fp2FromInt(1), fp2FromInt(-1))
+
+	for _, u := range us {
+		// NewECP2fp2s returns the point at infinity if the coordinates do not satisfy the curve equation
+		P := mapToCurveG2(u)
+		assert.False(t, P.Is_infinity())
+		if bpgroup.CurrentCurve() == bpgroup.BN254 {
+			// on BLS12-381 the sign is chosen before applying the isogeny
+			assert.Equal(t, fp2Sgn0(u), fp2Sgn0(fp2FromFP2(P.GetY())))
+		}
+	}
+}
+
+func TestHashBytesToG2(t *testing.T) {
+	dst := []byte("COCONUT-TEST-with-" + G2SuiteID())
+
+	P1, err := HashBytesToG2([]byte("foo"), dst)
+	assert.Nil(t, err)
+	assert.Nil(t, ValidateECP2(P1))
+
+	P2, err := HashStringToG2("foo", dst)
+	assert.Nil(t, err)
+	assert.True(t, P1.Equals(P2))
+
+	P3, err := HashBytesToG2([]byte("bar"), dst)
+	assert.Nil(t, err)
+	assert.Nil(t, ValidateECP2(P3))
+	assert.False(t, P1.Equals(P3))
+
+	P4, err := HashBytesToG2([]byte("foo"), []byte("OTHER-DST"))
+	assert.Nil(t, err)
+	assert.Nil(t, ValidateECP2(P4))
+	assert.False(t, P1.Equals(P4), "Different domain separation tags should result in different points")
+
+	_, err = HashBytesToG2([]byte("foo"), nil)
+	assert.Equal(t, ErrExpandMessage, err)
+}
+
+func TestFp2Sqrt(t *testing.T) {
+	xs, err := hashToFieldFp2([]byte("sqrt"), []byte("DST"), 16)
+	assert.Nil(t, err)
+	xs = append(xs, fp2FromInt(0), fp2FromInt(-1), fp2FromInt(2), &fp2{a: new(big.Int), b: big.NewInt(3)})
+
+	// the norm of 1 + i is 2, which is not a square as p = 3 mod 8 for both curves
+	nonSquare := &fp2{a: big.NewInt(1), b: big.NewInt(1)}
+
+	for _, x := range xs {
+		x2 := fp2Mul(x, x)
+		r, ok := fp2Sqrt(x2)
+		assert.True(t, ok)
+		assert.True(t, fp2Mul(r, r).equals(x2))
+
+		// exactly one of x and its product with a non-square is a square
+		_, ok = fp2Sqrt(fp2Mul(x2, nonSquare))
+		assert.Equal(t, x.isZero(), ok)
+	}
+}
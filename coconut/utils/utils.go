@@ -29,9 +29,6 @@ import (
 
 var MB = int(Curve.MODBYTES)
 
-// todo: verify HashBytesToG1
-// todo: wait for George's change in bplib for hashG1 -> worst case scenario is to try to butcher and modify amcl fork
-
 // Printable is a wrapper for all objects that have ToString method. In particular Curve.ECP and Curve.ECP2.
 type Printable interface {
 	ToString() string
@@ -62,6 +59,42 @@ func ToCoconutString(p Printable) string {
 	}
 }
 
+// BIGFromCoconutString recovers BIG number from its string representation as returned by ToCoconutString.
+// Leading zeroes can be omitted and the hex digits can be of either case.
+func BIGFromCoconutString(s string) (*Curve.BIG, error) {
+	if len(s) == 0 {
+		return nil, ErrDecodeBIG
+	}
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) > BIGLen {
+		return nil, ErrDecodeBIG
+	}
+	padded := make([]byte, BIGLen)
+	copy(padded[BIGLen-len(b):], b)
+	return Curve.FromBytes(padded), nil
+}
+
+// ECPFromCoconutString recovers point on the G1 curve from its string representation as returned by ToCoconutString.
+func ECPFromCoconutString(s string) (*Curve.ECP, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, ErrDecodeECP
+	}
+	return ECPFromBytes(b)
+}
+
+// ECP2FromCoconutString recovers point on the G2 curve from its string representation as returned by ToCoconutString.
+func ECP2FromCoconutString(s string) (*Curve.ECP2, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, ErrDecodeECP2
+	}
+	return ECP2FromBytes(b)
+}
+
 // addHashPadding ensures that resultant hash is long enough to be used in a FromBytes() method
 func addHashPadding(sha int, b []byte) []byte {
 	const RM int = int(Curve.MODBYTES)
@@ -141,8 +174,8 @@ func HashStringToG1(sha int, m string) (*Curve.ECP, error) {
 }
 
 // HashBytesToG1 takes a bytes message and maps it to a point on G1 Curve
-// Python implementation use SHA512, so temporarily hardcoding it here
-// todo: NEED GEORGE'S FIX TO KNOW HOW TO FURTHER CHANGE IT
+// in a way compatible with the Python implementation, which uses SHA512.
+// It does not provide domain separation, HashToCurveG1 should be used instead whenever compatibility is not required.
 func HashBytesToG1(sha int, b []byte) (*Curve.ECP, error) {
 	// Follow Python implementation
 	if Curve.CURVE_PAIRING_TYPE == Curve.BN {
@@ -145,6 +145,46 @@ func TestToCoconutString(t *testing.T) {
 	assert.Empty(t, ToCoconutString(f))
 }
 
+func TestFromCoconutString(t *testing.T) {
+	G := bpgroup.New()
+	r := Curve.Randomnum(G.Order(), G.Rng())
+	g1r := Curve.G1mul(G.Gen1(), r)
+	g2r := Curve.G2mul(G.Gen2(), r)
+
+	rRec, err := BIGFromCoconutString(ToCoconutString(r))
+	assert.Nil(t, err)
+	assert.Zero(t, Curve.Comp(r, rRec))
+
+	g1rRec, err := ECPFromCoconutString(ToCoconutString(g1r))
+	assert.Nil(t, err)
+	assert.True(t, g1r.Equals(g1rRec))
+
+	g2rRec, err := ECP2FromCoconutString(ToCoconutString(g2r))
+	assert.Nil(t, err)
+	assert.True(t, g2r.Equals(g2rRec))
+
+	// Python's Bn.hex() does not include leading zeroes
+	x, err := BIGFromCoconutString("2a")
+	assert.Nil(t, err)
+	assert.Zero(t, Curve.Comp(Curve.NewBIGint(42), x))
+	x, err = BIGFromCoconutString("A2A")
+	assert.Nil(t, err)
+	assert.Zero(t, Curve.Comp(Curve.NewBIGint(2602), x))
+
+	_, err = BIGFromCoconutString("")
+	assert.Equal(t, ErrDecodeBIG, err)
+	_, err = BIGFromCoconutString("xyz")
+	assert.Equal(t, ErrDecodeBIG, err)
+	_, err = BIGFromCoconutString("01" + ToCoconutString(r))
+	assert.Equal(t, ErrDecodeBIG, err, "Should not accept values longer than BIGLen")
+	_, err = ECPFromCoconutString("xyz")
+	assert.Equal(t, ErrDecodeECP, err)
+	_, err = ECPFromCoconutString(ToCoconutString(g1r)[2:])
+	assert.Equal(t, ErrDecodeECP, err)
+	_, err = ECP2FromCoconutString("xyz")
+	assert.Equal(t, ErrDecodeECP2, err)
+}
+
 func randomString(n int) string {
 	var letter = []rune(" !\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~")
 
@@ -0,0 +1,137 @@
+// validation.go - validation of curve elements
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"errors"
+
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+var (
+	// ErrValidateNil indicates that a required element was missing.
+	ErrValidateNil = errors.New("Missing element")
+
+	// ErrValidateIdentity indicates that a point was the point at infinity.
+	ErrValidateIdentity = errors.New("Point at infinity is not allowed")
+
+	// ErrValidateSubgroup indicates that a point was not an element of the prime order subgroup.
+	ErrValidateSubgroup = errors.New("Point is not in the prime order subgroup")
+
+	// ErrValidateRange indicates that a scalar was not smaller than the order of the groups.
+	ErrValidateRange = errors.New("Scalar is out of range")
+)
+
+// ValidateECP ensures the point on the G1 curve is present, is not the point at infinity
+// and is an element of the prime order subgroup.
+func ValidateECP(p *Curve.ECP) error {
+	if p == nil {
+		return ErrValidateNil
+	}
+	// both Is_infinity and Mul normalise the point in place, so they operate on a copy
+	q := Curve.NewECP()
+	q.Copy(p)
+	if q.Is_infinity() {
+		return ErrValidateIdentity
+	}
+	// G1mul assumes the point is in the subgroup, so the generic multiplication has to be used
+	if !q.Mul(Curve.NewBIGints(Curve.CURVE_Order)).Is_infinity() {
+		return ErrValidateSubgroup
+	}
+	return nil
+}
+
+// ValidateECP2 ensures the point on the G2 curve is present, is not the point at infinity
+// and is an element of the prime order subgroup.
+func ValidateECP2(p *Curve.ECP2) error {
+	if p == nil {
+		return ErrValidateNil
+	}
+	// both Is_infinity and Mul normalise the point in place, so they operate on a copy
+	q := Curve.NewECP2()
+	q.Copy(p)
+	if q.Is_infinity() {
+		return ErrValidateIdentity
+	}
+	// G2mul assumes the point is in the subgroup, so the generic multiplication has to be used
+	if !q.Mul(Curve.NewBIGints(Curve.CURVE_Order)).Is_infinity() {
+		return ErrValidateSubgroup
+	}
+	return nil
+}
+
+// IsInfinity reports whether the point on the G1 curve is the point at infinity.
+// Unlike Is_infinity, it does not normalise the point in place,
+// hence it is safe to use on points shared between goroutines.
+func IsInfinity(p *Curve.ECP) bool {
+	q := Curve.NewECP()
+	q.Copy(p)
+	return q.Is_infinity()
+}
+
+// IsInfinity2 reports whether the point on the G2 curve is the point at infinity.
+// Unlike Is_infinity, it does not normalise the point in place,
+// hence it is safe to use on points shared between goroutines.
+func IsInfinity2(p *Curve.ECP2) bool {
+	q := Curve.NewECP2()
+	q.Copy(p)
+	return q.Is_infinity()
+}
+
+// ValidateECPs validates each of the points on the G1 curve.
+func ValidateECPs(ps []*Curve.ECP) error {
+	for _, p := range ps {
+		if err := ValidateECP(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateECP2s validates each of the points on the G2 curve.
+func ValidateECP2s(ps []*Curve.ECP2) error {
+	for _, p := range ps {
+		if err := ValidateECP2(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateBIGs ensures none of the BIG numbers is missing.
+func ValidateBIGs(xs []*Curve.BIG) error {
+	for _, x := range xs {
+		if x == nil {
+			return ErrValidateNil
+		}
+	}
+	return nil
+}
+
+// ValidateScalars ensures none of the scalars is missing and all of them are smaller than the order of the groups.
+func ValidateScalars(xs []*Curve.BIG) error {
+	p := Curve.NewBIGints(Curve.CURVE_Order)
+	for _, x := range xs {
+		if x == nil {
+			return ErrValidateNil
+		}
+		if Curve.Comp(x, p) >= 0 {
+			return ErrValidateRange
+		}
+	}
+	return nil
+}
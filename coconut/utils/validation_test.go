@@ -0,0 +1,119 @@
+// validation_test.go - tests of validation of curve elements
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// nonSubgroupECP returns a point on the G1 curve outside the prime order subgroup.
+// It returns nil if the cofactor of G1 is 1, as it is the case for BN curves.
+func nonSubgroupECP() *Curve.ECP {
+	if Curve.CURVE_PAIRING_TYPE == Curve.BN {
+		return nil
+	}
+	for i := 1; ; i++ {
+		P := Curve.NewECPbigint(Curve.NewBIGint(i), 0)
+		if !P.Is_infinity() && !P.Mul(Curve.NewBIGints(Curve.CURVE_Order)).Is_infinity() {
+			return P
+		}
+	}
+}
+
+// nonSubgroupECP2 returns a point on the G2 curve outside the prime order subgroup.
+func nonSubgroupECP2() *Curve.ECP2 {
+	for i := 1; ; i++ {
+		P := Curve.NewECP2fp2(Curve.NewFP2int(i))
+		if !P.Is_infinity() && !P.Mul(Curve.NewBIGints(Curve.CURVE_Order)).Is_infinity() {
+			return P
+		}
+	}
+}
+
+func TestValidateECP(t *testing.T) {
+	G := bpgroup.New()
+
+	assert.Nil(t, ValidateECP(G.Gen1()))
+	assert.Nil(t, ValidateECP(Curve.G1mul(G.Gen1(), Curve.Randomnum(G.Order(), G.Rng()))))
+	assert.Equal(t, ErrValidateNil, ValidateECP(nil))
+	assert.Equal(t, ErrValidateIdentity, ValidateECP(Curve.NewECP()))
+
+	if P := nonSubgroupECP(); P != nil {
+		// the point can be encoded and decoded, but is rejected by the validation
+		Q, err := ECPFromBytes(ECPToBytes(P))
+		assert.Nil(t, err)
+		assert.Equal(t, ErrValidateSubgroup, ValidateECP(Q))
+		assert.Equal(t, ErrValidateSubgroup, ValidateECPs([]*Curve.ECP{G.Gen1(), Q}))
+	}
+
+	assert.Nil(t, ValidateECPs([]*Curve.ECP{G.Gen1(), G.Gen1()}))
+	assert.Equal(t, ErrValidateNil, ValidateECPs([]*Curve.ECP{G.Gen1(), nil}))
+}
+
+func TestValidateECP2(t *testing.T) {
+	G := bpgroup.New()
+
+	assert.Nil(t, ValidateECP2(G.Gen2()))
+	assert.Nil(t, ValidateECP2(Curve.G2mul(G.Gen2(), Curve.Randomnum(G.Order(), G.Rng()))))
+	assert.Equal(t, ErrValidateNil, ValidateECP2(nil))
+	assert.Equal(t, ErrValidateIdentity, ValidateECP2(Curve.NewECP2()))
+
+	P := nonSubgroupECP2()
+	assert.Equal(t, ErrValidateSubgroup, ValidateECP2(P))
+	assert.Equal(t, ErrValidateSubgroup, ValidateECP2s([]*Curve.ECP2{G.Gen2(), P}))
+
+	assert.Nil(t, ValidateECP2s([]*Curve.ECP2{G.Gen2(), G.Gen2()}))
+	assert.Equal(t, ErrValidateIdentity, ValidateECP2s([]*Curve.ECP2{G.Gen2(), Curve.NewECP2()}))
+}
+
+func TestIsInfinity(t *testing.T) {
+	G := bpgroup.New()
+
+	// sum of two points is in projective coordinates, which Is_infinity would normalise
+	P := Curve.G1mul(G.Gen1(), Curve.NewBIGint(2))
+	P.Add(G.Gen1())
+	assert.False(t, IsInfinity(P))
+	assert.True(t, IsInfinity(Curve.NewECP()))
+	P.Sub(Curve.G1mul(G.Gen1(), Curve.NewBIGint(3)))
+	assert.True(t, IsInfinity(P))
+
+	Q := Curve.G2mul(G.Gen2(), Curve.NewBIGint(2))
+	Q.Add(G.Gen2())
+	assert.False(t, IsInfinity2(Q))
+	assert.True(t, IsInfinity2(Curve.NewECP2()))
+	Q.Sub(Curve.G2mul(G.Gen2(), Curve.NewBIGint(3)))
+	assert.True(t, IsInfinity2(Q))
+}
+
+func TestValidateBIGs(t *testing.T) {
+	assert.Nil(t, ValidateBIGs([]*Curve.BIG{Curve.NewBIGint(1), Curve.NewBIGint(2)}))
+	assert.Nil(t, ValidateBIGs(nil))
+	assert.Equal(t, ErrValidateNil, ValidateBIGs([]*Curve.BIG{Curve.NewBIGint(1), nil}))
+}
+
+func TestValidateScalars(t *testing.T) {
+	p := Curve.NewBIGints(Curve.CURVE_Order)
+	pMinusOne := Curve.Modneg(Curve.NewBIGint(1), p)
+	assert.Nil(t, ValidateScalars([]*Curve.BIG{Curve.NewBIG(), pMinusOne}))
+	assert.Nil(t, ValidateScalars(nil))
+	assert.Equal(t, ErrValidateNil, ValidateScalars([]*Curve.BIG{Curve.NewBIGint(1), nil}))
+	assert.Equal(t, ErrValidateRange, ValidateScalars([]*Curve.BIG{Curve.NewBIGint(1), p}))
+}
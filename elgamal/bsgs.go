@@ -0,0 +1,133 @@
+// bsgs.go - recovery of small plaintexts with baby-step giant-step algorithm
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package elgamal
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// MaxDecryptionBound is the largest supported bound of messages recoverable with a DecryptionTable.
+// The table holds square root of the bound entries, hence for the maximum it takes roughly 100MB of memory.
+const MaxDecryptionBound uint64 = 1 << 40
+
+var (
+	// ErrDecryptionTableBound indicates that the bound of the table was either 0 or larger than MaxDecryptionBound.
+	ErrDecryptionTableBound = errors.New("Invalid bound of the decryption table")
+
+	// ErrDecryptRange indicates that the decrypted message was not smaller than the bound of the table.
+	ErrDecryptRange = errors.New("Decrypted message is out of range of the decryption table")
+)
+
+// DecryptionTable is a precomputed table of baby steps used to recover m from h^m for m in range [0, bound).
+// It is specific to the base h and can be reused for any number of decryptions, including concurrent ones.
+type DecryptionTable struct {
+	h         *Curve.ECP
+	bound     uint64
+	step      uint64
+	babySteps map[string]uint64 // j * h -> j for j in [1, step)
+	giantStep *Curve.ECP        // -(step * h)
+}
+
+// H returns the base of the messages the table can recover.
+func (dt *DecryptionTable) H() *Curve.ECP {
+	return dt.h
+}
+
+// Bound returns the exclusive upper bound of the messages the table can recover.
+func (dt *DecryptionTable) Bound() uint64 {
+	return dt.bound
+}
+
+// NewDecryptionTable precomputes the table required to recover messages in range [0, bound)
+// encrypted in the form of h^m.
+func NewDecryptionTable(h *Curve.ECP, bound uint64) (*DecryptionTable, error) {
+	if bound == 0 || bound > MaxDecryptionBound {
+		return nil, ErrDecryptionTableBound
+	}
+	if err := utils.ValidateECP(h); err != nil {
+		return nil, err
+	}
+
+	step := uint64(math.Ceil(math.Sqrt(float64(bound))))
+	babySteps := make(map[string]uint64, step)
+	// the points are indexed by their serialised form, which is unique for every point but the point at infinity,
+	// so m = 0 is handled separately when recovering
+	acc := Curve.NewECP()
+	acc.Copy(h)
+	for j := uint64(1); j < step; j++ {
+		babySteps[string(utils.ECPToBytes(acc))] = j
+		acc.Add(h)
+	}
+
+	// acc = step * h
+	giantStep := Curve.NewECP()
+	giantStep.Sub(acc)
+	return &DecryptionTable{
+		h:         h,
+		bound:     bound,
+		step:      step,
+		babySteps: babySteps,
+		giantStep: giantStep,
+	}, nil
+}
+
+// DiscreteLog returns m such that hm = h^m, where h is the base of the table.
+// It returns ErrDecryptRange if no such m exists in range [0, bound).
+func (dt *DecryptionTable) DiscreteLog(hm *Curve.ECP) (uint64, error) {
+	acc := Curve.NewECP()
+	acc.Copy(hm)
+	for i := uint64(0); i*dt.step < dt.bound; i++ {
+		// acc = hm - i * step * h
+		var j uint64
+		found := acc.Is_infinity()
+		if !found {
+			j, found = dt.babySteps[string(utils.ECPToBytes(acc))]
+		}
+		if found {
+			if m := i*dt.step + j; m < dt.bound {
+				return m, nil
+			}
+			return 0, ErrDecryptRange
+		}
+		acc.Add(dt.giantStep)
+	}
+	return 0, ErrDecryptRange
+}
+
+// DecryptScalar takes the ElGamal encryption of a message in the form of h^m and recovers m itself
+// using the table precomputed for h. It returns ErrDecryptRange if m is not smaller than the bound of the table.
+func DecryptScalar(G *bpgroup.BpGroup, privk *PrivateKey, enc *Encryption, dt *DecryptionTable) (*Curve.BIG, error) {
+	m, err := dt.DiscreteLog(Decrypt(G, privk, enc))
+	if err != nil {
+		return nil, err
+	}
+	// the bound guarantees m fits in 64 bits
+	return bigFromUint64(m), nil
+}
+
+// bigFromUint64 converts the number to BIG.
+func bigFromUint64(x uint64) *Curve.BIG {
+	b := make([]byte, utils.BIGLen)
+	binary.BigEndian.PutUint64(b[utils.BIGLen-8:], x)
+	return Curve.FromBytes(b)
+}
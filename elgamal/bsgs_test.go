@@ -0,0 +1,114 @@
+// bsgs_test.go - tests of recovery of small plaintexts
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package elgamal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+func TestNewDecryptionTable(t *testing.T) {
+	G := bpgroup.New()
+	h := Curve.G1mul(G.Gen1(), Curve.Randomnum(G.Order(), G.Rng()))
+
+	_, err := NewDecryptionTable(h, 0)
+	assert.Equal(t, ErrDecryptionTableBound, err)
+	_, err = NewDecryptionTable(h, MaxDecryptionBound+1)
+	assert.Equal(t, ErrDecryptionTableBound, err)
+	_, err = NewDecryptionTable(nil, 100)
+	assert.Equal(t, utils.ErrValidateNil, err)
+	_, err = NewDecryptionTable(Curve.NewECP(), 100)
+	assert.Equal(t, utils.ErrValidateIdentity, err)
+
+	dt, err := NewDecryptionTable(h, 1000)
+	assert.Nil(t, err)
+	assert.True(t, dt.H().Equals(h))
+	assert.Equal(t, uint64(1000), dt.Bound())
+}
+
+func TestDecryptScalar(t *testing.T) {
+	G := bpgroup.New()
+	h := Curve.G1mul(G.Gen1(), Curve.Randomnum(G.Order(), G.Rng()))
+	d, gamma := Keygen(G)
+
+	tests := []struct {
+		bound uint64
+		ms    []int
+	}{
+		{bound: 1, ms: []int{0}},
+		{bound: 2, ms: []int{0, 1}},
+		{bound: 10, ms: []int{0, 1, 3, 4, 8, 9}},
+		{bound: 1000, ms: []int{0, 31, 32, 33, 500, 999}},
+		{bound: 1 << 20, ms: []int{0, 1023, 1024, 123456, 1<<20 - 1}},
+	}
+
+	for _, test := range tests {
+		// the same table is reused for all the decryptions
+		dt, err := NewDecryptionTable(h, test.bound)
+		assert.Nil(t, err)
+
+		for _, m := range test.ms {
+			enc, _ := Encrypt(G, gamma, Curve.NewBIGint(m), h)
+			mRec, err := DecryptScalar(G, d, enc, dt)
+			assert.Nil(t, err)
+			assert.Zero(t, Curve.Comp(Curve.NewBIGint(m), mRec))
+		}
+
+		for _, m := range []*Curve.BIG{
+			bigFromUint64(test.bound),
+			bigFromUint64(test.bound + 1),
+			bigFromUint64(2 * test.bound),
+			Curve.NewBIGints(Curve.CURVE_Order).Minus(Curve.NewBIGint(1)), // -1
+			Curve.Randomnum(G.Order(), G.Rng()),
+		} {
+			enc, _ := Encrypt(G, gamma, m, h)
+			_, err := DecryptScalar(G, d, enc, dt)
+			assert.Equal(t, ErrDecryptRange, err)
+		}
+	}
+
+	// table for a different base can't recover the message
+	dt, err := NewDecryptionTable(G.Gen1(), 1000)
+	assert.Nil(t, err)
+	enc, _ := Encrypt(G, gamma, Curve.NewBIGint(42), h)
+	_, err = DecryptScalar(G, d, enc, dt)
+	assert.Equal(t, ErrDecryptRange, err)
+}
+
+func TestBigFromUint64(t *testing.T) {
+	assert.Zero(t, Curve.Comp(Curve.NewBIGint(0), bigFromUint64(0)))
+	assert.Zero(t, Curve.Comp(Curve.NewBIGint(123456), bigFromUint64(123456)))
+	assert.Equal(t, "FFFFFFFFFFFFFFFF", utils.ToCoconutString(bigFromUint64(^uint64(0)))[2*utils.BIGLen-16:])
+}
+
+var dlogRes uint64
+
+func BenchmarkDiscreteLog(b *testing.B) {
+	G := bpgroup.New()
+	dt, _ := NewDecryptionTable(G.Gen1(), 1<<32)
+	hm := G.Gen1Mul(Curve.NewBIGint(1<<31 + 12345))
+	b.ResetTimer()
+	var m uint64
+	for i := 0; i < b.N; i++ {
+		m, _ = dt.DiscreteLog(hm)
+	}
+	dlogRes = m
+}
@@ -19,16 +19,83 @@
 package elgamal
 
 import (
+	"errors"
+
 	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
 
 	// The named import is used to be able to easily update curve being used
 	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
 )
 
-// todo: create types for public and private keys and adjust arguments accordingly (look https://godoc.org/golang.org/x/crypto/openpgp/elgamal)
-// todo: possibly alternative version of Decrypt to return actual m rather than h^m
 // todo: should decrypt take BpGroup argument for the sake of consistency or just remove it?
 
+var (
+	// ErrPrivateKeyRange indicates that the private key was not a non-zero number smaller than the order of the groups.
+	ErrPrivateKeyRange = errors.New("Private key is out of range")
+)
+
+// PrivateKey represents the private key d of the ElGamal encryption scheme.
+type PrivateKey struct {
+	d *Curve.BIG
+}
+
+// D returns the secret scalar of the PrivateKey.
+func (privk *PrivateKey) D() *Curve.BIG {
+	return privk.d
+}
+
+// PublicKey returns the PublicKey corresponding to the PrivateKey, i.e. gamma = g1^d.
+func (privk *PrivateKey) PublicKey(G *bpgroup.BpGroup) *PublicKey {
+	return &PublicKey{
+		gamma: G.Gen1Mul(privk.d),
+	}
+}
+
+// Validate ensures the PrivateKey is present and its scalar is in range (0, p).
+func (privk *PrivateKey) Validate() error {
+	if privk == nil || privk.d == nil {
+		return utils.ErrValidateNil
+	}
+	if Curve.Comp(privk.d, Curve.NewBIGint(0)) == 0 || Curve.Comp(privk.d, Curve.NewBIGints(Curve.CURVE_Order)) >= 0 {
+		return ErrPrivateKeyRange
+	}
+	return nil
+}
+
+// NewPrivateKey wraps the scalar d as ElGamal PrivateKey.
+func NewPrivateKey(d *Curve.BIG) *PrivateKey {
+	return &PrivateKey{
+		d: d,
+	}
+}
+
+// PublicKey represents the public key gamma = g1^d of the ElGamal encryption scheme.
+type PublicKey struct {
+	gamma *Curve.ECP
+}
+
+// Gamma returns the point on the G1 curve of the PublicKey.
+func (pubk *PublicKey) Gamma() *Curve.ECP {
+	return pubk.gamma
+}
+
+// Validate ensures the PublicKey is present, is not the point at infinity
+// and is an element of the prime order subgroup.
+func (pubk *PublicKey) Validate() error {
+	if pubk == nil {
+		return utils.ErrValidateNil
+	}
+	return utils.ValidateECP(pubk.gamma)
+}
+
+// NewPublicKey wraps the point on the G1 curve as ElGamal PublicKey.
+func NewPublicKey(gamma *Curve.ECP) *PublicKey {
+	return &PublicKey{
+		gamma: gamma,
+	}
+}
+
 // Encryption are the two points on the G1 curve
 // that represent encryption of message in form of h^m
 type Encryption struct {
@@ -46,6 +113,18 @@ func (e *Encryption) C2() *Curve.ECP {
 	return e.c2
 }
 
+// Validate ensures both points of the Encryption are present, are not the point at infinity
+// and are elements of the prime order subgroup.
+func (e *Encryption) Validate() error {
+	if e == nil {
+		return utils.ErrValidateNil
+	}
+	if err := utils.ValidateECP(e.c1); err != nil {
+		return err
+	}
+	return utils.ValidateECP(e.c2)
+}
+
 // NewEncryptionFromPoints wraps two points on G1 curve as ElGamal Encryption
 func NewEncryptionFromPoints(c1 *Curve.ECP, c2 *Curve.ECP) *Encryption {
 	return &Encryption{
@@ -57,34 +136,33 @@ func NewEncryptionFromPoints(c1 *Curve.ECP, c2 *Curve.ECP) *Encryption {
 // Keygen generates private and public keys required for ElGamal encryption scheme.
 // Passing coconut.Params as an argument would cause issues with cyclic dependencies,
 // passing BpGroup in that case is sufficient.
-func Keygen(G *bpgroup.BpGroup) (*Curve.BIG, *Curve.ECP) {
-	p, g1, rng := G.Order(), G.Gen1(), G.Rng()
+func Keygen(G *bpgroup.BpGroup) (*PrivateKey, *PublicKey) {
+	p, rng := G.Order(), G.Rng()
 
 	d := Curve.Randomnum(p, rng)
-	gamma := Curve.G1mul(g1, d)
-	return d, gamma
+	privk := &PrivateKey{d: d}
+	return privk, privk.PublicKey(G)
 }
 
 // Encrypt encrypts the given message in the form of h^m,
 // where h is a point on the G1 curve using the given public key.
 // The random k is returned alongside the encryption
 // as it is required by the Coconut Scheme to create proofs of knowledge.
-func Encrypt(G *bpgroup.BpGroup, gamma *Curve.ECP, m *Curve.BIG, h *Curve.ECP) (*Encryption, *Curve.BIG) {
-	p, g1, rng := G.Order(), G.Gen1(), G.Rng()
+func Encrypt(G *bpgroup.BpGroup, pubk *PublicKey, m *Curve.BIG, h *Curve.ECP) (*Encryption, *Curve.BIG) {
+	p, rng := G.Order(), G.Rng()
 
 	k := Curve.Randomnum(p, rng)
-	a := Curve.G1mul(g1, k)
-	b := Curve.G1mul(gamma, k) // b = (k * gamma)
-	b.Add(Curve.G1mul(h, m))   // b = (k * gamma) + (m * h)
+	a := G.Gen1Mul(k)
+	b := bpgroup.G1MulSum([]*Curve.ECP{pubk.gamma, h}, []*Curve.BIG{k, m}) // b = (k * gamma) + (m * h)
 
 	return &Encryption{a, b}, k
 }
 
 // Decrypt takes the ElGamal encryption of a message and returns a point on the G1 curve
 // that represents original h^m.
-func Decrypt(G *bpgroup.BpGroup, d *Curve.BIG, enc *Encryption) *Curve.ECP {
+func Decrypt(G *bpgroup.BpGroup, privk *PrivateKey, enc *Encryption) *Curve.ECP {
 	dec := Curve.NewECP()
 	dec.Copy(enc.c2)
-	dec.Sub(Curve.G1mul(enc.c1, d))
+	dec.Sub(Curve.G1mul(enc.c1, privk.d))
 	return dec
 }
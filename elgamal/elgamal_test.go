@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
 	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
 )
 
@@ -30,7 +31,31 @@ func TestElGamalKeygen(t *testing.T) {
 	g1 := G.Gen1()
 	d, gamma := Keygen(G)
 
-	assert.True(t, gamma.Equals(Curve.G1mul(g1, d)), "Gamma should be equal to g1 * d")
+	assert.True(t, gamma.Gamma().Equals(Curve.G1mul(g1, d.D())), "Gamma should be equal to g1 * d")
+}
+
+func TestElGamalKeys(t *testing.T) {
+	G := bpgroup.New()
+	d, gamma := Keygen(G)
+	assert.Nil(t, d.Validate())
+	assert.Nil(t, gamma.Validate())
+	assert.True(t, d.PublicKey(G).Gamma().Equals(gamma.Gamma()))
+
+	d2 := NewPrivateKey(Curve.NewBIGint(42))
+	assert.Nil(t, d2.Validate())
+	assert.True(t, d2.PublicKey(G).Gamma().Equals(Curve.G1mul(G.Gen1(), Curve.NewBIGint(42))))
+	assert.True(t, NewPublicKey(gamma.Gamma()).Gamma().Equals(gamma.Gamma()))
+
+	var nilPrivk *PrivateKey
+	assert.Equal(t, utils.ErrValidateNil, nilPrivk.Validate())
+	assert.Equal(t, utils.ErrValidateNil, NewPrivateKey(nil).Validate())
+	assert.Equal(t, ErrPrivateKeyRange, NewPrivateKey(Curve.NewBIGint(0)).Validate())
+	assert.Equal(t, ErrPrivateKeyRange, NewPrivateKey(G.Order()).Validate())
+
+	var nilPubk *PublicKey
+	assert.Equal(t, utils.ErrValidateNil, nilPubk.Validate())
+	assert.Equal(t, utils.ErrValidateNil, NewPublicKey(nil).Validate())
+	assert.Equal(t, utils.ErrValidateIdentity, NewPublicKey(Curve.NewECP()).Validate())
 }
 
 func TestElGamalEncryption(t *testing.T) {
@@ -47,8 +72,8 @@ func TestElGamalEncryption(t *testing.T) {
 
 	assert.True(t, enc.c1.Equals(Curve.G1mul(g1, k)), "a should be equal to g1^k")
 
-	tmp := Curve.G1mul(gamma, k) // b = (k * gamma)
-	tmp.Add(Curve.G1mul(h, m))   // b = (k * gamma) + (m * h)
+	tmp := Curve.G1mul(gamma.Gamma(), k) // b = (k * gamma)
+	tmp.Add(Curve.G1mul(h, m))           // b = (k * gamma) + (m * h)
 
 	assert.True(t, enc.c2.Equals(tmp), "b should be equal to (k * gamma) + (m * h)")
 }
@@ -95,6 +120,22 @@ func TestElGamalNewEncryptionFromPoints(t *testing.T) {
 	assert.True(t, dec.Equals(hm), "Original message (multiplied by same scalar) should be recovered")
 }
 
+func TestElGamalValidate(t *testing.T) {
+	G := bpgroup.New()
+	p, g1, rng := G.Order(), G.Gen1(), G.Rng()
+
+	_, gamma := Keygen(G)
+	h := Curve.G1mul(g1, Curve.Randomnum(p, rng))
+	enc, _ := Encrypt(G, gamma, Curve.Randomnum(p, rng), h)
+	assert.Nil(t, enc.Validate())
+
+	var nilEnc *Encryption
+	assert.Equal(t, utils.ErrValidateNil, nilEnc.Validate())
+	assert.Equal(t, utils.ErrValidateNil, NewEncryptionFromPoints(enc.C1(), nil).Validate())
+	assert.Equal(t, utils.ErrValidateIdentity, NewEncryptionFromPoints(Curve.NewECP(), enc.C2()).Validate())
+	assert.Equal(t, utils.ErrValidateIdentity, NewEncryptionFromPoints(enc.C1(), Curve.NewECP()).Validate())
+}
+
 var kencRes *Curve.BIG
 
 func BenchmarkElGamalEncryption(b *testing.B) {
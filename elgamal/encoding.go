@@ -0,0 +1,465 @@
+// encoding.go - binary and JSON encoding of ElGamal objects
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package elgamal
+
+import (
+	"encoding/json"
+
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+)
+
+// encodingVersion is the version of the binary representation of all objects in the package.
+const encodingVersion byte = 1
+
+// type tags used in the header of encoded objects
+const (
+	encryptionTag byte = iota + 1
+	privateKeyTag
+	publicKeyTag
+	keyShareTag
+	partialDecryptionTag
+	decryptionProofTag
+	hybridCiphertextTag
+	shuffleProofTag
+)
+
+// MarshalBinary encodes the Encryption as [version | curve | tag | c1 | c2].
+func (e *Encryption) MarshalBinary() ([]byte, error) {
+	if e == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, encryptionTag)
+	enc.PutECP(e.c1)
+	enc.PutECP(e.c2)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the Encryption encoded by MarshalBinary and validates it.
+func (e *Encryption) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, encryptionTag)
+	c1 := dec.GetECP()
+	c2 := dec.GetECP()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if err := (&Encryption{c1: c1, c2: c2}).Validate(); err != nil {
+		return err
+	}
+	e.c1, e.c2 = c1, c2
+	return nil
+}
+
+type encryptionJSON struct {
+	C1 string `json:"c1"`
+	C2 string `json:"c2"`
+}
+
+// MarshalJSON encodes the Encryption as {"c1": c1, "c2": c2},
+// where both points are represented as returned by utils.ToCoconutString.
+func (e *Encryption) MarshalJSON() ([]byte, error) {
+	if e.c1 == nil || e.c2 == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	if utils.IsInfinity(e.c1) || utils.IsInfinity(e.c2) {
+		return nil, utils.ErrEncodeInfinity
+	}
+	return json.Marshal(encryptionJSON{
+		C1: utils.ToCoconutString(e.c1),
+		C2: utils.ToCoconutString(e.c2),
+	})
+}
+
+// UnmarshalJSON decodes the Encryption encoded by MarshalJSON and validates it.
+func (e *Encryption) UnmarshalJSON(data []byte) error {
+	encJSON := encryptionJSON{}
+	if err := json.Unmarshal(data, &encJSON); err != nil {
+		return err
+	}
+	c1, err := utils.ECPFromCoconutString(encJSON.C1)
+	if err != nil {
+		return err
+	}
+	c2, err := utils.ECPFromCoconutString(encJSON.C2)
+	if err != nil {
+		return err
+	}
+	if err := (&Encryption{c1: c1, c2: c2}).Validate(); err != nil {
+		return err
+	}
+	e.c1, e.c2 = c1, c2
+	return nil
+}
+
+// MarshalBinary encodes the PrivateKey as [version | curve | tag | d].
+func (privk *PrivateKey) MarshalBinary() ([]byte, error) {
+	if privk == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, privateKeyTag)
+	enc.PutBIG(privk.d)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the PrivateKey encoded by MarshalBinary and validates it.
+func (privk *PrivateKey) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, privateKeyTag)
+	d := dec.GetBIG()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if err := (&PrivateKey{d: d}).Validate(); err != nil {
+		return err
+	}
+	privk.d = d
+	return nil
+}
+
+type privateKeyJSON struct {
+	D string `json:"d"`
+}
+
+// MarshalJSON encodes the PrivateKey as {"d": d},
+// where the scalar is represented as returned by utils.ToCoconutString.
+func (privk *PrivateKey) MarshalJSON() ([]byte, error) {
+	if privk.d == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	return json.Marshal(privateKeyJSON{
+		D: utils.ToCoconutString(privk.d),
+	})
+}
+
+// UnmarshalJSON decodes the PrivateKey encoded by MarshalJSON and validates it.
+func (privk *PrivateKey) UnmarshalJSON(data []byte) error {
+	privkJSON := privateKeyJSON{}
+	if err := json.Unmarshal(data, &privkJSON); err != nil {
+		return err
+	}
+	d, err := utils.BIGFromCoconutString(privkJSON.D)
+	if err != nil {
+		return err
+	}
+	if err := (&PrivateKey{d: d}).Validate(); err != nil {
+		return err
+	}
+	privk.d = d
+	return nil
+}
+
+// MarshalBinary encodes the PublicKey as [version | curve | tag | gamma].
+func (pubk *PublicKey) MarshalBinary() ([]byte, error) {
+	if pubk == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, publicKeyTag)
+	enc.PutECP(pubk.gamma)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the PublicKey encoded by MarshalBinary and validates it.
+func (pubk *PublicKey) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, publicKeyTag)
+	gamma := dec.GetECP()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if err := (&PublicKey{gamma: gamma}).Validate(); err != nil {
+		return err
+	}
+	pubk.gamma = gamma
+	return nil
+}
+
+type publicKeyJSON struct {
+	Gamma string `json:"gamma"`
+}
+
+// MarshalJSON encodes the PublicKey as {"gamma": gamma},
+// where the point is represented as returned by utils.ToCoconutString.
+func (pubk *PublicKey) MarshalJSON() ([]byte, error) {
+	if pubk.gamma == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	if utils.IsInfinity(pubk.gamma) {
+		return nil, utils.ErrEncodeInfinity
+	}
+	return json.Marshal(publicKeyJSON{
+		Gamma: utils.ToCoconutString(pubk.gamma),
+	})
+}
+
+// UnmarshalJSON decodes the PublicKey encoded by MarshalJSON and validates it.
+func (pubk *PublicKey) UnmarshalJSON(data []byte) error {
+	pubkJSON := publicKeyJSON{}
+	if err := json.Unmarshal(data, &pubkJSON); err != nil {
+		return err
+	}
+	gamma, err := utils.ECPFromCoconutString(pubkJSON.Gamma)
+	if err != nil {
+		return err
+	}
+	if err := (&PublicKey{gamma: gamma}).Validate(); err != nil {
+		return err
+	}
+	pubk.gamma = gamma
+	return nil
+}
+
+// MarshalBinary encodes the KeyShare as [version | curve | tag | id | d].
+func (ks *KeyShare) MarshalBinary() ([]byte, error) {
+	if ks == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, keyShareTag)
+	enc.PutID(ks.id)
+	enc.PutBIG(ks.d)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the KeyShare encoded by MarshalBinary and validates it.
+func (ks *KeyShare) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, keyShareTag)
+	id := dec.GetID()
+	d := dec.GetBIG()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if err := (&KeyShare{id: id, d: d}).Validate(); err != nil {
+		return err
+	}
+	ks.id, ks.d = id, d
+	return nil
+}
+
+type keyShareJSON struct {
+	ID int    `json:"id"`
+	D  string `json:"d"`
+}
+
+// MarshalJSON encodes the KeyShare as {"id": id, "d": d},
+// where the scalar is represented as returned by utils.ToCoconutString.
+func (ks *KeyShare) MarshalJSON() ([]byte, error) {
+	if ks.d == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	return json.Marshal(keyShareJSON{
+		ID: ks.id,
+		D:  utils.ToCoconutString(ks.d),
+	})
+}
+
+// UnmarshalJSON decodes the KeyShare encoded by MarshalJSON and validates it.
+func (ks *KeyShare) UnmarshalJSON(data []byte) error {
+	ksJSON := keyShareJSON{}
+	if err := json.Unmarshal(data, &ksJSON); err != nil {
+		return err
+	}
+	d, err := utils.BIGFromCoconutString(ksJSON.D)
+	if err != nil {
+		return err
+	}
+	if err := (&KeyShare{id: ksJSON.ID, d: d}).Validate(); err != nil {
+		return err
+	}
+	ks.id, ks.d = ksJSON.ID, d
+	return nil
+}
+
+// MarshalBinary encodes the PartialDecryption as [version | curve | tag | id | share | c | r],
+// where c and r are the challenge and the response of its proof.
+func (pd *PartialDecryption) MarshalBinary() ([]byte, error) {
+	if pd == nil || pd.proof == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, partialDecryptionTag)
+	enc.PutID(pd.id)
+	enc.PutECP(pd.share)
+	enc.PutBIG(pd.proof.c)
+	enc.PutBIG(pd.proof.r)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the PartialDecryption encoded by MarshalBinary and validates it.
+func (pd *PartialDecryption) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, partialDecryptionTag)
+	id := dec.GetID()
+	share := dec.GetECP()
+	c := dec.GetBIG()
+	r := dec.GetBIG()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	proof := &dleqProof{c: c, r: r}
+	if err := (&PartialDecryption{id: id, share: share, proof: proof}).Validate(); err != nil {
+		return err
+	}
+	pd.id, pd.share, pd.proof = id, share, proof
+	return nil
+}
+
+type partialDecryptionJSON struct {
+	ID    int    `json:"id"`
+	Share string `json:"share"`
+	C     string `json:"c"`
+	R     string `json:"r"`
+}
+
+// MarshalJSON encodes the PartialDecryption as {"id": id, "share": share, "c": c, "r": r},
+// where all elements are represented as returned by utils.ToCoconutString.
+func (pd *PartialDecryption) MarshalJSON() ([]byte, error) {
+	if pd.share == nil || pd.proof == nil || pd.proof.c == nil || pd.proof.r == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	if utils.IsInfinity(pd.share) {
+		return nil, utils.ErrEncodeInfinity
+	}
+	return json.Marshal(partialDecryptionJSON{
+		ID:    pd.id,
+		Share: utils.ToCoconutString(pd.share),
+		C:     utils.ToCoconutString(pd.proof.c),
+		R:     utils.ToCoconutString(pd.proof.r),
+	})
+}
+
+// UnmarshalJSON decodes the PartialDecryption encoded by MarshalJSON and validates it.
+func (pd *PartialDecryption) UnmarshalJSON(data []byte) error {
+	pdJSON := partialDecryptionJSON{}
+	if err := json.Unmarshal(data, &pdJSON); err != nil {
+		return err
+	}
+	share, err := utils.ECPFromCoconutString(pdJSON.Share)
+	if err != nil {
+		return err
+	}
+	c, err := utils.BIGFromCoconutString(pdJSON.C)
+	if err != nil {
+		return err
+	}
+	r, err := utils.BIGFromCoconutString(pdJSON.R)
+	if err != nil {
+		return err
+	}
+	proof := &dleqProof{c: c, r: r}
+	if err := (&PartialDecryption{id: pdJSON.ID, share: share, proof: proof}).Validate(); err != nil {
+		return err
+	}
+	pd.id, pd.share, pd.proof = pdJSON.ID, share, proof
+	return nil
+}
+
+// MarshalBinary encodes the DecryptionProof as [version | curve | tag | c | r].
+func (proof *DecryptionProof) MarshalBinary() ([]byte, error) {
+	if proof == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, decryptionProofTag)
+	enc.PutBIG(proof.c)
+	enc.PutBIG(proof.r)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the DecryptionProof encoded by MarshalBinary and validates it.
+func (proof *DecryptionProof) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, decryptionProofTag)
+	c := dec.GetBIG()
+	r := dec.GetBIG()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if err := (&DecryptionProof{c: c, r: r}).Validate(); err != nil {
+		return err
+	}
+	proof.c, proof.r = c, r
+	return nil
+}
+
+type decryptionProofJSON struct {
+	C string `json:"c"`
+	R string `json:"r"`
+}
+
+// MarshalJSON encodes the DecryptionProof as {"c": c, "r": r},
+// where both scalars are represented as returned by utils.ToCoconutString.
+func (proof *DecryptionProof) MarshalJSON() ([]byte, error) {
+	if proof.c == nil || proof.r == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	return json.Marshal(decryptionProofJSON{
+		C: utils.ToCoconutString(proof.c),
+		R: utils.ToCoconutString(proof.r),
+	})
+}
+
+// UnmarshalJSON decodes the DecryptionProof encoded by MarshalJSON and validates it.
+func (proof *DecryptionProof) UnmarshalJSON(data []byte) error {
+	proofJSON := decryptionProofJSON{}
+	if err := json.Unmarshal(data, &proofJSON); err != nil {
+		return err
+	}
+	c, err := utils.BIGFromCoconutString(proofJSON.C)
+	if err != nil {
+		return err
+	}
+	r, err := utils.BIGFromCoconutString(proofJSON.R)
+	if err != nil {
+		return err
+	}
+	proof.c, proof.r = c, r
+	return nil
+}
+
+// MarshalBinary encodes the ShuffleProof as [version | curve | tag | c | cs | cHats | s1 | s2 | s3 | s4 | sHats | sPrimes],
+// where each slice is preceded by its length.
+func (proof *ShuffleProof) MarshalBinary() ([]byte, error) {
+	if proof == nil {
+		return nil, utils.ErrEncodeNil
+	}
+	enc := utils.NewEncoder(encodingVersion, shuffleProofTag)
+	enc.PutBIG(proof.c)
+	enc.PutECPs(proof.cs)
+	enc.PutECPs(proof.cHats)
+	enc.PutBIG(proof.s1)
+	enc.PutBIG(proof.s2)
+	enc.PutBIG(proof.s3)
+	enc.PutBIG(proof.s4)
+	enc.PutBIGs(proof.sHats)
+	enc.PutBIGs(proof.sPrimes)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes the ShuffleProof encoded by MarshalBinary and validates it.
+func (proof *ShuffleProof) UnmarshalBinary(data []byte) error {
+	dec := utils.NewDecoder(data, encodingVersion, shuffleProofTag)
+	decoded := &ShuffleProof{}
+	decoded.c = dec.GetBIG()
+	decoded.cs = dec.GetECPs()
+	decoded.cHats = dec.GetECPs()
+	decoded.s1 = dec.GetBIG()
+	decoded.s2 = dec.GetBIG()
+	decoded.s3 = dec.GetBIG()
+	decoded.s4 = dec.GetBIG()
+	decoded.sHats = dec.GetBIGs()
+	decoded.sPrimes = dec.GetBIGs()
+	if err := dec.Finish(); err != nil {
+		return err
+	}
+	if err := decoded.Validate(len(decoded.cs)); err != nil {
+		return err
+	}
+	*proof = *decoded
+	return nil
+}
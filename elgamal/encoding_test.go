@@ -0,0 +1,270 @@
+// encoding_test.go - tests for binary and JSON encoding of ElGamal objects
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package elgamal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+func TestEncryptionEncoding(t *testing.T) {
+	G := bpgroup.New()
+	p, g1, rng := G.Order(), G.Gen1(), G.Rng()
+
+	d, gamma := Keygen(G)
+	h := Curve.G1mul(g1, Curve.Randomnum(p, rng))
+	m := Curve.Randomnum(p, rng)
+	enc, _ := Encrypt(G, gamma, m, h)
+
+	b, err := enc.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Len(t, b, utils.HeaderLen+2*utils.ECPLen)
+
+	encRec := &Encryption{}
+	assert.Nil(t, encRec.UnmarshalBinary(b))
+	assert.True(t, encRec.c1.Equals(enc.c1))
+	assert.True(t, encRec.c2.Equals(enc.c2))
+	assert.True(t, Decrypt(G, d, encRec).Equals(Curve.G1mul(h, m)))
+
+	assert.Equal(t, utils.ErrDecodeLength, encRec.UnmarshalBinary(b[:len(b)-1]))
+	assert.Equal(t, utils.ErrDecodeLength, encRec.UnmarshalBinary(append(b, 0x00)))
+
+	b[utils.HeaderLen] = 0x05 // invalid compression flag of c1
+	assert.Equal(t, utils.ErrDecodeECP, encRec.UnmarshalBinary(b))
+
+	var nilEnc *Encryption
+	_, err = nilEnc.MarshalBinary()
+	assert.Equal(t, utils.ErrEncodeNil, err)
+}
+
+func TestEncryptionJSON(t *testing.T) {
+	G := bpgroup.New()
+	p, g1, rng := G.Order(), G.Gen1(), G.Rng()
+
+	d, gamma := Keygen(G)
+	h := Curve.G1mul(g1, Curve.Randomnum(p, rng))
+	m := Curve.Randomnum(p, rng)
+	enc, _ := Encrypt(G, gamma, m, h)
+
+	b, err := json.Marshal(enc)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"c1":"`+utils.ToCoconutString(enc.c1)+`","c2":"`+utils.ToCoconutString(enc.c2)+`"}`, string(b))
+
+	encRec := &Encryption{}
+	assert.Nil(t, json.Unmarshal(b, encRec))
+	assert.True(t, Decrypt(G, d, encRec).Equals(Curve.G1mul(h, m)))
+
+	assert.Equal(t, utils.ErrDecodeECP, json.Unmarshal([]byte(`{"c1":"`+utils.ToCoconutString(enc.c1)+`"}`), encRec))
+	_, err = json.Marshal(&Encryption{c1: enc.c1})
+	assert.NotNil(t, err)
+}
+
+func TestKeysEncoding(t *testing.T) {
+	G := bpgroup.New()
+	d, gamma := Keygen(G)
+
+	b, err := d.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Len(t, b, utils.HeaderLen+utils.BIGLen)
+	dRec := &PrivateKey{}
+	assert.Nil(t, dRec.UnmarshalBinary(b))
+	assert.Zero(t, Curve.Comp(d.D(), dRec.D()))
+	assert.NotNil(t, (&PublicKey{}).UnmarshalBinary(b), "Key types should not be interchangeable")
+
+	b, err = gamma.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Len(t, b, utils.HeaderLen+utils.ECPLen)
+	gammaRec := &PublicKey{}
+	assert.Nil(t, gammaRec.UnmarshalBinary(b))
+	assert.True(t, gammaRec.Gamma().Equals(gamma.Gamma()))
+
+	// the blinding key can be persisted between the encryption and decryption
+	h := Curve.G1mul(G.Gen1(), Curve.Randomnum(G.Order(), G.Rng()))
+	m := Curve.Randomnum(G.Order(), G.Rng())
+	enc, _ := Encrypt(G, gammaRec, m, h)
+	assert.True(t, Decrypt(G, dRec, enc).Equals(Curve.G1mul(h, m)))
+
+	b, err = NewPrivateKey(Curve.NewBIGint(0)).MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, ErrPrivateKeyRange, dRec.UnmarshalBinary(b))
+	assert.Zero(t, Curve.Comp(d.D(), dRec.D()), "Failed decoding should not modify the key")
+
+	var nilPrivk *PrivateKey
+	_, err = nilPrivk.MarshalBinary()
+	assert.Equal(t, utils.ErrEncodeNil, err)
+	var nilPubk *PublicKey
+	_, err = nilPubk.MarshalBinary()
+	assert.Equal(t, utils.ErrEncodeNil, err)
+}
+
+func TestKeysJSON(t *testing.T) {
+	G := bpgroup.New()
+	d, gamma := Keygen(G)
+
+	b, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"d":"`+utils.ToCoconutString(d.D())+`"}`, string(b))
+	dRec := &PrivateKey{}
+	assert.Nil(t, json.Unmarshal(b, dRec))
+	assert.Zero(t, Curve.Comp(d.D(), dRec.D()))
+	assert.Equal(t, ErrPrivateKeyRange, json.Unmarshal([]byte(`{"d":"00"}`), dRec))
+
+	b, err = json.Marshal(gamma)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"gamma":"`+utils.ToCoconutString(gamma.Gamma())+`"}`, string(b))
+	gammaRec := &PublicKey{}
+	assert.Nil(t, json.Unmarshal(b, gammaRec))
+	assert.True(t, gammaRec.Gamma().Equals(gamma.Gamma()))
+
+	_, err = json.Marshal(NewPublicKey(Curve.NewECP()))
+	assert.NotNil(t, err)
+	assert.Equal(t, utils.ErrDecodeECP, json.Unmarshal([]byte(`{}`), gammaRec))
+}
+
+func TestThresholdEncoding(t *testing.T) {
+	G := bpgroup.New()
+	pubk, shares, vks, err := ThresholdKeygen(G, 2, 3)
+	assert.Nil(t, err)
+	enc, _ := Encrypt(G, pubk, Curve.Randomnum(G.Order(), G.Rng()), G.Gen1())
+
+	b, err := shares[2].MarshalBinary()
+	assert.Nil(t, err)
+	assert.Len(t, b, utils.HeaderLen+4+utils.BIGLen)
+	ksRec := &KeyShare{}
+	assert.Nil(t, ksRec.UnmarshalBinary(b))
+	assert.Equal(t, 3, ksRec.ID())
+	assert.Zero(t, Curve.Comp(shares[2].D(), ksRec.D()))
+	assert.NotNil(t, (&PrivateKey{}).UnmarshalBinary(b), "Key share should not be decoded as private key")
+
+	b, err = NewKeyShare(0, shares[0].D()).MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, ErrShareID, ksRec.UnmarshalBinary(b))
+
+	pd, err := ksRec.PartialDecrypt(G, enc)
+	assert.Nil(t, err)
+	b, err = pd.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Len(t, b, utils.HeaderLen+4+utils.ECPLen+2*utils.BIGLen)
+	pdRec := &PartialDecryption{}
+	assert.Nil(t, pdRec.UnmarshalBinary(b))
+	assert.Equal(t, pd.ID(), pdRec.ID())
+	assert.True(t, VerifyPartialDecryption(G, vks[2], enc, pdRec))
+
+	assert.Equal(t, utils.ErrDecodeLength, pdRec.UnmarshalBinary(b[:len(b)-1]))
+
+	var nilShare *KeyShare
+	_, err = nilShare.MarshalBinary()
+	assert.Equal(t, utils.ErrEncodeNil, err)
+	var nilPd *PartialDecryption
+	_, err = nilPd.MarshalBinary()
+	assert.Equal(t, utils.ErrEncodeNil, err)
+}
+
+func TestThresholdJSON(t *testing.T) {
+	G := bpgroup.New()
+	pubk, shares, vks, err := ThresholdKeygen(G, 2, 3)
+	assert.Nil(t, err)
+	enc, _ := Encrypt(G, pubk, Curve.Randomnum(G.Order(), G.Rng()), G.Gen1())
+
+	b, err := json.Marshal(shares[1])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"id":2,"d":"`+utils.ToCoconutString(shares[1].D())+`"}`, string(b))
+	ksRec := &KeyShare{}
+	assert.Nil(t, json.Unmarshal(b, ksRec))
+	assert.Equal(t, 2, ksRec.ID())
+	assert.Zero(t, Curve.Comp(shares[1].D(), ksRec.D()))
+	assert.Equal(t, ErrShareID, json.Unmarshal([]byte(`{"d":"01"}`), ksRec))
+
+	pd, err := ksRec.PartialDecrypt(G, enc)
+	assert.Nil(t, err)
+	b, err = json.Marshal(pd)
+	assert.Nil(t, err)
+	pdRec := &PartialDecryption{}
+	assert.Nil(t, json.Unmarshal(b, pdRec))
+	assert.True(t, pdRec.Share().Equals(pd.Share()))
+	assert.True(t, VerifyPartialDecryption(G, vks[1], enc, pdRec))
+
+	_, err = json.Marshal(&PartialDecryption{id: 1, share: pd.Share()})
+	assert.NotNil(t, err)
+}
+
+func TestDecryptionProofEncoding(t *testing.T) {
+	G := bpgroup.New()
+	d, gamma := Keygen(G)
+	enc, _ := Encrypt(G, gamma, Curve.Randomnum(G.Order(), G.Rng()), G.Gen1())
+	dec, proof := ConstructDecryptionProof(G, d, enc)
+
+	b, err := proof.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Len(t, b, utils.HeaderLen+2*utils.BIGLen)
+	proofRec := &DecryptionProof{}
+	assert.Nil(t, proofRec.UnmarshalBinary(b))
+	assert.True(t, VerifyDecryptionProof(G, gamma, enc, dec, proofRec))
+	assert.Equal(t, utils.ErrDecodeLength, proofRec.UnmarshalBinary(b[:len(b)-1]))
+
+	b, err = json.Marshal(proof)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"c":"`+utils.ToCoconutString(proof.C())+`","r":"`+utils.ToCoconutString(proof.R())+`"}`, string(b))
+	proofRec = &DecryptionProof{}
+	assert.Nil(t, json.Unmarshal(b, proofRec))
+	assert.True(t, VerifyDecryptionProof(G, gamma, enc, dec, proofRec))
+	assert.Equal(t, utils.ErrDecodeBIG, json.Unmarshal([]byte(`{"c":"01"}`), proofRec))
+
+	var nilProof *DecryptionProof
+	_, err = nilProof.MarshalBinary()
+	assert.Equal(t, utils.ErrEncodeNil, err)
+	_, err = json.Marshal(NewDecryptionProof(proof.C(), nil))
+	assert.NotNil(t, err)
+}
+
+func TestShuffleProofEncoding(t *testing.T) {
+	G := bpgroup.New()
+	_, gamma := Keygen(G)
+	n := 3
+	encs := make([]*Encryption, n)
+	for i := range encs {
+		encs[i], _ = Encrypt(G, gamma, Curve.Randomnum(G.Order(), G.Rng()), G.Gen1())
+	}
+	shuffled, proof, err := Shuffle(G, gamma, encs)
+	assert.Nil(t, err)
+
+	b, err := proof.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Len(t, b, utils.HeaderLen+4*4+2*n*utils.ECPLen+(5+2*n)*utils.BIGLen)
+	proofRec := &ShuffleProof{}
+	assert.Nil(t, proofRec.UnmarshalBinary(b))
+	assert.True(t, VerifyShuffle(G, gamma, encs, shuffled, proofRec))
+
+	assert.Equal(t, utils.ErrDecodeLength, proofRec.UnmarshalBinary(b[:len(b)-1]))
+
+	incomplete := *proof
+	incomplete.sHats = proof.sHats[1:]
+	b, err = incomplete.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, ErrShuffleProofLength, proofRec.UnmarshalBinary(b))
+	assert.True(t, VerifyShuffle(G, gamma, encs, shuffled, proofRec), "Failed decoding should not modify the proof")
+
+	var nilProof *ShuffleProof
+	_, err = nilProof.MarshalBinary()
+	assert.Equal(t, utils.ErrEncodeNil, err)
+}
@@ -0,0 +1,85 @@
+// homomorphic.go - homomorphic operations on ElGamal encryptions
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package elgamal
+
+import (
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// The operations are performed componentwise and return new Encryption, leaving the operands unchanged.
+// Encryptions of h^m1 and h^m2 under the same public key combine into encryption of h^(m1 + m2) and so on,
+// where all the arithmetic on the messages is performed modulo the order of the group.
+// Note that results of the operations can contain the point at infinity,
+// for example if an encryption is subtracted from itself, and as such are rejected by Validate.
+// They should be rerandomized before being published.
+
+// Add returns encryption of h^(m1 + m2), where e and other are encryptions of h^m1 and h^m2 respectively.
+func (e *Encryption) Add(other *Encryption) *Encryption {
+	c1, c2 := Curve.NewECP(), Curve.NewECP()
+	c1.Copy(e.c1)
+	c1.Add(other.c1)
+	c2.Copy(e.c2)
+	c2.Add(other.c2)
+	return &Encryption{c1: c1, c2: c2}
+}
+
+// Sub returns encryption of h^(m1 - m2), where e and other are encryptions of h^m1 and h^m2 respectively.
+func (e *Encryption) Sub(other *Encryption) *Encryption {
+	c1, c2 := Curve.NewECP(), Curve.NewECP()
+	c1.Copy(e.c1)
+	c1.Sub(other.c1)
+	c2.Copy(e.c2)
+	c2.Sub(other.c2)
+	return &Encryption{c1: c1, c2: c2}
+}
+
+// Mul returns encryption of h^(x * m), where e is encryption of h^m.
+func (e *Encryption) Mul(x *Curve.BIG) *Encryption {
+	return &Encryption{
+		c1: Curve.G1mul(e.c1, x),
+		c2: Curve.G1mul(e.c2, x),
+	}
+}
+
+// Neg returns encryption of h^(-m), where e is encryption of h^m.
+func (e *Encryption) Neg() *Encryption {
+	c1, c2 := Curve.NewECP(), Curve.NewECP()
+	c1.Sub(e.c1)
+	c2.Sub(e.c2)
+	return &Encryption{c1: c1, c2: c2}
+}
+
+// Rerandomize returns fresh encryption of the same message under the given public key
+// by adding an encryption of zero to e. The resulting encryption is unlinkable to e
+// by anyone not knowing the private key. The random k of the encryption of zero is returned alongside.
+func (e *Encryption) Rerandomize(G *bpgroup.BpGroup, pubk *PublicKey) (*Encryption, *Curve.BIG) {
+	zero, k := EncryptZero(G, pubk)
+	return e.Add(zero), k
+}
+
+// EncryptZero encrypts the message 0, i.e. returns (g1^k, gamma^k) for random k,
+// which does not depend on h. The random k is returned alongside the encryption.
+func EncryptZero(G *bpgroup.BpGroup, pubk *PublicKey) (*Encryption, *Curve.BIG) {
+	p, rng := G.Order(), G.Rng()
+
+	k := Curve.Randomnum(p, rng)
+	return &Encryption{
+		c1: G.Gen1Mul(k),
+		c2: Curve.G1mul(pubk.gamma, k),
+	}, k
+}
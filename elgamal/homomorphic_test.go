@@ -0,0 +1,122 @@
+// homomorphic_test.go - tests of homomorphic operations on ElGamal encryptions
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package elgamal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+func TestHomomorphicOperations(t *testing.T) {
+	G := bpgroup.New()
+	p, rng := G.Order(), G.Rng()
+	d, gamma := Keygen(G)
+	h := G.Gen1Mul(Curve.Randomnum(p, rng))
+
+	m1 := Curve.Randomnum(p, rng)
+	m2 := Curve.Randomnum(p, rng)
+	x := Curve.Randomnum(p, rng)
+	enc1, _ := Encrypt(G, gamma, m1, h)
+	enc2, _ := Encrypt(G, gamma, m2, h)
+	enc1Copy := NewEncryptionFromPoints(Curve.NewECP(), Curve.NewECP())
+	enc1Copy.c1.Copy(enc1.c1)
+	enc1Copy.c2.Copy(enc1.c2)
+
+	tests := []struct {
+		name string
+		enc  *Encryption
+		m    *Curve.BIG
+	}{
+		{name: "Add", enc: enc1.Add(enc2), m: modAdd(m1, m2, p)},
+		{name: "Sub", enc: enc1.Sub(enc2), m: modAdd(m1, Curve.Modneg(m2, p), p)},
+		{name: "Mul", enc: enc1.Mul(x), m: Curve.Modmul(m1, x, p)},
+		{name: "Neg", enc: enc1.Neg(), m: Curve.Modneg(m1, p)},
+		{name: "Combined", enc: enc1.Mul(x).Sub(enc2.Neg()), m: modAdd(Curve.Modmul(m1, x, p), m2, p)},
+	}
+	for _, test := range tests {
+		assert.True(t, Decrypt(G, d, test.enc).Equals(Curve.G1mul(h, test.m)), test.name)
+	}
+
+	// operands are not modified
+	assert.True(t, enc1.c1.Equals(enc1Copy.c1))
+	assert.True(t, enc1.c2.Equals(enc1Copy.c2))
+
+	// subtracting encryption from itself results in the point at infinity
+	assert.NotNil(t, enc1.Sub(enc1).Validate())
+}
+
+func TestEncryptZero(t *testing.T) {
+	G := bpgroup.New()
+	d, gamma := Keygen(G)
+
+	zero, k := EncryptZero(G, gamma)
+	assert.Nil(t, zero.Validate())
+	assert.True(t, zero.c1.Equals(G.Gen1Mul(k)))
+	assert.True(t, Decrypt(G, d, zero).Is_infinity())
+
+	zero2, _ := EncryptZero(G, gamma)
+	assert.False(t, zero.c1.Equals(zero2.c1))
+}
+
+func TestRerandomize(t *testing.T) {
+	G := bpgroup.New()
+	p, rng := G.Order(), G.Rng()
+	d, gamma := Keygen(G)
+	h := G.Gen1Mul(Curve.Randomnum(p, rng))
+	m := Curve.Randomnum(p, rng)
+
+	enc, k := Encrypt(G, gamma, m, h)
+	encRand, k2 := enc.Rerandomize(G, gamma)
+	assert.Nil(t, encRand.Validate())
+	assert.False(t, enc.c1.Equals(encRand.c1))
+	assert.False(t, enc.c2.Equals(encRand.c2))
+	assert.True(t, encRand.c1.Equals(G.Gen1Mul(modAdd(k, k2, p))))
+	assert.True(t, Decrypt(G, d, encRand).Equals(Curve.G1mul(h, m)))
+}
+
+func TestEncryptedTally(t *testing.T) {
+	G := bpgroup.New()
+	d, gamma := Keygen(G)
+	h := G.Gen1()
+
+	votes := []int{1, 0, 1, 1, 0, 1, 0, 1, 1, 1}
+	tally, _ := EncryptZero(G, gamma)
+	expected := 0
+	for _, vote := range votes {
+		enc, _ := Encrypt(G, gamma, Curve.NewBIGint(vote), h)
+		tally = tally.Add(enc)
+		expected += vote
+	}
+
+	dt, err := NewDecryptionTable(h, uint64(len(votes)+1))
+	assert.Nil(t, err)
+	result, err := DecryptScalar(G, d, tally, dt)
+	assert.Nil(t, err)
+	assert.Zero(t, Curve.Comp(Curve.NewBIGint(expected), result))
+
+	// weighted tally, i.e. 3 * tally - first vote
+	first, _ := Encrypt(G, gamma, Curve.NewBIGint(votes[0]), h)
+	weighted := tally.Mul(Curve.NewBIGint(3)).Sub(first)
+	dt, err = NewDecryptionTable(h, uint64(3*len(votes)))
+	assert.Nil(t, err)
+	result, err = DecryptScalar(G, d, weighted, dt)
+	assert.Nil(t, err)
+	assert.Zero(t, Curve.Comp(Curve.NewBIGint(3*expected-votes[0]), result))
+}
@@ -0,0 +1,137 @@
+// hybrid.go - hybrid encryption of arbitrary byte payloads to ElGamal keys
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package elgamal
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	"github.com/jstuczyn/amcl/version3/go/amcl"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+const (
+	// hybridKeyLen is the length of the AES-256 key used to encrypt the payload.
+	hybridKeyLen = 32
+
+	// hybridIVLen is the length of the GCM initialisation vector.
+	hybridIVLen = 12
+
+	// hybridMACLen is the length of the GCM authentication tag appended to the encrypted payload.
+	hybridMACLen = 16
+
+	// hybridKDFInfo is the shared info of the key derivation function, which separates it from any other use.
+	hybridKDFInfo = "COCONUT-V01-ELGAMAL-HYBRID_"
+)
+
+var (
+	// ErrDecryptBytes indicates that the ciphertext was not created for the given key or that it was tampered with.
+	ErrDecryptBytes = errors.New("Failed to authenticate the ciphertext")
+)
+
+// EncryptBytes encrypts the arbitrary payload so that only the holder of the private key behind
+// the given public key can recover it. It uses ephemeral key agreement on the G1 curve, derives the AES-256 key
+// and the initialisation vector with KDF2 over SHA256 and encrypts the payload with AES-GCM.
+//
+// The ciphertext is encoded as [version | curve | tag | R | len | payload | mac], where R = g1^k is the ephemeral public key,
+// len is the length of the encrypted payload and mac is the GCM tag authenticating all preceding bytes.
+func EncryptBytes(G *bpgroup.BpGroup, pubk *PublicKey, payload []byte) ([]byte, error) {
+	if err := pubk.Validate(); err != nil {
+		return nil, err
+	}
+	p, rng := G.Order(), G.Rng()
+
+	k := Curve.Randomnum(p, rng)
+	R := G.Gen1Mul(k)
+	key, iv := deriveHybridKey(R, pubk.gamma, Curve.G1mul(pubk.gamma, k))
+
+	enc := utils.NewEncoder(encodingVersion, hybridCiphertextTag)
+	enc.PutECP(R)
+	enc.PutLen(len(payload))
+	header, err := enc.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	gcm := new(amcl.GCM)
+	gcm.Init(hybridKeyLen, key, hybridIVLen, iv)
+	gcm.Add_header(header, len(header))
+	ct := gcm.Add_plain(payload, len(payload))
+	mac := gcm.Finish(true)
+
+	out := make([]byte, 0, len(header)+len(ct)+hybridMACLen)
+	out = append(out, header...)
+	out = append(out, ct...)
+	return append(out, mac[:]...), nil
+}
+
+// DecryptBytes recovers the payload encrypted with EncryptBytes.
+// It returns ErrDecryptBytes if the ciphertext was not created for the public key corresponding to privk
+// or if it was modified in any way.
+func DecryptBytes(G *bpgroup.BpGroup, privk *PrivateKey, ciphertext []byte) ([]byte, error) {
+	if err := privk.Validate(); err != nil {
+		return nil, err
+	}
+	headerLen := utils.HeaderLen + utils.ECPLen + 4
+	if len(ciphertext) < headerLen+hybridMACLen {
+		return nil, utils.ErrDecodeLength
+	}
+
+	dec := utils.NewDecoder(ciphertext[:headerLen], encodingVersion, hybridCiphertextTag)
+	R := dec.GetECP()
+	n := dec.GetLen(0)
+	if err := dec.Finish(); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateECP(R); err != nil {
+		return nil, err
+	}
+	if n != len(ciphertext)-headerLen-hybridMACLen {
+		return nil, utils.ErrDecodeLength
+	}
+
+	header := ciphertext[:headerLen]
+	ct := ciphertext[headerLen : headerLen+n]
+	mac := ciphertext[headerLen+n:]
+
+	key, iv := deriveHybridKey(R, privk.PublicKey(G).gamma, Curve.G1mul(R, privk.d))
+
+	gcm := new(amcl.GCM)
+	gcm.Init(hybridKeyLen, key, hybridIVLen, iv)
+	gcm.Add_header(header, len(header))
+	payload := gcm.Add_cipher(ct, len(ct))
+	expectedMac := gcm.Finish(true)
+
+	if subtle.ConstantTimeCompare(mac, expectedMac[:]) != 1 {
+		return nil, ErrDecryptBytes
+	}
+	return payload, nil
+}
+
+// deriveHybridKey derives the AES key and the GCM initialisation vector from the shared point,
+// binding them to both the ephemeral and the recipient's public keys.
+func deriveHybridKey(R, gamma, shared *Curve.ECP) ([]byte, []byte) {
+	info := make([]byte, 0, len(hybridKDFInfo)+2*utils.ECPLen)
+	info = append(info, hybridKDFInfo...)
+	info = append(info, utils.ECPToBytes(R)...)
+	info = append(info, utils.ECPToBytes(gamma)...)
+
+	okm := Curve.ECDH_KDF2(amcl.SHA256, utils.ECPToBytes(shared), info, hybridKeyLen+hybridIVLen)
+	return okm[:hybridKeyLen], okm[hybridKeyLen:]
+}
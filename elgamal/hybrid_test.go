@@ -0,0 +1,103 @@
+// hybrid_test.go - tests for hybrid encryption of byte payloads
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package elgamal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+)
+
+func TestHybridEncryption(t *testing.T) {
+	G := bpgroup.New()
+	d, gamma := Keygen(G)
+
+	for _, l := range []int{0, 1, 15, 16, 17, 100, 1000} {
+		payload := bytes.Repeat([]byte{0xAB}, l)
+		ct, err := EncryptBytes(G, gamma, payload)
+		assert.Nil(t, err)
+		assert.Len(t, ct, utils.HeaderLen+utils.ECPLen+4+l+hybridMACLen)
+		if l >= 16 {
+			assert.False(t, bytes.Contains(ct, payload), "Payload should not be present in plain")
+		}
+
+		dec, err := DecryptBytes(G, d, ct)
+		assert.Nil(t, err)
+		assert.Equal(t, payload, dec)
+	}
+
+	payload := []byte("issuance receipt")
+	ct1, err := EncryptBytes(G, gamma, payload)
+	assert.Nil(t, err)
+	ct2, err := EncryptBytes(G, gamma, payload)
+	assert.Nil(t, err)
+	assert.NotEqual(t, ct1, ct2, "Encryption should be randomised")
+
+	otherD, _ := Keygen(G)
+	_, err = DecryptBytes(G, otherD, ct1)
+	assert.Equal(t, ErrDecryptBytes, err)
+
+	_, err = EncryptBytes(G, nil, payload)
+	assert.Equal(t, utils.ErrValidateNil, err)
+	_, err = DecryptBytes(G, nil, ct1)
+	assert.Equal(t, utils.ErrValidateNil, err)
+}
+
+func TestHybridEncryptionTampering(t *testing.T) {
+	G := bpgroup.New()
+	d, gamma := Keygen(G)
+	payload := []byte("metadata that must not be modified")
+	ct, err := EncryptBytes(G, gamma, payload)
+	assert.Nil(t, err)
+
+	for i := range ct {
+		tampered := append([]byte{}, ct...)
+		tampered[i] ^= 0x01
+		dec, err := DecryptBytes(G, d, tampered)
+		assert.NotNil(t, err, "Modification of byte %v should be detected", i)
+		assert.Nil(t, dec)
+	}
+
+	_, err = DecryptBytes(G, d, ct[:len(ct)-1])
+	assert.Equal(t, utils.ErrDecodeLength, err)
+	_, err = DecryptBytes(G, d, append(ct, 0x00))
+	assert.Equal(t, utils.ErrDecodeLength, err)
+	_, err = DecryptBytes(G, d, ct[:utils.HeaderLen+utils.ECPLen])
+	assert.Equal(t, utils.ErrDecodeLength, err)
+
+	// ciphertext of other type of object
+	encBytes, err := NewEncryptionFromPoints(gamma.Gamma(), gamma.Gamma()).MarshalBinary()
+	assert.Nil(t, err)
+	_, err = DecryptBytes(G, d, append(encBytes, make([]byte, hybridMACLen)...))
+	assert.NotNil(t, err)
+}
+
+func BenchmarkEncryptBytes(b *testing.B) {
+	G := bpgroup.New()
+	_, gamma := Keygen(G)
+	payload := make([]byte, 1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := EncryptBytes(G, gamma, payload); err != nil {
+			panic(err)
+		}
+	}
+}
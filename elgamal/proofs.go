@@ -0,0 +1,155 @@
+// proofs.go - zero-knowledge proofs of the ElGamal encryption scheme
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package elgamal
+
+import (
+	"strings"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// proofDomain is the prefix of domain separation tags of the challenges of all proofs in the package.
+const proofDomain = "COCONUT-V01-ELGAMAL-"
+
+// domain separation tags of the challenges of particular proofs
+const (
+	partialDecryptionDomain = proofDomain + "PARTIAL-DECRYPTION_"
+	decryptionDomain        = proofDomain + "DECRYPTION_"
+	shuffleDomain           = proofDomain + "SHUFFLE_"
+	shuffleSeedDomain       = proofDomain + "SHUFFLE-SEED_"
+	shuffleGeneratorsDomain = proofDomain + "SHUFFLE-GENERATORS_"
+)
+
+// dleqProof is a non-interactive Chaum-Pedersen proof of equality of discrete logarithms,
+// i.e. of knowledge of x such that a = g^x and b = h^x.
+type dleqProof struct {
+	c *Curve.BIG
+	r *Curve.BIG
+}
+
+// validate ensures both scalars of the proof are present.
+func (proof *dleqProof) validate() error {
+	if proof == nil {
+		return utils.ErrValidateNil
+	}
+	return utils.ValidateBIGs([]*Curve.BIG{proof.c, proof.r})
+}
+
+// constructChallenge constructs a BIG num challenge by hashing a number of Eliptic Curve points
+// (or other printable elements) in the same way as the challenges of the proofs of the Coconut scheme,
+// but always using hash_to_field with the given domain separation tag.
+func constructChallenge(domain string, elems []utils.Printable) *Curve.BIG {
+	csa := make([]string, len(elems))
+	for i := range elems {
+		csa[i] = utils.ToCoconutString(elems[i])
+	}
+	cs := strings.Join(csa, ",")
+	c, err := utils.HashToScalar([]byte(cs), []byte(domain))
+	if err != nil {
+		// can only happen for empty domain separation tag
+		panic(err)
+	}
+	return c
+}
+
+// constructDLEQProof creates a proof of knowledge of x such that a = g^x and b = h^x.
+func constructDLEQProof(G *bpgroup.BpGroup, domain string, g, a, h, b *Curve.ECP, x *Curve.BIG) *dleqProof {
+	p, rng := G.Order(), G.Rng()
+
+	// witness
+	w := Curve.Randomnum(p, rng)
+	Aw := Curve.G1mul(g, w)
+	Bw := Curve.G1mul(h, w)
+
+	c := constructChallenge(domain, []utils.Printable{g, a, h, b, Aw, Bw})
+
+	// r = (w - c * x) mod p
+	r := w.Minus(Curve.Modmul(c, x, p))
+	r = r.Plus(p)
+	r.Mod(p)
+
+	return &dleqProof{
+		c: c,
+		r: r,
+	}
+}
+
+// verifyDLEQProof verifies the proof of knowledge of x such that a = g^x and b = h^x.
+func verifyDLEQProof(domain string, g, a, h, b *Curve.ECP, proof *dleqProof) bool {
+	if proof.validate() != nil {
+		return false
+	}
+	Aw := bpgroup.G1MultiMul([]*Curve.ECP{g, a}, []*Curve.BIG{proof.r, proof.c}) // Aw = g^r * a^c
+	Bw := bpgroup.G1MultiMul([]*Curve.ECP{h, b}, []*Curve.BIG{proof.r, proof.c}) // Bw = h^r * b^c
+
+	return Curve.Comp(proof.c, constructChallenge(domain, []utils.Printable{g, a, h, b, Aw, Bw})) == 0
+}
+
+// DecryptionProof is a non-interactive Chaum-Pedersen proof that a point on the G1 curve is the decryption
+// of an Encryption under the private key behind a given public key gamma, i.e. that log_g1(gamma) = log_c1(c2 - dec).
+type DecryptionProof dleqProof
+
+// C returns the challenge of the DecryptionProof.
+func (proof *DecryptionProof) C() *Curve.BIG {
+	return proof.c
+}
+
+// R returns the response of the DecryptionProof.
+func (proof *DecryptionProof) R() *Curve.BIG {
+	return proof.r
+}
+
+// Validate ensures both scalars of the DecryptionProof are present.
+func (proof *DecryptionProof) Validate() error {
+	return (*dleqProof)(proof).validate()
+}
+
+// NewDecryptionProof wraps the challenge and the response as DecryptionProof.
+func NewDecryptionProof(c *Curve.BIG, r *Curve.BIG) *DecryptionProof {
+	return &DecryptionProof{
+		c: c,
+		r: r,
+	}
+}
+
+// ConstructDecryptionProof decrypts the Encryption and creates the proof of correctness of the result,
+// which can be verified by anyone holding the corresponding public key.
+func ConstructDecryptionProof(G *bpgroup.BpGroup, privk *PrivateKey, enc *Encryption) (*Curve.ECP, *DecryptionProof) {
+	dec := Decrypt(G, privk, enc)
+	c1d := Curve.G1mul(enc.c1, privk.d)
+	gamma := G.Gen1Mul(privk.d)
+	proof := constructDLEQProof(G, decryptionDomain, G.Gen1(), gamma, enc.c1, c1d, privk.d)
+	return dec, (*DecryptionProof)(proof)
+}
+
+// VerifyDecryptionProof verifies whether dec is the decryption of the Encryption
+// under the private key behind the given public key.
+func VerifyDecryptionProof(G *bpgroup.BpGroup, pubk *PublicKey, enc *Encryption, dec *Curve.ECP, proof *DecryptionProof) bool {
+	if pubk.Validate() != nil || enc.Validate() != nil || dec == nil {
+		return false
+	}
+	// c1^d = c2 - dec
+	c1d := Curve.NewECP()
+	c1d.Copy(enc.c2)
+	c1d.Sub(dec)
+	if utils.ValidateECP(c1d) != nil {
+		return false
+	}
+	return verifyDLEQProof(decryptionDomain, G.Gen1(), pubk.gamma, enc.c1, c1d, (*dleqProof)(proof))
+}
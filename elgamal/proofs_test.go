@@ -0,0 +1,99 @@
+// proofs_test.go - tests for zero-knowledge proofs of the ElGamal encryption scheme
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package elgamal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+func TestDLEQProof(t *testing.T) {
+	G := bpgroup.New()
+	p, g1, rng := G.Order(), G.Gen1(), G.Rng()
+
+	x := Curve.Randomnum(p, rng)
+	h := G.Gen1Mul(Curve.Randomnum(p, rng))
+	a, b := Curve.G1mul(g1, x), Curve.G1mul(h, x)
+
+	proof := constructDLEQProof(G, decryptionDomain, g1, a, h, b, x)
+	assert.True(t, verifyDLEQProof(decryptionDomain, g1, a, h, b, proof))
+	assert.False(t, verifyDLEQProof(partialDecryptionDomain, g1, a, h, b, proof), "Proofs should be domain separated")
+	assert.False(t, verifyDLEQProof(decryptionDomain, g1, a, h, Curve.G1mul(h, Curve.Randomnum(p, rng)), proof))
+	assert.False(t, verifyDLEQProof(decryptionDomain, g1, a, h, b, &dleqProof{c: proof.c}))
+	assert.False(t, verifyDLEQProof(decryptionDomain, g1, a, h, b, nil))
+
+	// unequal logarithms
+	y := Curve.Randomnum(p, rng)
+	proof = constructDLEQProof(G, decryptionDomain, g1, a, h, Curve.G1mul(h, y), x)
+	assert.False(t, verifyDLEQProof(decryptionDomain, g1, a, h, Curve.G1mul(h, y), proof))
+}
+
+func TestDecryptionProof(t *testing.T) {
+	G := bpgroup.New()
+	p, rng := G.Order(), G.Rng()
+
+	d, gamma := Keygen(G)
+	_, otherGamma := Keygen(G)
+	h := G.Gen1Mul(Curve.Randomnum(p, rng))
+	m := Curve.Randomnum(p, rng)
+	enc, _ := Encrypt(G, gamma, m, h)
+
+	dec, proof := ConstructDecryptionProof(G, d, enc)
+	assert.Nil(t, proof.Validate())
+	assert.True(t, dec.Equals(Curve.G1mul(h, m)))
+	assert.True(t, dec.Equals(Decrypt(G, d, enc)))
+	assert.True(t, VerifyDecryptionProof(G, gamma, enc, dec, proof))
+
+	assert.False(t, VerifyDecryptionProof(G, otherGamma, enc, dec, proof), "Other public key should be rejected")
+	assert.False(t, VerifyDecryptionProof(G, gamma, enc, Curve.G1mul(h, Curve.Randomnum(p, rng)), proof),
+		"Incorrect decryption should be rejected")
+	otherEnc, _ := Encrypt(G, gamma, m, h)
+	assert.False(t, VerifyDecryptionProof(G, gamma, otherEnc, dec, proof), "Other encryption should be rejected")
+	assert.False(t, VerifyDecryptionProof(G, gamma, enc, dec, NewDecryptionProof(proof.C(), Curve.Randomnum(p, rng))))
+	assert.False(t, VerifyDecryptionProof(G, gamma, enc, dec, nil))
+	assert.False(t, VerifyDecryptionProof(G, gamma, enc, nil, proof))
+	assert.False(t, VerifyDecryptionProof(G, gamma, nil, dec, proof))
+	assert.False(t, VerifyDecryptionProof(G, nil, enc, dec, proof))
+
+	// message of 0 decrypts to the point at infinity
+	enc, _ = Encrypt(G, gamma, Curve.NewBIGint(0), h)
+	dec, proof = ConstructDecryptionProof(G, d, enc)
+	assert.True(t, dec.Is_infinity())
+	assert.True(t, VerifyDecryptionProof(G, gamma, enc, dec, proof))
+
+	var nilProof *DecryptionProof
+	assert.Equal(t, utils.ErrValidateNil, nilProof.Validate())
+	assert.Equal(t, utils.ErrValidateNil, NewDecryptionProof(nil, proof.R()).Validate())
+}
+
+func BenchmarkVerifyDecryptionProof(b *testing.B) {
+	G := bpgroup.New()
+	d, gamma := Keygen(G)
+	enc, _ := Encrypt(G, gamma, Curve.Randomnum(G.Order(), G.Rng()), G.Gen1())
+	dec, proof := ConstructDecryptionProof(G, d, enc)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !VerifyDecryptionProof(G, gamma, enc, dec, proof) {
+			panic("Failed to verify the proof")
+		}
+	}
+}
@@ -0,0 +1,367 @@
+// shuffle.go - verifiable shuffle of ElGamal encryptions
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package elgamal
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	"github.com/jstuczyn/amcl/version3/go/amcl"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+var (
+	// ErrShuffleEmpty indicates that there were no encryptions to shuffle.
+	ErrShuffleEmpty = errors.New("Can't shuffle empty list of encryptions")
+
+	// ErrShuffleProofLength indicates that the shuffle proof was created for different number of encryptions.
+	ErrShuffleProofLength = errors.New("Invalid length of the shuffle proof")
+)
+
+// ShuffleProof is a non-interactive zero-knowledge proof that a list of encryptions is a re-randomised
+// permutation of another list of encryptions under the same public key.
+// It is the proof of Terelius and Wikström in the form described by Haenni et al. in
+// "Pseudo-Code Algorithms for Verifiable Re-Encryption Mix-Nets": https://eprint.iacr.org/2017/1077.
+type ShuffleProof struct {
+	c       *Curve.BIG
+	cs      []*Curve.ECP // commitment to the permutation
+	cHats   []*Curve.ECP // commitment chain of the permuted challenges
+	s1      *Curve.BIG
+	s2      *Curve.BIG
+	s3      *Curve.BIG
+	s4      *Curve.BIG
+	sHats   []*Curve.BIG
+	sPrimes []*Curve.BIG
+}
+
+// Validate ensures all elements of the ShuffleProof are present and that it is a proof for n encryptions.
+func (proof *ShuffleProof) Validate(n int) error {
+	if proof == nil {
+		return utils.ErrValidateNil
+	}
+	if n < 1 || len(proof.cs) != n || len(proof.cHats) != n || len(proof.sHats) != n || len(proof.sPrimes) != n {
+		return ErrShuffleProofLength
+	}
+	if err := utils.ValidateECPs(proof.cs); err != nil {
+		return err
+	}
+	if err := utils.ValidateECPs(proof.cHats); err != nil {
+		return err
+	}
+	if err := utils.ValidateBIGs([]*Curve.BIG{proof.c, proof.s1, proof.s2, proof.s3, proof.s4}); err != nil {
+		return err
+	}
+	if err := utils.ValidateBIGs(proof.sHats); err != nil {
+		return err
+	}
+	return utils.ValidateBIGs(proof.sPrimes)
+}
+
+// Shuffle permutes and re-randomises the encryptions under the given public key
+// and creates the proof that the result contains the same messages as the original list.
+func Shuffle(G *bpgroup.BpGroup, pubk *PublicKey, encs []*Encryption) ([]*Encryption, *ShuffleProof, error) {
+	if err := pubk.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if len(encs) == 0 {
+		return nil, nil, ErrShuffleEmpty
+	}
+	for _, enc := range encs {
+		if err := enc.Validate(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	psi := randomPermutation(G, len(encs))
+	shuffled := make([]*Encryption, len(encs))
+	rs := make([]*Curve.BIG, len(encs))
+	for i := range shuffled {
+		shuffled[i], rs[i] = encs[psi[i]].Rerandomize(G, pubk)
+	}
+
+	return shuffled, constructShuffleProof(G, pubk, encs, shuffled, psi, rs), nil
+}
+
+// constructShuffleProof creates the proof that shuffled[i] is the re-randomisation of encs[psi[i]] by rs[i].
+func constructShuffleProof(G *bpgroup.BpGroup, pubk *PublicKey, encs []*Encryption, shuffled []*Encryption, psi []int, rPrimes []*Curve.BIG) *ShuffleProof {
+	p, g1, rng := G.Order(), G.Gen1(), G.Rng()
+	n := len(encs)
+	h, hs := shuffleGenerators(n)
+
+	// commitment to the permutation, cs[psi[i]] = g1^rs[psi[i]] * hs[i]
+	rs := make([]*Curve.BIG, n)
+	cs := make([]*Curve.ECP, n)
+	for i := range psi {
+		j := psi[i]
+		rs[j] = Curve.Randomnum(p, rng)
+		cs[j] = G.Gen1Mul(rs[j])
+		cs[j].Add(hs[i])
+	}
+
+	us := shuffleChallenges(pubk, encs, shuffled, cs)
+	uPrimes := make([]*Curve.BIG, n)
+	for i := range psi {
+		uPrimes[i] = us[psi[i]]
+	}
+
+	// commitment chain, cHats[i] = g1^rHats[i] * cHats[i-1]^uPrimes[i], starting from h
+	rHats := make([]*Curve.BIG, n)
+	cHats := make([]*Curve.ECP, n)
+	for i := range cHats {
+		rHats[i] = Curve.Randomnum(p, rng)
+		cHats[i] = bpgroup.G1MulSum([]*Curve.ECP{g1, chainPrev(h, cHats, i)}, []*Curve.BIG{rHats[i], uPrimes[i]})
+	}
+
+	// witnesses
+	w1 := Curve.Randomnum(p, rng)
+	w2 := Curve.Randomnum(p, rng)
+	w3 := Curve.Randomnum(p, rng)
+	w4 := Curve.Randomnum(p, rng)
+	wHats := make([]*Curve.BIG, n)
+	wPrimes := make([]*Curve.BIG, n)
+	for i := 0; i < n; i++ {
+		wHats[i] = Curve.Randomnum(p, rng)
+		wPrimes[i] = Curve.Randomnum(p, rng)
+	}
+
+	c1s, c2s := splitEncryptions(shuffled)
+	negW4 := modNeg(w4, p)
+
+	t1 := G.Gen1Mul(w1)
+	t2 := G.Gen1Mul(w2)
+	t3 := bpgroup.G1MulSum(append([]*Curve.ECP{g1}, hs...), append([]*Curve.BIG{w3}, wPrimes...))
+	t41 := bpgroup.G1MulSum(append([]*Curve.ECP{g1}, c1s...), append([]*Curve.BIG{negW4}, wPrimes...))
+	t42 := bpgroup.G1MulSum(append([]*Curve.ECP{pubk.gamma}, c2s...), append([]*Curve.BIG{negW4}, wPrimes...))
+	tHats := make([]*Curve.ECP, n)
+	for i := range tHats {
+		tHats[i] = bpgroup.G1MulSum([]*Curve.ECP{g1, chainPrev(h, cHats, i)}, []*Curve.BIG{wHats[i], wPrimes[i]})
+	}
+
+	c := shuffleChallenge(pubk, encs, shuffled, cs, cHats, []*Curve.ECP{t1, t2, t3, t41, t42}, tHats)
+
+	// vs[i] = uPrimes[i+1] * ... * uPrimes[n-1]
+	vs := make([]*Curve.BIG, n)
+	vs[n-1] = Curve.NewBIGint(1)
+	for i := n - 1; i > 0; i-- {
+		vs[i-1] = Curve.Modmul(uPrimes[i], vs[i], p)
+	}
+
+	rBar, rHat, rTilde, rPrime := Curve.NewBIG(), Curve.NewBIG(), Curve.NewBIG(), Curve.NewBIG()
+	for i := 0; i < n; i++ {
+		rBar = modAdd(rBar, rs[i], p)
+		rHat = modAdd(rHat, Curve.Modmul(rHats[i], vs[i], p), p)
+		rTilde = modAdd(rTilde, Curve.Modmul(rs[i], us[i], p), p)
+		rPrime = modAdd(rPrime, Curve.Modmul(rPrimes[i], uPrimes[i], p), p)
+	}
+
+	sHats := make([]*Curve.BIG, n)
+	sPrimes := make([]*Curve.BIG, n)
+	for i := 0; i < n; i++ {
+		sHats[i] = response(wHats[i], c, rHats[i], p)
+		sPrimes[i] = response(wPrimes[i], c, uPrimes[i], p)
+	}
+
+	return &ShuffleProof{
+		c:       c,
+		cs:      cs,
+		cHats:   cHats,
+		s1:      response(w1, c, rBar, p),
+		s2:      response(w2, c, rHat, p),
+		s3:      response(w3, c, rTilde, p),
+		s4:      response(w4, c, rPrime, p),
+		sHats:   sHats,
+		sPrimes: sPrimes,
+	}
+}
+
+// VerifyShuffle verifies whether shuffled is a re-randomised permutation of encs under the given public key.
+// It rejects any dropped, duplicated or substituted encryption.
+func VerifyShuffle(G *bpgroup.BpGroup, pubk *PublicKey, encs []*Encryption, shuffled []*Encryption, proof *ShuffleProof) bool {
+	n := len(encs)
+	if pubk.Validate() != nil || len(shuffled) != n || proof.Validate(n) != nil {
+		return false
+	}
+	for i := range encs {
+		if encs[i].Validate() != nil || shuffled[i].Validate() != nil {
+			return false
+		}
+	}
+	p, g1 := G.Order(), G.Gen1()
+	h, hs := shuffleGenerators(n)
+
+	us := shuffleChallenges(pubk, encs, shuffled, proof.cs)
+
+	// cBar = prod(cs) / prod(hs), cHat = cHats[n-1] / h^prod(us)
+	cBar := Curve.NewECP()
+	u := Curve.NewBIGint(1)
+	for i := 0; i < n; i++ {
+		cBar.Add(proof.cs[i])
+		cBar.Sub(hs[i])
+		u = Curve.Modmul(u, us[i], p)
+	}
+	cHat := Curve.NewECP()
+	cHat.Copy(proof.cHats[n-1])
+	cHat.Sub(Curve.G1mul(h, u))
+
+	c1s, c2s := splitEncryptions(encs)
+	cTilde := bpgroup.G1MultiMul(proof.cs, us)
+	aTilde := bpgroup.G1MultiMul(c1s, us)
+	bTilde := bpgroup.G1MultiMul(c2s, us)
+
+	c1Primes, c2Primes := splitEncryptions(shuffled)
+	negS4 := modNeg(proof.s4, p)
+
+	t1 := bpgroup.G1MultiMul([]*Curve.ECP{cBar, g1}, []*Curve.BIG{proof.c, proof.s1})
+	t2 := bpgroup.G1MultiMul([]*Curve.ECP{cHat, g1}, []*Curve.BIG{proof.c, proof.s2})
+	t3 := bpgroup.G1MultiMul(append([]*Curve.ECP{cTilde, g1}, hs...), append([]*Curve.BIG{proof.c, proof.s3}, proof.sPrimes...))
+	t41 := bpgroup.G1MultiMul(append([]*Curve.ECP{aTilde, g1}, c1Primes...), append([]*Curve.BIG{proof.c, negS4}, proof.sPrimes...))
+	t42 := bpgroup.G1MultiMul(append([]*Curve.ECP{bTilde, pubk.gamma}, c2Primes...), append([]*Curve.BIG{proof.c, negS4}, proof.sPrimes...))
+	tHats := make([]*Curve.ECP, n)
+	for i := range tHats {
+		tHats[i] = bpgroup.G1MultiMul(
+			[]*Curve.ECP{proof.cHats[i], g1, chainPrev(h, proof.cHats, i)},
+			[]*Curve.BIG{proof.c, proof.sHats[i], proof.sPrimes[i]},
+		)
+	}
+
+	c := shuffleChallenge(pubk, encs, shuffled, proof.cs, proof.cHats, []*Curve.ECP{t1, t2, t3, t41, t42}, tHats)
+	return Curve.Comp(proof.c, c) == 0
+}
+
+// shuffleGenerators deterministically derives the independent generators h and hs[0], ..., hs[n-1] of G1
+// used for the commitments of the shuffle proof, so that nobody knows their discrete logarithms.
+func shuffleGenerators(n int) (*Curve.ECP, []*Curve.ECP) {
+	h := hashToG1([]byte("h"))
+	hs := make([]*Curve.ECP, n)
+	for i := range hs {
+		hs[i] = hashToG1([]byte("h" + strconv.Itoa(i)))
+	}
+	return h, hs
+}
+
+// hashToG1 hashes msg to a point on the G1 curve in the domain of the shuffle generators.
+// hash_to_curve is only available on BLS12-381, so on BN254 the domain is prepended to the message
+// hashed in the same way as by the Python implementation instead.
+func hashToG1(msg []byte) *Curve.ECP {
+	var h *Curve.ECP
+	var err error
+	if Curve.CURVE_PAIRING_TYPE == Curve.BN {
+		h, err = utils.HashBytesToG1(amcl.SHA512, append([]byte(shuffleGeneratorsDomain), msg...))
+	} else {
+		h, err = utils.HashToCurveG1(msg, []byte(shuffleGeneratorsDomain))
+	}
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// shuffleChallenges derives the challenges us[0], ..., us[n-1] bound to both lists of encryptions
+// and the commitment to the permutation.
+func shuffleChallenges(pubk *PublicKey, encs []*Encryption, shuffled []*Encryption, cs []*Curve.ECP) []*Curve.BIG {
+	elems := []utils.Printable{pubk.gamma}
+	elems = appendEncryptions(elems, encs)
+	elems = appendEncryptions(elems, shuffled)
+	for _, c := range cs {
+		elems = append(elems, c)
+	}
+	seed := constructChallenge(shuffleSeedDomain, elems)
+
+	us := make([]*Curve.BIG, len(cs))
+	for i := range us {
+		us[i] = constructChallenge(shuffleDomain, []utils.Printable{seed, Curve.NewBIGint(i)})
+	}
+	return us
+}
+
+// shuffleChallenge derives the challenge of the shuffle proof from the statement and the commitments.
+func shuffleChallenge(pubk *PublicKey, encs []*Encryption, shuffled []*Encryption, cs []*Curve.ECP, cHats []*Curve.ECP, ts []*Curve.ECP, tHats []*Curve.ECP) *Curve.BIG {
+	elems := []utils.Printable{pubk.gamma}
+	elems = appendEncryptions(elems, encs)
+	elems = appendEncryptions(elems, shuffled)
+	for _, points := range [][]*Curve.ECP{cs, cHats, ts, tHats} {
+		for _, point := range points {
+			elems = append(elems, point)
+		}
+	}
+	return constructChallenge(shuffleDomain, elems)
+}
+
+func appendEncryptions(elems []utils.Printable, encs []*Encryption) []utils.Printable {
+	for _, enc := range encs {
+		elems = append(elems, enc.c1, enc.c2)
+	}
+	return elems
+}
+
+// splitEncryptions returns first and second elements of the encryptions.
+func splitEncryptions(encs []*Encryption) ([]*Curve.ECP, []*Curve.ECP) {
+	c1s := make([]*Curve.ECP, len(encs))
+	c2s := make([]*Curve.ECP, len(encs))
+	for i, enc := range encs {
+		c1s[i], c2s[i] = enc.c1, enc.c2
+	}
+	return c1s, c2s
+}
+
+// chainPrev returns the predecessor of cHats[i] in the commitment chain.
+func chainPrev(h *Curve.ECP, cHats []*Curve.ECP, i int) *Curve.ECP {
+	if i == 0 {
+		return h
+	}
+	return cHats[i-1]
+}
+
+// randomPermutation returns uniformly random permutation of 0, ..., n-1 using Fisher-Yates shuffle.
+func randomPermutation(G *bpgroup.BpGroup, n int) []int {
+	rng := G.Rng()
+	psi := make([]int, n)
+	for i := range psi {
+		psi[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		jb := utils.BIGToBytes(Curve.Randomnum(Curve.NewBIGint(i+1), rng))
+		j := int(binary.BigEndian.Uint64(jb[len(jb)-8:]))
+		psi[i], psi[j] = psi[j], psi[i]
+	}
+	return psi
+}
+
+// response returns (w - c * x) mod p.
+func response(w, c, x, p *Curve.BIG) *Curve.BIG {
+	r := w.Minus(Curve.Modmul(c, x, p))
+	r = r.Plus(p)
+	r.Mod(p)
+	return r
+}
+
+// modAdd returns (a + b) mod p.
+func modAdd(a, b, p *Curve.BIG) *Curve.BIG {
+	r := a.Plus(b)
+	r.Mod(p)
+	return r
+}
+
+// modNeg returns -a mod p.
+func modNeg(a, p *Curve.BIG) *Curve.BIG {
+	r := Curve.Modneg(a, p)
+	r.Mod(p)
+	return r
+}
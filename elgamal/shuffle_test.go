@@ -0,0 +1,187 @@
+// shuffle_test.go - tests for verifiable shuffle of ElGamal encryptions
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package elgamal
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+// encryptRandom returns n encryptions of random messages in the form of h^m.
+func encryptRandom(G *bpgroup.BpGroup, pubk *PublicKey, h *Curve.ECP, n int) []*Encryption {
+	encs := make([]*Encryption, n)
+	for i := range encs {
+		encs[i], _ = Encrypt(G, pubk, Curve.Randomnum(G.Order(), G.Rng()), h)
+	}
+	return encs
+}
+
+// decryptAll returns sorted string representations of the decryptions of encs.
+func decryptAll(G *bpgroup.BpGroup, privk *PrivateKey, encs []*Encryption) []string {
+	decs := make([]string, len(encs))
+	for i := range encs {
+		decs[i] = utils.ToCoconutString(Decrypt(G, privk, encs[i]))
+	}
+	sort.Strings(decs)
+	return decs
+}
+
+func TestShuffle(t *testing.T) {
+	G := bpgroup.New()
+	d, gamma := Keygen(G)
+	h := G.Gen1Mul(Curve.Randomnum(G.Order(), G.Rng()))
+
+	for _, n := range []int{1, 2, 3, 10} {
+		encs := encryptRandom(G, gamma, h, n)
+		shuffled, proof, err := Shuffle(G, gamma, encs)
+		assert.Nil(t, err)
+		assert.Len(t, shuffled, n)
+		assert.Nil(t, proof.Validate(n))
+		assert.True(t, VerifyShuffle(G, gamma, encs, shuffled, proof))
+
+		assert.Equal(t, decryptAll(G, d, encs), decryptAll(G, d, shuffled))
+		for i := range shuffled {
+			for j := range encs {
+				assert.False(t, shuffled[i].c1.Equals(encs[j].c1), "Encryptions should be re-randomised")
+			}
+		}
+	}
+
+	_, _, err := Shuffle(G, gamma, nil)
+	assert.Equal(t, ErrShuffleEmpty, err)
+	_, _, err = Shuffle(G, gamma, []*Encryption{nil})
+	assert.Equal(t, utils.ErrValidateNil, err)
+	_, _, err = Shuffle(G, nil, encryptRandom(G, gamma, h, 2))
+	assert.Equal(t, utils.ErrValidateNil, err)
+}
+
+func TestVerifyShuffle(t *testing.T) {
+	G := bpgroup.New()
+	_, gamma := Keygen(G)
+	_, otherGamma := Keygen(G)
+	h := G.Gen1Mul(Curve.Randomnum(G.Order(), G.Rng()))
+	n := 5
+
+	encs := encryptRandom(G, gamma, h, n)
+	shuffled, proof, err := Shuffle(G, gamma, encs)
+	assert.Nil(t, err)
+	assert.True(t, VerifyShuffle(G, gamma, encs, shuffled, proof))
+
+	withShuffled := func(f func([]*Encryption) []*Encryption) []*Encryption {
+		return f(append([]*Encryption{}, shuffled...))
+	}
+
+	dropped := withShuffled(func(s []*Encryption) []*Encryption { return s[1:] })
+	assert.False(t, VerifyShuffle(G, gamma, encs, dropped, proof), "Dropped encryption should be rejected")
+
+	duplicated := withShuffled(func(s []*Encryption) []*Encryption { s[1] = s[0]; return s })
+	assert.False(t, VerifyShuffle(G, gamma, encs, duplicated, proof), "Duplicated encryption should be rejected")
+
+	rerandomised := withShuffled(func(s []*Encryption) []*Encryption {
+		s[0], _ = s[0].Rerandomize(G, gamma)
+		return s
+	})
+	assert.False(t, VerifyShuffle(G, gamma, encs, rerandomised, proof), "Modified encryption should be rejected")
+
+	substituted := withShuffled(func(s []*Encryption) []*Encryption {
+		s[2] = encryptRandom(G, gamma, h, 1)[0]
+		return s
+	})
+	assert.False(t, VerifyShuffle(G, gamma, encs, substituted, proof), "Substituted encryption should be rejected")
+
+	swapped := withShuffled(func(s []*Encryption) []*Encryption { s[0], s[1] = s[1], s[0]; return s })
+	assert.False(t, VerifyShuffle(G, gamma, encs, swapped, proof), "Proof should be bound to the order of the output")
+
+	otherEncs := append([]*Encryption{}, encs...)
+	otherEncs[3] = encryptRandom(G, gamma, h, 1)[0]
+	assert.False(t, VerifyShuffle(G, gamma, otherEncs, shuffled, proof), "Other input should be rejected")
+	assert.False(t, VerifyShuffle(G, otherGamma, encs, shuffled, proof), "Other public key should be rejected")
+	assert.False(t, VerifyShuffle(G, gamma, encs, shuffled, nil))
+	assert.False(t, VerifyShuffle(G, gamma, encs, withShuffled(func(s []*Encryption) []*Encryption {
+		s[4] = nil
+		return s
+	}), proof))
+
+	tamperedProof := *proof
+	tamperedProof.s3 = Curve.Randomnum(G.Order(), G.Rng())
+	assert.False(t, VerifyShuffle(G, gamma, encs, shuffled, &tamperedProof))
+	tamperedProof = *proof
+	tamperedProof.sPrimes = append([]*Curve.BIG{}, proof.sPrimes...)
+	tamperedProof.sPrimes[0], tamperedProof.sPrimes[1] = tamperedProof.sPrimes[1], tamperedProof.sPrimes[0]
+	assert.False(t, VerifyShuffle(G, gamma, encs, shuffled, &tamperedProof))
+	tamperedProof = *proof
+	tamperedProof.cs = proof.cs[1:]
+	assert.False(t, VerifyShuffle(G, gamma, encs, shuffled, &tamperedProof))
+}
+
+func TestShuffleProofOfInvalidShuffle(t *testing.T) {
+	G := bpgroup.New()
+	_, gamma := Keygen(G)
+	h := G.Gen1Mul(Curve.Randomnum(G.Order(), G.Rng()))
+	n := 4
+
+	encs := encryptRandom(G, gamma, h, n)
+	psi := []int{2, 0, 3, 1}
+	rs := make([]*Curve.BIG, n)
+	shuffled := make([]*Encryption, n)
+	for i := range shuffled {
+		shuffled[i], rs[i] = encs[psi[i]].Rerandomize(G, gamma)
+	}
+	assert.True(t, VerifyShuffle(G, gamma, encs, shuffled, constructShuffleProof(G, gamma, encs, shuffled, psi, rs)))
+
+	// dishonest mix duplicates one of the encryptions and drops another
+	shuffled[1], rs[1] = encs[psi[0]].Rerandomize(G, gamma)
+	assert.False(t, VerifyShuffle(G, gamma, encs, shuffled, constructShuffleProof(G, gamma, encs, shuffled, psi, rs)))
+
+	// dishonest mix substitutes one of the encryptions with its own
+	shuffled[1], rs[1] = EncryptZero(G, gamma)
+	assert.False(t, VerifyShuffle(G, gamma, encs, shuffled, constructShuffleProof(G, gamma, encs, shuffled, psi, rs)))
+}
+
+func TestRandomPermutation(t *testing.T) {
+	G := bpgroup.New()
+	for _, n := range []int{1, 2, 10, 100} {
+		psi := randomPermutation(G, n)
+		sorted := append([]int{}, psi...)
+		sort.Ints(sorted)
+		for i := range sorted {
+			assert.Equal(t, i, sorted[i])
+		}
+	}
+}
+
+func BenchmarkVerifyShuffle(b *testing.B) {
+	G := bpgroup.New()
+	_, gamma := Keygen(G)
+	encs := encryptRandom(G, gamma, G.Gen1(), 10)
+	shuffled, proof, err := Shuffle(G, gamma, encs)
+	if err != nil {
+		panic(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !VerifyShuffle(G, gamma, encs, shuffled, proof) {
+			panic("Failed to verify the shuffle")
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// threshold.go - threshold variant of the ElGamal encryption scheme
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package elgamal
+
+import (
+	"errors"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+var (
+	// ErrThresholdParams indicates that the threshold was not in range [1, n].
+	ErrThresholdParams = errors.New("Invalid threshold parameters")
+
+	// ErrShareID indicates that the identifier of a key share or of a partial decryption was not positive.
+	ErrShareID = errors.New("Invalid identifier of the share")
+
+	// ErrCombinePartialDecryptions indicates that fewer than threshold partial decryptions
+	// or partial decryptions with repeated identifiers were provided.
+	ErrCombinePartialDecryptions = errors.New("Invalid set of partial decryptions")
+)
+
+// KeyShare represents the share d_i = f(i) of the private key d = f(0) held by party i
+// in the threshold variant of the ElGamal encryption scheme.
+type KeyShare struct {
+	id int
+	d  *Curve.BIG
+}
+
+// ID returns the identifier i of the party holding the KeyShare.
+func (ks *KeyShare) ID() int {
+	return ks.id
+}
+
+// D returns the secret scalar of the KeyShare.
+func (ks *KeyShare) D() *Curve.BIG {
+	return ks.d
+}
+
+// VerificationKey returns the public key gamma_i = g1^d_i used to verify partial decryptions
+// created with the KeyShare.
+func (ks *KeyShare) VerificationKey(G *bpgroup.BpGroup) *PublicKey {
+	return &PublicKey{
+		gamma: G.Gen1Mul(ks.d),
+	}
+}
+
+// Validate ensures the KeyShare is present, has positive identifier and its scalar is in range (0, p).
+func (ks *KeyShare) Validate() error {
+	if ks == nil {
+		return utils.ErrValidateNil
+	}
+	if ks.id < 1 {
+		return ErrShareID
+	}
+	return (&PrivateKey{d: ks.d}).Validate()
+}
+
+// NewKeyShare wraps the scalar d as the KeyShare of party with the given identifier.
+func NewKeyShare(id int, d *Curve.BIG) *KeyShare {
+	return &KeyShare{
+		id: id,
+		d:  d,
+	}
+}
+
+// ThresholdKeygen generates the public key of the threshold ElGamal encryption scheme
+// alongside n shares of the corresponding private key, any t of which are sufficient to decrypt.
+// The shares are issued to parties with identifiers 1, ..., n and returned
+// together with their verification keys.
+func ThresholdKeygen(G *bpgroup.BpGroup, t int, n int) (*PublicKey, []*KeyShare, []*PublicKey, error) {
+	if t < 1 || t > n {
+		return nil, nil, nil, ErrThresholdParams
+	}
+	p, rng := G.Order(), G.Rng()
+
+	// generate polynomial of degree t - 1, its constant term is the private key
+	coeffs := make([]*Curve.BIG, t)
+	for i := range coeffs {
+		coeffs[i] = Curve.Randomnum(p, rng)
+	}
+
+	shares := make([]*KeyShare, n)
+	vks := make([]*PublicKey, n)
+	for i := range shares {
+		iBIG := Curve.NewBIGint(i + 1)
+		d := utils.PolyEval(coeffs, iBIG, p)
+		d.Mod(p)
+		shares[i] = &KeyShare{id: i + 1, d: d}
+		vks[i] = shares[i].VerificationKey(G)
+	}
+
+	return &PublicKey{gamma: G.Gen1Mul(coeffs[0])}, shares, vks, nil
+}
+
+// PartialDecryption represents the share c1^d_i of the decryption of an Encryption
+// created by party i, alongside the proof it was computed with the KeyShare behind its verification key.
+type PartialDecryption struct {
+	id    int
+	share *Curve.ECP
+	proof *dleqProof
+}
+
+// ID returns the identifier i of the party that created the PartialDecryption.
+func (pd *PartialDecryption) ID() int {
+	return pd.id
+}
+
+// Share returns the point c1^d_i of the PartialDecryption.
+func (pd *PartialDecryption) Share() *Curve.ECP {
+	return pd.share
+}
+
+// Validate ensures the PartialDecryption is present, has positive identifier,
+// its share is a valid element of G1 and its proof is present.
+func (pd *PartialDecryption) Validate() error {
+	if pd == nil {
+		return utils.ErrValidateNil
+	}
+	if pd.id < 1 {
+		return ErrShareID
+	}
+	if err := utils.ValidateECP(pd.share); err != nil {
+		return err
+	}
+	return pd.proof.validate()
+}
+
+// PartialDecrypt creates the PartialDecryption of the Encryption with the KeyShare.
+func (ks *KeyShare) PartialDecrypt(G *bpgroup.BpGroup, enc *Encryption) (*PartialDecryption, error) {
+	if err := enc.Validate(); err != nil {
+		return nil, err
+	}
+	share := Curve.G1mul(enc.c1, ks.d)
+	vk := ks.VerificationKey(G)
+	proof := constructDLEQProof(G, partialDecryptionDomain, G.Gen1(), vk.gamma, enc.c1, share, ks.d)
+
+	return &PartialDecryption{
+		id:    ks.id,
+		share: share,
+		proof: proof,
+	}, nil
+}
+
+// VerifyPartialDecryption verifies whether the PartialDecryption of the Encryption
+// was created using the KeyShare behind the given verification key.
+func VerifyPartialDecryption(G *bpgroup.BpGroup, vk *PublicKey, enc *Encryption, pd *PartialDecryption) bool {
+	if vk.Validate() != nil || enc.Validate() != nil || pd.Validate() != nil {
+		return false
+	}
+	return verifyDLEQProof(partialDecryptionDomain, G.Gen1(), vk.gamma, enc.c1, pd.share, pd.proof)
+}
+
+// CombinePartialDecryptions takes at least t partial decryptions of the Encryption created by distinct parties
+// and returns a point on the G1 curve that represents original h^m.
+// The partial decryptions should have been verified beforehand, as an invalid one results in an incorrect point.
+func CombinePartialDecryptions(G *bpgroup.BpGroup, enc *Encryption, pds []*PartialDecryption, t int) (*Curve.ECP, error) {
+	if t < 1 || len(pds) < t {
+		return nil, ErrCombinePartialDecryptions
+	}
+	if err := enc.Validate(); err != nil {
+		return nil, err
+	}
+	p := G.Order()
+
+	seen := make(map[int]bool, len(pds))
+	xs := make([]*Curve.BIG, len(pds))
+	shares := make([]*Curve.ECP, len(pds))
+	for i, pd := range pds {
+		if err := pd.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[pd.id] {
+			return nil, ErrCombinePartialDecryptions
+		}
+		seen[pd.id] = true
+		xs[i] = Curve.NewBIGint(pd.id)
+		shares[i] = pd.share
+	}
+
+	l := make([]*Curve.BIG, len(pds))
+	for i := range pds {
+		l[i] = utils.LagrangeBasis(i, p, xs, 0)
+	}
+
+	// c1^d = c1^(sum(l_i * d_i))
+	c1d := bpgroup.G1MultiMul(shares, l)
+	dec := Curve.NewECP()
+	dec.Copy(enc.c2)
+	dec.Sub(c1d)
+	return dec, nil
+}
@@ -0,0 +1,187 @@
+// threshold_test.go - tests for threshold variant of the ElGamal encryption scheme
+// Copyright (C) 2018  Jedrzej Stuczynski.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package elgamal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jstuczyn/CoconutGo/bpgroup"
+	"github.com/jstuczyn/CoconutGo/coconut/utils"
+	Curve "github.com/jstuczyn/amcl/version3/go/amcl/BLS381"
+)
+
+func TestThresholdKeygen(t *testing.T) {
+	G := bpgroup.New()
+
+	for _, params := range [][2]int{{0, 3}, {4, 3}, {-1, 0}} {
+		_, _, _, err := ThresholdKeygen(G, params[0], params[1])
+		assert.Equal(t, ErrThresholdParams, err)
+	}
+
+	tests := []struct {
+		t int
+		n int
+	}{
+		{t: 1, n: 1},
+		{t: 1, n: 3},
+		{t: 3, n: 5},
+		{t: 5, n: 5},
+	}
+	for _, test := range tests {
+		pubk, shares, vks, err := ThresholdKeygen(G, test.t, test.n)
+		assert.Nil(t, err)
+		assert.Nil(t, pubk.Validate())
+		assert.Len(t, shares, test.n)
+		assert.Len(t, vks, test.n)
+
+		for i := range shares {
+			assert.Equal(t, i+1, shares[i].ID())
+			assert.Nil(t, shares[i].Validate())
+			assert.True(t, vks[i].Gamma().Equals(G.Gen1Mul(shares[i].D())))
+		}
+
+		// any t shares interpolate to the private key
+		xs := make([]*Curve.BIG, test.t)
+		for i := range xs {
+			xs[i] = Curve.NewBIGint(shares[test.n-test.t+i].ID())
+		}
+		d := Curve.NewBIG()
+		for i := range xs {
+			l := utils.LagrangeBasis(i, G.Order(), xs, 0)
+			d = d.Plus(Curve.Modmul(l, shares[test.n-test.t+i].D(), G.Order()))
+			d.Mod(G.Order())
+		}
+		assert.True(t, pubk.Gamma().Equals(G.Gen1Mul(d)))
+	}
+}
+
+func TestKeyShareValidate(t *testing.T) {
+	G := bpgroup.New()
+	_, shares, _, err := ThresholdKeygen(G, 2, 3)
+	assert.Nil(t, err)
+
+	var nilShare *KeyShare
+	assert.Equal(t, utils.ErrValidateNil, nilShare.Validate())
+	assert.Equal(t, utils.ErrValidateNil, NewKeyShare(1, nil).Validate())
+	assert.Equal(t, ErrShareID, NewKeyShare(0, shares[0].D()).Validate())
+	assert.Equal(t, ErrPrivateKeyRange, NewKeyShare(1, Curve.NewBIGints(Curve.CURVE_Order)).Validate())
+	assert.Nil(t, NewKeyShare(1, shares[0].D()).Validate())
+}
+
+func TestThresholdDecryption(t *testing.T) {
+	G := bpgroup.New()
+	p, rng := G.Order(), G.Rng()
+
+	tests := []struct {
+		t     int
+		n     int
+		users []int // indices of the parties taking part in the decryption
+	}{
+		{t: 1, n: 1, users: []int{0}},
+		{t: 2, n: 3, users: []int{0, 1}},
+		{t: 2, n: 3, users: []int{2, 0}},
+		{t: 3, n: 5, users: []int{4, 1, 3}},
+		{t: 3, n: 5, users: []int{0, 1, 2, 3, 4}},
+	}
+
+	for _, test := range tests {
+		pubk, shares, vks, err := ThresholdKeygen(G, test.t, test.n)
+		assert.Nil(t, err)
+
+		h := G.Gen1Mul(Curve.Randomnum(p, rng))
+		m := Curve.Randomnum(p, rng)
+		enc, _ := Encrypt(G, pubk, m, h)
+
+		pds := make([]*PartialDecryption, len(test.users))
+		for i, user := range test.users {
+			pds[i], err = shares[user].PartialDecrypt(G, enc)
+			assert.Nil(t, err)
+			assert.Equal(t, user+1, pds[i].ID())
+			assert.True(t, VerifyPartialDecryption(G, vks[user], enc, pds[i]))
+		}
+
+		hm, err := CombinePartialDecryptions(G, enc, pds, test.t)
+		assert.Nil(t, err)
+		assert.True(t, hm.Equals(Curve.G1mul(h, m)))
+
+		if test.t > 1 {
+			_, err = CombinePartialDecryptions(G, enc, pds[:test.t-1], test.t)
+			assert.Equal(t, ErrCombinePartialDecryptions, err)
+		}
+	}
+}
+
+func TestPartialDecryptionVerification(t *testing.T) {
+	G := bpgroup.New()
+	p, rng := G.Order(), G.Rng()
+
+	pubk, shares, vks, err := ThresholdKeygen(G, 2, 3)
+	assert.Nil(t, err)
+	h := G.Gen1Mul(Curve.Randomnum(p, rng))
+	enc, _ := Encrypt(G, pubk, Curve.Randomnum(p, rng), h)
+	otherEnc, _ := Encrypt(G, pubk, Curve.Randomnum(p, rng), h)
+
+	pd, err := shares[0].PartialDecrypt(G, enc)
+	assert.Nil(t, err)
+	assert.True(t, VerifyPartialDecryption(G, vks[0], enc, pd))
+	assert.False(t, VerifyPartialDecryption(G, vks[1], enc, pd), "Verification key of other party should be rejected")
+	assert.False(t, VerifyPartialDecryption(G, vks[0], otherEnc, pd), "Other encryption should be rejected")
+
+	// party that uses wrong share
+	forged := &PartialDecryption{
+		id:    pd.id,
+		share: Curve.G1mul(enc.c1, shares[1].D()),
+		proof: pd.proof,
+	}
+	assert.False(t, VerifyPartialDecryption(G, vks[0], enc, forged))
+	forged.proof = constructDLEQProof(G, partialDecryptionDomain, G.Gen1(), vks[0].Gamma(), enc.c1, forged.share, shares[1].D())
+	assert.False(t, VerifyPartialDecryption(G, vks[0], enc, forged))
+
+	// invalid partial decryption results in incorrect plaintext
+	pd2, err := shares[1].PartialDecrypt(G, enc)
+	assert.Nil(t, err)
+	hm, err := CombinePartialDecryptions(G, enc, []*PartialDecryption{forged, pd2}, 2)
+	assert.Nil(t, err)
+	correct, err := CombinePartialDecryptions(G, enc, []*PartialDecryption{pd, pd2}, 2)
+	assert.Nil(t, err)
+	assert.False(t, hm.Equals(correct))
+
+	assert.False(t, VerifyPartialDecryption(G, vks[0], enc, nil))
+	assert.False(t, VerifyPartialDecryption(G, vks[0], enc, &PartialDecryption{id: pd.id, share: pd.share}))
+
+	_, err = CombinePartialDecryptions(G, enc, []*PartialDecryption{pd, pd}, 2)
+	assert.Equal(t, ErrCombinePartialDecryptions, err)
+	_, err = CombinePartialDecryptions(G, enc, []*PartialDecryption{pd, nil}, 2)
+	assert.Equal(t, utils.ErrValidateNil, err)
+	_, err = shares[0].PartialDecrypt(G, NewEncryptionFromPoints(Curve.NewECP(), enc.c2))
+	assert.Equal(t, utils.ErrValidateIdentity, err)
+}
+
+func BenchmarkPartialDecryption(b *testing.B) {
+	G := bpgroup.New()
+	pubk, shares, _, _ := ThresholdKeygen(G, 3, 5)
+	enc, _ := Encrypt(G, pubk, Curve.Randomnum(G.Order(), G.Rng()), G.Gen1())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := shares[0].PartialDecrypt(G, enc)
+		if err != nil {
+			panic(err)
+		}
+	}
+}